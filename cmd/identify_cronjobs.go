@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
+)
+
+var cronjobCleanupIdentify = &cobra.Command{
+	Use:     "cronjob-cleanup",
+	Aliases: []string{"cc"},
+	Short:   "Identify the native cronjobs that should be removed from a specific environment",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		currentCronjobs, err := cmd.Flags().GetString("current-cronjobs")
+		if err != nil {
+			return fmt.Errorf("error reading current-cronjobs flag: %v", err)
+		}
+		generator, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		cleanup, err := IdentifyCronjobCleanup(generator, currentCronjobs)
+		if err != nil {
+			return err
+		}
+		retJSON, _ := json.Marshal(cleanup)
+		fmt.Println(string(retJSON))
+		return nil
+	},
+}
+
+// IdentifyCronjobCleanup compares the cronjobs that currently exist in the environment against the native cronjobs
+// that this build will create, and returns the names of any that are no longer required
+func IdentifyCronjobCleanup(g generator.GeneratorInput, currentCronjobs string) ([]string, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	nativeCronjobs := map[string]bool{}
+	for _, service := range lagoonBuild.BuildValues.Services {
+		for cronjobName := range service.NativeCronjobs {
+			nativeCronjobs[strings.ToLower(servicestemplates.CronjobName(service.OverrideName, cronjobName))] = true
+		}
+	}
+
+	// the current cronjobs can be provided as a space or comma separated list
+	cleanup := []string{}
+	for _, cronjob := range strings.Fields(strings.ReplaceAll(currentCronjobs, ",", " ")) {
+		if !nativeCronjobs[cronjob] {
+			cleanup = append(cleanup, cronjob)
+		}
+	}
+	return cleanup, nil
+}
+
+func init() {
+	identifyCmd.AddCommand(cronjobCleanupIdentify)
+	cronjobCleanupIdentify.Flags().StringP("current-cronjobs", "", "",
+		"The names of the cronjobs that currently exist in the environment, space or comma separated.")
+}
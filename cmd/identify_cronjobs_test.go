@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+)
+
+func TestIdentifyCronjobCleanup(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            testdata.TestData
+		currentCronjobs string
+		templatePath    string
+		want            []string
+		wantErr         bool
+	}{
+		{
+			name: "test1 - no cronjobs to remove",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "master",
+					Branch:          "master",
+					LagoonYAML:      "../internal/testdata/complex/lagoon.yml",
+				}, true),
+			currentCronjobs: "cronjob-cli-drush-cron",
+			templatePath:    "testdata/output",
+			want:            []string{},
+		},
+		{
+			name: "test2 - remove cronjobs no longer defined",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "master",
+					Branch:          "master",
+					LagoonYAML:      "../internal/testdata/complex/lagoon.yml",
+				}, true),
+			currentCronjobs: "cronjob-cli-drush-cron cronjob-cli-old-cron,cronjob-nginx-php-cleanup",
+			templatePath:    "testdata/output",
+			want:            []string{"cronjob-cli-old-cron", "cronjob-nginx-php-cleanup"},
+		},
+		{
+			name: "test3 - cronjobs moved into the pod",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "production",
+					Branch:          "production",
+					LagoonYAML:      "../internal/testdata/complex/lagoon.yml",
+				}, true),
+			currentCronjobs: "cronjob-cli-drush-cron",
+			templatePath:    "testdata/output",
+			want:            []string{"cronjob-cli-drush-cron"},
+		},
+		{
+			name: "test4 - cronjobs disabled",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "master",
+					Branch:          "master",
+					LagoonYAML:      "../internal/testdata/complex/lagoon.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_CRONJOBS_DISABLED",
+							Value: "true",
+							Scope: "build",
+						},
+					},
+				}, true),
+			currentCronjobs: "cronjob-cli-drush-cron",
+			templatePath:    "testdata/output",
+			want:            []string{"cronjob-cli-drush-cron"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+
+			got, err := IdentifyCronjobCleanup(generator, tt.currentCronjobs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IdentifyCronjobCleanup() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IdentifyCronjobCleanup() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars([]helpers.EnvironmentVariable{{Name: "DBAAS_OPERATOR_HTTP"}})
+			})
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+var imagesIdentify = &cobra.Command{
+	Use:     "images",
+	Aliases: []string{"img"},
+	Short:   "Identify the images that each service will be deployed with for a specific environment",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		images, err := IdentifyImages(generator)
+		if err != nil {
+			return err
+		}
+		retJSON, _ := json.Marshal(images)
+		fmt.Println(string(retJSON))
+		return nil
+	},
+}
+
+// IdentifyImages returns the resolved image references for every service, keyed by the service name
+// and then the deployment service type, so services with multiple containers (eg nginx-php) list the image of each container
+func IdentifyImages(g generator.GeneratorInput) (map[string]map[string]string, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	images := map[string]map[string]string{}
+	for _, service := range lagoonBuild.BuildValues.Services {
+		// services with a type of none or that don't run any pods (dbaas) have no image
+		// built images that have no reference yet are still listed, with an empty image
+		if service.OverrideName == "" || strings.HasSuffix(service.Type, "-dbaas") {
+			continue
+		}
+		if _, ok := images[service.OverrideName]; !ok {
+			images[service.OverrideName] = map[string]string{}
+		}
+		images[service.OverrideName][service.DeploymentServiceType] = service.ImageName
+	}
+	return images, nil
+}
+
+func init() {
+	identifyCmd.AddCommand(imagesIdentify)
+}
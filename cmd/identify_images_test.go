@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+)
+
+func TestIdentifyImages(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         testdata.TestData
+		templatePath string
+		want         map[string]map[string]string
+		wantErr      bool
+	}{
+		{
+			name: "test1 - nginx-php with image references",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:         "example-project",
+					EnvironmentName:     "main",
+					Branch:              "main",
+					LagoonYAML:          "../internal/testdata/nginxphp/lagoon.yml",
+					ImageReferencesFile: "../internal/testdata/nginxphp/image-references.json",
+				}, true),
+			templatePath: "testdata/output",
+			want: map[string]map[string]string{
+				"nginx": {
+					"nginx": "harbor.example.com/example-project/main/nginx@sha256:6d5a5e5e1f3a3e0f1c5b1b1d6e4b6a4f7e3b0d6c2a1f9e8d7b2001bab5d2d3f0c",
+					"php":   "harbor.example.com/example-project/main/php@sha256:1c5b1b1d6e4b6a4f7e3b0d6c2a1f9e8d7b2001bab5d2d3f0c6d5a5e5e1f3a3e0f",
+				},
+			},
+		},
+		{
+			name: "test2 - node and opensearch with image cache and no image references",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_IMAGECACHE_REGISTRY",
+							Value: "imagecache.example.com",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want: map[string]map[string]string{
+				"node": {
+					"node": "",
+				},
+				"opensearch": {
+					"opensearch": "imagecache.example.com/uselagoon/opensearch-2:latest",
+				},
+			},
+		},
+		{
+			name: "test3 - missing image references file",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:         "example-project",
+					EnvironmentName:     "main",
+					Branch:              "main",
+					LagoonYAML:          "../internal/testdata/node/lagoon.yml",
+					ImageReferencesFile: "../internal/testdata/node/missing-image-references.json",
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			got, err := IdentifyImages(generator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IdentifyImages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IdentifyImages() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars([]helpers.EnvironmentVariable{{Name: "DBAAS_OPERATOR_HTTP"}})
+			})
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	ingresstemplate "github.com/uselagoon/build-deploy-tool/internal/templating/ingress"
+	networkv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+type ingressCleanupJSON struct {
+	Autogenerated []string `json:"autogenerated"`
+	Custom        []string `json:"custom"`
+	ActiveStandby []string `json:"activeStandby"`
+	HTTPRoutes    []string `json:"httpRoutes"`
+}
+
+var ingressCleanupIdentify = &cobra.Command{
+	Use:     "ingress-cleanup",
+	Aliases: []string{"ic"},
+	Short:   "Identify the ingress and httproutes that should be removed from a specific environment",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		currentIngressFile, err := cmd.Flags().GetString("current-ingress-file")
+		if err != nil {
+			return fmt.Errorf("error reading current-ingress-file flag: %v", err)
+		}
+		currentHTTPRouteFile, err := cmd.Flags().GetString("current-httproute-file")
+		if err != nil {
+			return fmt.Errorf("error reading current-httproute-file flag: %v", err)
+		}
+		generator, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		// the current ingress are listed from the cluster, unless they have been provided in a file
+		if currentIngressFile == "" {
+			generator.KubernetesClient, err = lagoon.NewK8sClient()
+			if err != nil {
+				return fmt.Errorf("unable to create kubernetes client to list the current ingress: %v", err)
+			}
+		}
+		if currentHTTPRouteFile == "" {
+			generator.GatewayAPIClient, err = lagoon.NewGatewayAPIClient()
+			if err != nil {
+				return fmt.Errorf("unable to create gateway api client to list the current httproutes: %v", err)
+			}
+		}
+		cleanup, err := IdentifyIngressCleanup(generator, currentIngressFile, currentHTTPRouteFile)
+		if err != nil {
+			return err
+		}
+		retJSON, _ := json.Marshal(cleanup)
+		fmt.Println(string(retJSON))
+		return nil
+	},
+}
+
+// IdentifyIngressCleanup compares the ingress that currently exist in the environment against the ingress that this build
+// will create, and returns the names of any that are no longer required. the ingress are grouped by how they were created
+// using the `lagoon.sh/autogenerated` and `activestandby.lagoon.sh/migrate` labels
+// if the gateway api is enabled the routes are created as httproutes, so all of the ingress are no longer required. the
+// httproutes are compared the same way, and if the gateway api is not enabled all of the httproutes are no longer required
+// ingress used by cert-manager to solve http01 challenges, or ingress and httproutes that have the `lagoon.sh/remove=false`
+// label are never removed
+func IdentifyIngressCleanup(g generator.GeneratorInput, currentIngressFile, currentHTTPRouteFile string) (*ingressCleanupJSON, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	currentIngress, err := getCurrentIngress(g, lagoonBuild.BuildValues.Namespace, currentIngressFile)
+	if err != nil {
+		return nil, err
+	}
+	currentHTTPRoutes, err := getCurrentHTTPRoutes(g, lagoonBuild.BuildValues.Namespace, currentHTTPRouteFile, lagoonBuild.BuildValues.GatewayAPI.Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	gatewayAPI := lagoonBuild.BuildValues.GatewayAPI.Enabled
+	autogenIngress := map[string]bool{}
+	customIngress := map[string]bool{}
+	httpRoutes := map[string]bool{}
+	for _, route := range lagoonBuild.AutogeneratedRoutes.Routes {
+		if gatewayAPI {
+			for _, name := range ingresstemplate.HTTPRouteNames(route) {
+				httpRoutes[name] = true
+			}
+			continue
+		}
+		autogenIngress[route.IngressName] = true
+	}
+	for _, route := range append(lagoonBuild.MainRoutes.Routes, lagoonBuild.ActiveStandbyRoutes.Routes...) {
+		if gatewayAPI {
+			for _, name := range ingresstemplate.HTTPRouteNames(route) {
+				httpRoutes[name] = true
+			}
+			continue
+		}
+		customIngress[route.IngressName] = true
+		if route.Canary != nil {
+			customIngress[ingresstemplate.CanaryIngressName(route.IngressName)] = true
+		}
+	}
+
+	cleanup := &ingressCleanupJSON{
+		Autogenerated: []string{},
+		Custom:        []string{},
+		ActiveStandby: []string{},
+		HTTPRoutes:    []string{},
+	}
+	for _, ingress := range currentIngress {
+		if ingress.Labels["acme.cert-manager.io/http01-solver"] == "true" || ingress.Labels["lagoon.sh/remove"] == "false" {
+			continue
+		}
+		switch {
+		case ingress.Labels["lagoon.sh/autogenerated"] == "true":
+			if !autogenIngress[ingress.Name] {
+				cleanup.Autogenerated = append(cleanup.Autogenerated, ingress.Name)
+			}
+		case ingress.Labels["activestandby.lagoon.sh/migrate"] == "true":
+			if !customIngress[ingress.Name] {
+				cleanup.ActiveStandby = append(cleanup.ActiveStandby, ingress.Name)
+			}
+		default:
+			if !customIngress[ingress.Name] {
+				cleanup.Custom = append(cleanup.Custom, ingress.Name)
+			}
+		}
+	}
+	for _, httpRoute := range currentHTTPRoutes {
+		if httpRoute.Labels["lagoon.sh/remove"] == "false" {
+			continue
+		}
+		if !httpRoutes[httpRoute.Name] {
+			cleanup.HTTPRoutes = append(cleanup.HTTPRoutes, httpRoute.Name)
+		}
+	}
+	sort.Strings(cleanup.Autogenerated)
+	sort.Strings(cleanup.Custom)
+	sort.Strings(cleanup.ActiveStandby)
+	sort.Strings(cleanup.HTTPRoutes)
+	return cleanup, nil
+}
+
+// getCurrentIngress returns the ingress that currently exist in the environment, either from a file containing
+// the output of `kubectl get ingress -o json`, or by listing them from the cluster
+func getCurrentIngress(g generator.GeneratorInput, namespace, currentIngressFile string) ([]networkv1.Ingress, error) {
+	if currentIngressFile != "" {
+		ingressBytes, err := os.ReadFile(currentIngressFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read current ingress file %s: %v", currentIngressFile, err)
+		}
+		ingressList := &networkv1.IngressList{}
+		if err := json.Unmarshal(ingressBytes, ingressList); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal current ingress file %s: %v", currentIngressFile, err)
+		}
+		return ingressList.Items, nil
+	}
+	if g.KubernetesClient == nil {
+		return nil, fmt.Errorf("no current ingress file or kubernetes client provided")
+	}
+	ingressList, err := g.KubernetesClient.NetworkingV1().Ingresses(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list ingress in namespace %s: %v", namespace, err)
+	}
+	return ingressList.Items, nil
+}
+
+// getCurrentHTTPRoutes returns the httproutes that currently exist in the environment, either from a file containing
+// the output of `kubectl get httproute -o json`, or by listing them from the cluster. if the gateway api isn't installed
+// in the cluster, then there are no httproutes. if the gateway api isn't enabled for the environment, the build may not be
+// allowed to list httproutes, and then there are no httproutes to clean up either
+func getCurrentHTTPRoutes(g generator.GeneratorInput, namespace, currentHTTPRouteFile string, gatewayAPI bool) ([]gwapiv1.HTTPRoute, error) {
+	if currentHTTPRouteFile != "" {
+		httpRouteBytes, err := os.ReadFile(currentHTTPRouteFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read current httproute file %s: %v", currentHTTPRouteFile, err)
+		}
+		httpRouteList := &gwapiv1.HTTPRouteList{}
+		if err := json.Unmarshal(httpRouteBytes, httpRouteList); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal current httproute file %s: %v", currentHTTPRouteFile, err)
+		}
+		return httpRouteList.Items, nil
+	}
+	if g.GatewayAPIClient == nil {
+		return nil, fmt.Errorf("no current httproute file or gateway api client provided")
+	}
+	httpRouteList, err := g.GatewayAPIClient.GatewayV1().HTTPRoutes(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) || (!gatewayAPI && apierrors.IsForbidden(err)) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to list httproutes in namespace %s: %v", namespace, err)
+	}
+	return httpRouteList.Items, nil
+}
+
+func init() {
+	identifyCmd.AddCommand(ingressCleanupIdentify)
+	ingressCleanupIdentify.Flags().StringP("current-ingress-file", "", "",
+		"A json file containing the output of `kubectl get ingress -o json` for the environment. If not provided the ingress are listed from the cluster")
+	ingressCleanupIdentify.Flags().StringP("current-httproute-file", "", "",
+		"A json file containing the output of `kubectl get httproute -o json` for the environment. If not provided the httproutes are listed from the cluster")
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+	networkv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayfake "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/fake"
+)
+
+func TestIdentifyIngressCleanup(t *testing.T) {
+	tests := []struct {
+		name                 string
+		args                 testdata.TestData
+		currentIngressFile   string
+		currentHTTPRouteFile string
+		objects              []runtime.Object
+		httpRoutes           []runtime.Object
+		httpRouteListErr     error
+		templatePath         string
+		want                 *ingressCleanupJSON
+		wantErr              bool
+	}{
+		{
+			name: "test1 - active environment with ingress from a file",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:        "example-project",
+					EnvironmentName:    "main",
+					Branch:             "main",
+					ActiveEnvironment:  "main",
+					StandbyEnvironment: "main-sb",
+					LagoonYAML:         "../internal/testdata/node/lagoon.activestandby.yml",
+				}, true),
+			currentIngressFile: "../internal/testdata/node/current-ingress.json",
+			templatePath:       "testdata/output",
+			want: &ingressCleanupJSON{
+				Autogenerated: []string{"nginx"},
+				Custom:        []string{"old.example.com"},
+				ActiveStandby: []string{"standby.example.com"},
+				HTTPRoutes:    []string{},
+			},
+		},
+		{
+			name: "test2 - ingress from the cluster",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.activestandby.yml",
+				}, true),
+			objects: []runtime.Object{
+				&networkv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "node",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/autogenerated": "true"},
+					},
+				},
+				&networkv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "main.example.com",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/autogenerated": "false"},
+					},
+				},
+				&networkv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "active.example.com",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/autogenerated": "false", "activestandby.lagoon.sh/migrate": "true"},
+					},
+				},
+				&networkv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "other.example.com",
+						Namespace: "example-project-other",
+						Labels:    map[string]string{"lagoon.sh/autogenerated": "false"},
+					},
+				},
+			},
+			templatePath: "testdata/output",
+			want: &ingressCleanupJSON{
+				Autogenerated: []string{},
+				Custom:        []string{},
+				ActiveStandby: []string{"active.example.com"},
+				HTTPRoutes:    []string{},
+			},
+		},
+		{
+			name: "test3 - missing current ingress file",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.activestandby.yml",
+				}, true),
+			currentIngressFile: "../internal/testdata/node/missing-current-ingress.json",
+			templatePath:       "testdata/output",
+			wantErr:            true,
+		},
+		{
+			name: "test4 - gateway api enabled",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.activestandby.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_GATEWAY_API_ROUTES",
+							Value: "enabled",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FEATURE_FLAG_GATEWAY_API_PARENT",
+							Value: "gateway-system/lagoon-gateway",
+							Scope: "build",
+						},
+					},
+				}, true),
+			objects: []runtime.Object{
+				&networkv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "node",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/autogenerated": "true"},
+					},
+				},
+				&networkv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "main.example.com",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/autogenerated": "false"},
+					},
+				},
+				&networkv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "keep.example.com",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/autogenerated": "false", "lagoon.sh/remove": "false"},
+					},
+				},
+			},
+			httpRoutes: []runtime.Object{
+				testHTTPRoute("node", "true"),
+				// autogenerated routes allow insecure traffic, so they have no redirect httproute
+				testHTTPRoute("node-redirect", "true"),
+				testHTTPRoute("main.example.com", "false"),
+				testHTTPRoute("main.example.com-redirect", "false"),
+				testHTTPRoute("old.example.com", "false"),
+				testHTTPRoute("old.example.com-redirect", "false"),
+			},
+			templatePath: "testdata/output",
+			want: &ingressCleanupJSON{
+				Autogenerated: []string{"node"},
+				Custom:        []string{"main.example.com"},
+				ActiveStandby: []string{},
+				HTTPRoutes:    []string{"node-redirect", "old.example.com", "old.example.com-redirect"},
+			},
+		},
+		{
+			name: "test5 - gateway api disabled with httproutes from a file",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.activestandby.yml",
+				}, true),
+			currentHTTPRouteFile: "../internal/testdata/node/current-httproute.json",
+			templatePath:         "testdata/output",
+			want: &ingressCleanupJSON{
+				Autogenerated: []string{},
+				Custom:        []string{},
+				ActiveStandby: []string{},
+				HTTPRoutes:    []string{"main.example.com", "main.example.com-redirect"},
+			},
+		},
+		{
+			name: "test6 - gateway api disabled and not allowed to list httproutes",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.activestandby.yml",
+				}, true),
+			httpRouteListErr: apierrors.NewForbidden(gwapiv1.Resource("httproutes"), "", errors.New("forbidden")),
+			templatePath:     "testdata/output",
+			want: &ingressCleanupJSON{
+				Autogenerated: []string{},
+				Custom:        []string{},
+				ActiveStandby: []string{},
+				HTTPRoutes:    []string{},
+			},
+		},
+		{
+			name: "test7 - gateway api enabled and not allowed to list httproutes",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.activestandby.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_GATEWAY_API_ROUTES",
+							Value: "enabled",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FEATURE_FLAG_GATEWAY_API_PARENT",
+							Value: "gateway-system/lagoon-gateway",
+							Scope: "build",
+						},
+					},
+				}, true),
+			httpRouteListErr: apierrors.NewForbidden(gwapiv1.Resource("httproutes"), "", errors.New("forbidden")),
+			templatePath:     "testdata/output",
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			if tt.currentIngressFile == "" {
+				generator.KubernetesClient = fake.NewSimpleClientset(tt.objects...)
+			}
+			if tt.currentHTTPRouteFile == "" {
+				gatewayClient := gatewayfake.NewSimpleClientset(tt.httpRoutes...)
+				if tt.httpRouteListErr != nil {
+					gatewayClient.PrependReactor("list", "httproutes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+						return true, nil, tt.httpRouteListErr
+					})
+				}
+				generator.GatewayAPIClient = gatewayClient
+			}
+			got, err := IdentifyIngressCleanup(generator, tt.currentIngressFile, tt.currentHTTPRouteFile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IdentifyIngressCleanup() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IdentifyIngressCleanup() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars([]helpers.EnvironmentVariable{{Name: "DBAAS_OPERATOR_HTTP"}})
+			})
+		})
+	}
+}
+
+func testHTTPRoute(name, autogenerated string) *gwapiv1.HTTPRoute {
+	return &gwapiv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "example-project-main",
+			Labels:    map[string]string{"lagoon.sh/autogenerated": autogenerated},
+		},
+	}
+}
@@ -147,6 +147,10 @@ func init() {
 		"The fastly service ID to use")
 	rootCmd.PersistentFlags().StringP("fastly-api-secret-prefix", "A", "fastly-api-",
 		"The fastly secret prefix to use")
+	rootCmd.PersistentFlags().StringP("dynamic-secrets-file", "", "",
+		"A file containing the names of the dynamic secrets to mount into services, one per line. If not provided the secrets are discovered from the cluster where required")
+	rootCmd.PersistentFlags().StringP("image-references-file", "", "",
+		"A json file containing the references of the images built or pulled during the build, keyed by the docker-compose service name")
 	rootCmd.PersistentFlags().BoolP("ignore-non-string-key-errors", "", true,
 		"Ignore non-string-key docker-compose errors (true by default, subject to change).")
 	rootCmd.PersistentFlags().BoolP("ignore-missing-env-files", "", true,
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// taskReportOutputSize is the number of bytes at the end of the output of a task that are kept in the report
+const taskReportOutputSize = 4096
+
+const (
+	taskStatusSucceeded = "succeeded"
+	taskStatusFailed    = "failed"
+	taskStatusSkipped   = "skipped"
+)
+
+// taskReport contains the results of the tasks that were run, it is written to the file given with the `--report` flag
+type taskReport struct {
+	Type  string       `json:"type"`
+	Tasks []taskResult `json:"tasks"`
+	lock  sync.Mutex
+}
+
+// taskResult is the result of a single task, tasks are added to the report in the order they complete
+type taskResult struct {
+	Name            string     `json:"name"`
+	Service         string     `json:"service"`
+	Container       string     `json:"container,omitempty"`
+	Status          string     `json:"status"`
+	StartTime       *time.Time `json:"startTime,omitempty"`
+	EndTime         *time.Time `json:"endTime,omitempty"`
+	DurationSeconds float64    `json:"durationSeconds,omitempty"`
+	Attempts        int        `json:"attempts,omitempty"`
+	ExitCode        *int       `json:"exitCode,omitempty"`
+	SkipReason      string     `json:"skipReason,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	Output          string     `json:"output,omitempty"`
+}
+
+func newTaskReport(prePost string) *taskReport {
+	return &taskReport{
+		Type:  prePost,
+		Tasks: []taskResult{},
+	}
+}
+
+// skipped adds a task that wasn't run to the report
+func (r *taskReport) skipped(task lagoon.Task, reason string) {
+	if r == nil {
+		return
+	}
+	r.add(taskResult{
+		Name:       task.Name,
+		Service:    task.Service,
+		Container:  task.Container,
+		Status:     taskStatusSkipped,
+		SkipReason: reason,
+	})
+}
+
+// completed adds a task that was run to the report, a task that failed because the deployment of its service is missing
+// is only a failure if missing deployments aren't allowed
+func (r *taskReport) completed(task lagoon.Task, startTime, endTime time.Time, attempts int, err error, output *tailWriter, allowDeployMissingErrors bool) {
+	if r == nil {
+		return
+	}
+	result := taskResult{
+		Name:            task.Name,
+		Service:         task.Service,
+		Container:       task.Container,
+		Status:          taskStatusSucceeded,
+		StartTime:       &startTime,
+		EndTime:         &endTime,
+		DurationSeconds: endTime.Sub(startTime).Seconds(),
+		Attempts:        attempts,
+	}
+	if output != nil {
+		result.Output = output.String()
+	}
+	var deploymentMissing *lagoon.DeploymentMissingError
+	var exitErr utilexec.ExitError
+	switch {
+	case err == nil:
+		exitCode := 0
+		result.ExitCode = &exitCode
+	case errors.As(err, &deploymentMissing) && allowDeployMissingErrors:
+		result.Status = taskStatusSkipped
+		result.SkipReason = err.Error()
+	default:
+		result.Status = taskStatusFailed
+		result.Error = err.Error()
+		if errors.As(err, &exitErr) {
+			exitCode := exitErr.ExitStatus()
+			result.ExitCode = &exitCode
+		}
+	}
+	r.add(result)
+}
+
+// failed adds a task that failed before it could be run to the report, eg its when condition couldn't be evaluated
+func (r *taskReport) failed(task lagoon.Task, err error) {
+	if r == nil {
+		return
+	}
+	r.add(taskResult{
+		Name:      task.Name,
+		Service:   task.Service,
+		Container: task.Container,
+		Status:    taskStatusFailed,
+		Error:     err.Error(),
+	})
+}
+
+func (r *taskReport) add(result taskResult) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Tasks = append(r.Tasks, result)
+}
+
+// writeTaskReport writes the report to the file as json
+func writeTaskReport(reportFile string, report *taskReport) error {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal task report: %v", err)
+	}
+	if err := os.WriteFile(reportFile, reportBytes, 0644); err != nil {
+		return fmt.Errorf("unable to write task report %s: %v", reportFile, err)
+	}
+	return nil
+}
+
+// tailWriter keeps the last bytes that are written to it, the stdout and stderr of a task are written from different
+// goroutines so writes are locked
+type tailWriter struct {
+	size int
+	buf  []byte
+	lock sync.Mutex
+}
+
+func newTailWriter(size int) *tailWriter {
+	return &tailWriter{size: size}
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.size {
+		w.buf = w.buf[len(w.buf)-w.size:]
+	}
+	return len(p), nil
+}
+
+// String returns the output that was kept, the output may have been cut in the middle of a character so any invalid
+// characters are removed
+func (w *tailWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return strings.ToValidUTF8(string(w.buf), "")
+}
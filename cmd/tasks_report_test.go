@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/tasklib"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func Test_tailWriter(t *testing.T) {
+	w := newTailWriter(10)
+	fmt.Fprint(w, "first line\n")
+	fmt.Fprint(w, "second line\n")
+	if got := w.String(); got != "cond line\n" {
+		t.Errorf("tailWriter kept %q, want %q", got, "cond line\n")
+	}
+	// a character cut in half is removed
+	w = newTailWriter(3)
+	fmt.Fprint(w, "a€")
+	if got := w.String(); got != "€" {
+		t.Errorf("tailWriter kept %q, want %q", got, "€")
+	}
+	fmt.Fprint(w, "b")
+	if got := w.String(); got != "b" {
+		t.Errorf("tailWriter kept %q, want %q", got, "b")
+	}
+}
+
+func Test_taskReport(t *testing.T) {
+	report := newTaskReport("Pre-Rollout")
+	taskRunner := func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+		fmt.Fprintf(incoming.GetStdout(), "running %s\n", incoming.Name)
+		switch incoming.Name {
+		case "missing":
+			return &lagoon.DeploymentMissingError{ErrorText: "No deployments found matching label: lagoon.sh/service=solr"}
+		case "fails":
+			return fmt.Errorf("Error returned: %w", utilexec.CodeExitError{Err: errors.New("command terminated with exit code 3"), Code: 3})
+		}
+		return nil
+	}
+	tasks := []lagoon.Task{
+		{Name: "succeeds", Service: "cli"},
+		{Name: "conditional", Service: "cli", When: "false"},
+		{Name: "missing", Service: "solr"},
+		{Name: "fails", Service: "cli", Container: "cli", Retries: 1},
+		{Name: "never run", Service: "cli"},
+	}
+	iterator, _ := iterateTaskGenerator(context.Background(), true, taskRunner, generator.BuildValues{Namespace: "empty"}, "Pre-Rollout", 1, report, false)
+	if _, err := iterator(tasklib.TaskEnvironment{}, tasks); err == nil {
+		t.Fatalf("expected the fails task to return an error")
+	}
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	if err := writeTaskReport(reportFile, report); err != nil {
+		t.Fatalf("writeTaskReport() error = %v", err)
+	}
+	reportBytes, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("couldn't read report: %v", err)
+	}
+	got := &taskReport{}
+	if err := json.Unmarshal(reportBytes, got); err != nil {
+		t.Fatalf("couldn't unmarshal report: %v", err)
+	}
+	if got.Type != "Pre-Rollout" || len(got.Tasks) != 5 {
+		t.Fatalf("report = %s, want 5 Pre-Rollout tasks", string(reportBytes))
+	}
+
+	succeeds := got.Tasks[0]
+	if succeeds.Name != "succeeds" || succeeds.Status != "succeeded" || succeeds.ExitCode == nil || *succeeds.ExitCode != 0 ||
+		succeeds.Attempts != 1 || succeeds.StartTime == nil || succeeds.EndTime == nil || succeeds.Output != "running succeeds\n" {
+		t.Errorf("succeeds result = %+v", succeeds)
+	}
+	conditional := got.Tasks[1]
+	if conditional.Status != "skipped" || conditional.SkipReason != "the when condition 'false' evaluated to false" || conditional.StartTime != nil {
+		t.Errorf("conditional result = %+v", conditional)
+	}
+	missing := got.Tasks[2]
+	if missing.Status != "skipped" || missing.SkipReason != "No deployments found matching label: lagoon.sh/service=solr" || missing.ExitCode != nil {
+		t.Errorf("missing result = %+v", missing)
+	}
+	fails := got.Tasks[3]
+	if fails.Status != "failed" || fails.ExitCode == nil || *fails.ExitCode != 3 || fails.Attempts != 2 ||
+		fails.Container != "cli" || fails.Output != "running fails\nTask fails failed on attempt 1/2, retrying in 0s\nrunning fails\n" {
+		t.Errorf("fails result = %+v", fails)
+	}
+	neverRun := got.Tasks[4]
+	if neverRun.Name != "never run" || neverRun.Status != "skipped" || neverRun.SkipReason != "not run because task fails failed" || neverRun.StartTime != nil {
+		t.Errorf("never run result = %+v", neverRun)
+	}
+}
+
+func Test_taskReportDependencies(t *testing.T) {
+	report := newTaskReport("Post-Rollout")
+	taskRunner := func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+		if incoming.Name == "deploy" {
+			return fmt.Errorf("Error returned: %w", utilexec.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1})
+		}
+		return nil
+	}
+	tasks := []lagoon.Task{
+		{Name: "deploy", Service: "cli"},
+		{Name: "warm cache", Service: "cli", DependsOn: []string{"deploy"}},
+		{Service: "cli", DependsOn: []string{"warm cache"}},
+	}
+	iterator, _ := iterateTaskGenerator(context.Background(), false, taskRunner, generator.BuildValues{Namespace: "empty"}, "Post-Rollout", 2, report, false)
+	if _, err := iterator(tasklib.TaskEnvironment{}, tasks); err == nil {
+		t.Fatalf("expected the deploy task to return an error")
+	}
+	if len(report.Tasks) != 3 {
+		t.Fatalf("report = %+v, want 3 tasks", report.Tasks)
+	}
+	if report.Tasks[0].Name != "deploy" || report.Tasks[0].Status != "failed" {
+		t.Errorf("deploy result = %+v", report.Tasks[0])
+	}
+	for _, result := range report.Tasks[1:] {
+		if result.Status != "skipped" || result.SkipReason != "not run because task deploy failed" {
+			t.Errorf("%s result = %+v", result.Name, result)
+		}
+	}
+}
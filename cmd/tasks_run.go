@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
@@ -26,26 +31,41 @@ var taskCmd = &cobra.Command{
 	Long:    `Will run Pre/Post/etc. tasks defined in a .lagoon.yml`,
 }
 
-// unidleThenRun is a wrapper around 'runCleanTaskInEnvironment' used for pre-rollout tasks
+// unidleLock stops tasks that run at the same time from unidling the namespace at the same time
+var unidleLock sync.Mutex
+
+// unidleNamespaceFuncType is the function used to unidle the namespace, it is passed to unidleThenRun so it can be replaced in tests
+type unidleNamespaceFuncType func(ctx context.Context, namespace string, retries int, waitTime int) error
+
+// unidleThenRun is a wrapper around a task runner used for pre-rollout tasks
 // We actually want to unidle the namespace before running pre-rollout tasks,
 // so we wrap the usual task runner before calling it.
-func unidleThenRun(namespace string, prePost string, incoming lagoon.Task) error {
-	fmt.Printf("Unidling namespace with RequiresEnvironment: %v, ScaleMaxIterations:%v and ScaleWaitTime:%v\n", incoming.RequiresEnvironment, incoming.ScaleMaxIterations, incoming.ScaleWaitTime)
-	err := lagoon.UnidleNamespace(context.TODO(), namespace, incoming.ScaleMaxIterations, incoming.ScaleWaitTime)
-	if err != nil {
-		switch {
-		case errors.Is(err, lagoon.NamespaceUnidlingTimeoutError):
-			if !incoming.RequiresEnvironment { // we don't have to kill this build if we can't bring the services up, so we just note the issue and continue
-				fmt.Println("Namespace unidling is taking longer than expected - this might affect pre-rollout tasks that rely on multiple services")
-			} else {
-				return fmt.Errorf("Unable to unidle the environment for pre-rollout tasks in time (waited %v seconds, retried %v times) - exiting as the task is defined as requiring the environment to be up.",
-					incoming.ScaleWaitTime, incoming.ScaleMaxIterations)
+// tasks that run in a job don't need a running pod, so the namespace isn't unidled for them
+func unidleThenRun(unidle unidleNamespaceFuncType, taskRunner runTaskInEnvironmentFuncType) runTaskInEnvironmentFuncType {
+	return func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+		if incoming.Executor == lagoon.TaskExecutorJob {
+			return taskRunner(ctx, namespace, prePost, incoming)
+		}
+		// tasks that run at the same time unidle the namespace one at a time, so they don't try to scale the same deployments
+		unidleLock.Lock()
+		fmt.Fprintf(incoming.GetStdout(), "Unidling namespace with RequiresEnvironment: %v, ScaleMaxIterations:%v and ScaleWaitTime:%v\n", incoming.RequiresEnvironment, incoming.ScaleMaxIterations, incoming.ScaleWaitTime)
+		err := unidle(ctx, namespace, incoming.ScaleMaxIterations, incoming.ScaleWaitTime)
+		unidleLock.Unlock()
+		if err != nil {
+			switch {
+			case errors.Is(err, lagoon.NamespaceUnidlingTimeoutError):
+				if !incoming.RequiresEnvironment { // we don't have to kill this build if we can't bring the services up, so we just note the issue and continue
+					fmt.Fprintln(incoming.GetStdout(), "Namespace unidling is taking longer than expected - this might affect pre-rollout tasks that rely on multiple services")
+				} else {
+					return fmt.Errorf("Unable to unidle the environment for pre-rollout tasks in time (waited %v seconds, retried %v times) - exiting as the task is defined as requiring the environment to be up.",
+						incoming.ScaleWaitTime, incoming.ScaleMaxIterations)
+				}
+			default:
+				return fmt.Errorf("There was a problem when unidling the environment for pre-rollout tasks: %v", err.Error())
 			}
-		default:
-			return fmt.Errorf("There was a problem when unidling the environment for pre-rollout tasks: %v", err.Error())
 		}
+		return taskRunner(ctx, namespace, prePost, incoming)
 	}
-	return runCleanTaskInEnvironment(namespace, prePost, incoming)
 }
 
 var tasksPreRun = &cobra.Command{
@@ -53,6 +73,14 @@ var tasksPreRun = &cobra.Command{
 	Aliases: []string{"pre"},
 	Short:   "Will run pre rollout tasks defined in .lagoon.yml",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		maxParallel, err := cmd.Flags().GetInt("max-parallel")
+		if err != nil {
+			return fmt.Errorf("error reading max-parallel flag: %v", err)
+		}
+		reportFile, err := cmd.Flags().GetString("report")
+		if err != nil {
+			return fmt.Errorf("error reading report flag: %v", err)
+		}
 		generator, err := generator.GenerateInput(*rootCmd, true)
 		if err != nil {
 			return err
@@ -63,13 +91,27 @@ var tasksPreRun = &cobra.Command{
 		}
 		fmt.Println("Executing Pre-rollout Tasks")
 
-		taskIterator, err := iterateTaskGenerator(true, unidleThenRun, buildValues, "Pre-Rollout", true)
+		// cancel any running task if the build is cancelled
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var report *taskReport
+		if reportFile != "" {
+			report = newTaskReport("Pre-Rollout")
+		}
+		taskIterator, err := iterateTaskGenerator(ctx, true, unidleThenRun(lagoon.UnidleNamespace, runCleanTaskInEnvironment), buildValues, "Pre-Rollout", maxParallel, report, true)
 		if err != nil {
 			fmt.Println("Pre-rollout Tasks Failed with the following error: ", err.Error())
 			os.Exit(1)
 		}
 
 		err = runTasks(taskIterator, lYAML.Tasks.Prerollout, lagoonConditionalEvaluationEnvironment)
+		// the report is written even if a task failed, so the failure can be seen in the report
+		if report != nil {
+			if reportErr := writeTaskReport(reportFile, report); reportErr != nil {
+				fmt.Println(reportErr.Error())
+			}
+		}
 		if err != nil {
 			fmt.Println("Pre-rollout Tasks Failed with the following error: ", err.Error())
 			os.Exit(1)
@@ -84,6 +126,14 @@ var tasksPostRun = &cobra.Command{
 	Aliases: []string{"post"},
 	Short:   "Will run post rollout tasks defined in .lagoon.yml",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		maxParallel, err := cmd.Flags().GetInt("max-parallel")
+		if err != nil {
+			return fmt.Errorf("error reading max-parallel flag: %v", err)
+		}
+		reportFile, err := cmd.Flags().GetString("report")
+		if err != nil {
+			return fmt.Errorf("error reading report flag: %v", err)
+		}
 		generator, err := generator.GenerateInput(*rootCmd, true)
 		if err != nil {
 			return err
@@ -95,12 +145,26 @@ var tasksPostRun = &cobra.Command{
 
 		fmt.Println("Executing Post-rollout Tasks")
 
-		taskIterator, err := iterateTaskGenerator(false, runCleanTaskInEnvironment, buildValues, "Post-Rollout", true)
+		// cancel any running task if the build is cancelled
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var report *taskReport
+		if reportFile != "" {
+			report = newTaskReport("Post-Rollout")
+		}
+		taskIterator, err := iterateTaskGenerator(ctx, false, runCleanTaskInEnvironment, buildValues, "Post-Rollout", maxParallel, report, true)
 		if err != nil {
 			fmt.Println("Pre-rollout Tasks Failed with the following error: ", err.Error())
 			os.Exit(1)
 		}
 		err = runTasks(taskIterator, lYAML.Tasks.Postrollout, lagoonConditionalEvaluationEnvironment)
+		// the report is written even if a task failed, so the failure can be seen in the report
+		if report != nil {
+			if reportErr := writeTaskReport(reportFile, report); reportErr != nil {
+				fmt.Println(reportErr.Error())
+			}
+		}
 		if err != nil {
 			fmt.Println("Post-rollout Tasks Failed with the following error: ", err.Error())
 			os.Exit(1)
@@ -132,7 +196,7 @@ func getEnvironmentInfo(g generator.GeneratorInput) (lagoon.YAML, tasklib.TaskEn
 // and the environment in which conditional statements are going to be run (i.e. a list of variables available to "where" clauses) and runs them.
 func runTasks(taskRunner iterateTaskFuncType, tasks []lagoon.TaskRun, lagoonConditionalEvaluationEnvironment tasklib.TaskEnvironment) error {
 
-	done, err := taskRunner(lagoonConditionalEvaluationEnvironment, unwindTaskRun(tasks))
+	done, err := taskRunner(lagoonConditionalEvaluationEnvironment, lagoon.UnwindTaskRuns(tasks))
 	if done {
 		return err
 	}
@@ -140,15 +204,6 @@ func runTasks(taskRunner iterateTaskFuncType, tasks []lagoon.TaskRun, lagoonCond
 	return nil
 }
 
-// unwindTaskRun simply reformats a []lagoon.TaskRun structure. It gets rid of the nested "run" field so that the array is flatter.
-func unwindTaskRun(taskRun []lagoon.TaskRun) []lagoon.Task {
-	var tasks []lagoon.Task
-	for _, taskrun := range taskRun {
-		tasks = append(tasks, taskrun.Run)
-	}
-	return tasks
-}
-
 // iterateTaskFuncType defines what a function that runs tasks looks like. There's an environment to evaluate a task,
 // as well as the task definition itself.
 type iterateTaskFuncType func(tasklib.TaskEnvironment, []lagoon.Task) (bool, error)
@@ -157,10 +212,17 @@ type iterateTaskFuncType func(tasklib.TaskEnvironment, []lagoon.Task) (bool, err
 // that lets the resulting function reference values as part of the closure, thereby cleaning up the definition a bit.
 // so, the variables passed into the factor (eg. allowDeployMissingErrors, etc.) determine the way the function behaves,
 // without needing to pass those into the call to the returned function itself.
-func iterateTaskGenerator(allowDeployMissingErrors bool, taskRunner runTaskInEnvironmentFuncType, buildValues generator.BuildValues, prePost string, debug bool) (iterateTaskFuncType, error) {
+func iterateTaskGenerator(ctx context.Context, allowDeployMissingErrors bool, taskRunner runTaskInEnvironmentFuncType, buildValues generator.BuildValues, prePost string, maxParallel int, report *taskReport, debug bool) (iterateTaskFuncType, error) {
 	var retErr error
 	return func(lagoonConditionalEvaluationEnvironment tasklib.TaskEnvironment, tasks []lagoon.Task) (bool, error) {
+		// check the timeouts and retry policies of all the tasks before running any of them
 		for _, task := range tasks {
+			if err := task.Validate(); err != nil {
+				return true, err
+			}
+		}
+		// runTask runs a single task, it returns an error if the task failed and no more tasks should be run
+		runTask := func(task lagoon.Task) error {
 			// set the iterations and wait times here
 			if task.ScaleMaxIterations == 0 {
 				task.ScaleMaxIterations = buildValues.TaskScaleMaxIterations
@@ -168,31 +230,71 @@ func iterateTaskGenerator(allowDeployMissingErrors bool, taskRunner runTaskInEnv
 			if task.ScaleWaitTime == 0 {
 				task.ScaleWaitTime = buildValues.TaskScaleWaitTime
 			}
-			runTask, err := evaluateWhenConditionsForTaskInEnvironment(lagoonConditionalEvaluationEnvironment, task, debug)
+			shouldRun, err := evaluateWhenConditionsForTaskInEnvironment(lagoonConditionalEvaluationEnvironment, task, debug)
 			if err != nil {
-				return true, err
+				report.failed(task, err)
+				return err
 			}
-			if runTask {
-				err := taskRunner(buildValues.Namespace, prePost, task)
+			if shouldRun {
+				// keep the end of the output of the task for the report
+				var output *tailWriter
+				if report != nil {
+					output = newTailWriter(taskReportOutputSize)
+					task.Stdout = io.MultiWriter(task.GetStdout(), output)
+					task.Stderr = io.MultiWriter(task.GetStderr(), output)
+				}
+				attempts := 0
+				countAttempts := func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+					attempts++
+					return taskRunner(ctx, namespace, prePost, incoming)
+				}
+				startTime := time.Now()
+				err := runTaskWithRetries(ctx, countAttempts, buildValues.Namespace, prePost, task)
+				report.completed(task, startTime, time.Now(), attempts, err, output, allowDeployMissingErrors)
 				if err != nil {
 					switch e := err.(type) {
 					case *lagoon.DeploymentMissingError:
 						if allowDeployMissingErrors {
 							if debug {
-								fmt.Println("No running deployment found, skipping")
+								fmt.Fprintln(task.GetStdout(), "No running deployment found, skipping")
 							}
 						} else {
-							return true, e
+							return e
 						}
 					default:
-						return true, e
+						return e
 					}
 				}
 			} else {
+				report.skipped(task, fmt.Sprintf("the when condition '%v' evaluated to false", task.When))
 				if debug {
-					fmt.Printf("Conditional '%v' for task: \n '%v' \n evaluated to false, skipping\n", task.When, task.Command)
+					fmt.Fprintf(task.GetStdout(), "Conditional '%v' for task: \n '%v' \n evaluated to false, skipping\n", task.When, task.Command)
 				}
 			}
+			return nil
+		}
+		// notRun adds a task that was never started because another task failed to the report, so every task is in the report
+		notRun := func(task lagoon.Task, failed int) {
+			reason := fmt.Sprintf("not run because task %s failed", lagoon.TaskDisplayName(tasks[failed], failed))
+			if ctx.Err() != nil {
+				reason = "not run because the tasks were cancelled"
+			}
+			report.skipped(task, reason)
+		}
+		// tasks without any dependencies are run one at a time in the order they are defined
+		if !lagoon.TasksHaveDependencies(tasks) {
+			for i, task := range tasks {
+				if err := runTask(task); err != nil {
+					for _, remaining := range tasks[i+1:] {
+						notRun(remaining, i)
+					}
+					return true, err
+				}
+			}
+			return false, nil
+		}
+		if err := runTaskGraph(tasks, maxParallel, runTask, notRun); err != nil {
+			return true, err
 		}
 		return false, nil
 	}, retErr
@@ -207,12 +309,12 @@ func evaluateWhenConditionsForTaskInEnvironment(environment tasklib.TaskEnvironm
 		return true, nil
 	}
 	if debug {
-		fmt.Println("Evaluating task condition - ", task.When)
+		fmt.Fprintln(task.GetStdout(), "Evaluating task condition - ", task.When)
 	}
 	ret, err := tasklib.EvaluateExpressionsInTaskEnvironment(task.When, environment)
 	if err != nil {
 		if debug {
-			fmt.Println("Error evaluating condition: ", err.Error())
+			fmt.Fprintln(task.GetStdout(), "Error evaluating condition: ", err.Error())
 		}
 		return false, err
 	}
@@ -220,19 +322,41 @@ func evaluateWhenConditionsForTaskInEnvironment(environment tasklib.TaskEnvironm
 	if !okay {
 		err := fmt.Errorf("Expression doesn't evaluate to a boolean")
 		if debug {
-			fmt.Println(err.Error())
+			fmt.Fprintln(task.GetStdout(), err.Error())
 		}
 		return false, err
 	}
 	return retBool, nil
 }
 
-type runTaskInEnvironmentFuncType func(namespace string, prePost string, incoming lagoon.Task) error
+// runTaskWithRetries runs the task, and if it fails with a failure that the task is configured to retry, runs it again
+// after the retry delay until it succeeds or it has been attempted `retries` + 1 times
+func runTaskWithRetries(ctx context.Context, taskRunner runTaskInEnvironmentFuncType, namespace string, prePost string, task lagoon.Task) error {
+	// the retry delay is checked before any tasks are run
+	retryDelay, _ := task.RetryDelayDuration()
+	var err error
+	for attempt := 1; attempt <= task.Retries+1; attempt++ {
+		task.Attempt = attempt
+		err = taskRunner(ctx, namespace, prePost, task)
+		if err == nil || attempt > task.Retries || !task.Retryable(err) {
+			return err
+		}
+		fmt.Fprintf(task.GetStdout(), "Task %s failed on attempt %d/%d, retrying in %s\n", task.Name, attempt, task.Retries+1, retryDelay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelay):
+		}
+	}
+	return err
+}
+
+type runTaskInEnvironmentFuncType func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error
 
 // runCleanTaskInEnvironment implements runTaskInEnvironmentFuncType and will
 // 1. make sure the task we pass to the execution environment is free of any data we don't want (hence the new task)
 // 2. will actually execute the task in the environment.
-func runCleanTaskInEnvironment(namespace string, prePost string, incoming lagoon.Task) error {
+func runCleanTaskInEnvironment(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
 	task := lagoon.NewTask()
 	task.Command = incoming.Command
 	task.Namespace = namespace
@@ -242,7 +366,14 @@ func runCleanTaskInEnvironment(namespace string, prePost string, incoming lagoon
 	task.Name = incoming.Name
 	task.ScaleMaxIterations = incoming.ScaleMaxIterations
 	task.ScaleWaitTime = incoming.ScaleWaitTime
-	err := lagoon.ExecuteTaskInEnvironment(task, prePost)
+	task.Timeout = incoming.Timeout
+	task.Executor = incoming.Executor
+	task.Retries = incoming.Retries
+	task.RetryOn = incoming.RetryOn
+	task.Attempt = incoming.Attempt
+	task.Stdout = incoming.Stdout
+	task.Stderr = incoming.Stderr
+	err := lagoon.ExecuteTaskInEnvironment(ctx, task, prePost)
 	return err
 }
 
@@ -253,6 +384,10 @@ func init() {
 	addArgs := func(command *cobra.Command) {
 		command.Flags().StringP("namespace", "n", "",
 			"The environments environment variables JSON payload")
+		command.Flags().IntP("max-parallel", "", 4,
+			"The maximum number of tasks to run at the same time, only tasks that define dependencies with `dependsOn` are run at the same time")
+		command.Flags().StringP("report", "", "",
+			"A file to write a JSON report of the results of the tasks to")
 	}
 	addArgs(tasksPreRun)
 	addArgs(tasksPostRun)
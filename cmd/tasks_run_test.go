@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 	"github.com/uselagoon/build-deploy-tool/internal/tasklib"
+	utilexec "k8s.io/client-go/util/exec"
 )
 
 func Test_evaluateWhenConditionsForTaskInEnvironment(t *testing.T) {
@@ -56,11 +62,17 @@ func Test_evaluateWhenConditionsForTaskInEnvironment(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := evaluateWhenConditionsForTaskInEnvironment(tt.args.environment, tt.args.task, false)
+			// the debug output is written to the output of the task, so it can be prefixed when tasks run at the same time
+			var out bytes.Buffer
+			tt.args.task.Stdout = &out
+			got, err := evaluateWhenConditionsForTaskInEnvironment(tt.args.environment, tt.args.task, true)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("evaluateWhenConditionsForTaskInEnvironment() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if !strings.Contains(out.String(), "Evaluating task condition") {
+				t.Errorf("evaluateWhenConditionsForTaskInEnvironment() didn't write to the output of the task: %v", out.String())
+			}
 			if got != tt.want {
 				t.Errorf("evaluateWhenConditionsForTaskInEnvironment() got = %v, want %v", got, tt.want)
 			}
@@ -170,7 +182,7 @@ func Test_iterateTaskGenerator(t *testing.T) {
 		{name: "Runs with no errors",
 			args: args{
 				allowDeployMissingErrors: true,
-				taskRunner: func(namespace string, prePost string, incoming lagoon.Task) error {
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
 					return nil
 				},
 				tasks: []lagoon.Task{
@@ -184,7 +196,7 @@ func Test_iterateTaskGenerator(t *testing.T) {
 		{name: "Allows deploy missing errors and keeps rolling (pre rollout case)",
 			args: args{
 				allowDeployMissingErrors: true,
-				taskRunner: func(namespace string, prePost string, incoming lagoon.Task) error {
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
 					return &lagoon.DeploymentMissingError{}
 				},
 				tasks: []lagoon.Task{
@@ -198,7 +210,7 @@ func Test_iterateTaskGenerator(t *testing.T) {
 		{name: "Does not allow deploy missing errors and stops with error (post rollout)",
 			args: args{
 				allowDeployMissingErrors: false,
-				taskRunner: func(namespace string, prePost string, incoming lagoon.Task) error {
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
 					return &lagoon.DeploymentMissingError{}
 				},
 				tasks: []lagoon.Task{
@@ -212,7 +224,7 @@ func Test_iterateTaskGenerator(t *testing.T) {
 		{name: "Allows deploy missing errors but stops with any other error (pre rollout)",
 			args: args{
 				allowDeployMissingErrors: true,
-				taskRunner: func(namespace string, prePost string, incoming lagoon.Task) error {
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
 					return &lagoon.PodScalingError{}
 				},
 				tasks: []lagoon.Task{
@@ -223,10 +235,70 @@ func Test_iterateTaskGenerator(t *testing.T) {
 			prePost:   "PostRollout",
 			wantError: true,
 		},
+		{name: "Stops with a timeout error (post rollout)",
+			args: args{
+				allowDeployMissingErrors: false,
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+					return &lagoon.TaskTimeoutError{}
+				},
+				tasks: []lagoon.Task{
+					{Timeout: "10m"},
+				},
+				buildValues: generator.BuildValues{Namespace: "empty"},
+			},
+			prePost:   "PostRollout",
+			wantError: true,
+		},
+		{name: "Runs tasks with dependencies",
+			args: args{
+				allowDeployMissingErrors: false,
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+					return nil
+				},
+				tasks: []lagoon.Task{
+					{Name: "first"},
+					{Name: "second", DependsOn: []string{"first"}},
+					{Name: "third", DependsOn: []string{"first"}},
+				},
+				buildValues: generator.BuildValues{Namespace: "empty"},
+			},
+			prePost:   "PostRollout",
+			wantError: false,
+		},
+		{name: "Stops with an error on an unknown dependency",
+			args: args{
+				allowDeployMissingErrors: false,
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+					return nil
+				},
+				tasks: []lagoon.Task{
+					{Name: "first"},
+					{Name: "second", DependsOn: []string{"zeroth"}},
+				},
+				buildValues: generator.BuildValues{Namespace: "empty"},
+			},
+			prePost:   "PostRollout",
+			wantError: true,
+		},
+		{name: "Stops on an invalid timeout before running any tasks",
+			args: args{
+				allowDeployMissingErrors: true,
+				taskRunner: func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+					return fmt.Errorf("task %s should not have been run", incoming.Name)
+				},
+				tasks: []lagoon.Task{
+					{Name: "first"},
+					{Name: "second", Timeout: "ten minutes"},
+				},
+				buildValues: generator.BuildValues{Namespace: "empty"},
+			},
+			prePost:   "PreRollout",
+			wantError: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, _ := iterateTaskGenerator(tt.args.allowDeployMissingErrors, tt.args.taskRunner, tt.args.buildValues, tt.prePost, tt.debug)
+			got, _ := iterateTaskGenerator(context.Background(), tt.args.allowDeployMissingErrors, tt.args.taskRunner, tt.args.buildValues, tt.prePost, 1, nil, tt.debug)
 			_, err := got(tasklib.TaskEnvironment{}, tt.args.tasks)
 
 			if tt.wantError && err == nil {
@@ -239,3 +311,136 @@ func Test_iterateTaskGenerator(t *testing.T) {
 		})
 	}
 }
+
+func Test_runTaskWithRetries(t *testing.T) {
+	exitErr := fmt.Errorf("Error returned: %w", utilexec.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1})
+	tests := []struct {
+		name         string
+		task         lagoon.Task
+		failAttempts int
+		err          error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "succeeds without retries",
+			task:         lagoon.Task{Name: "test"},
+			wantAttempts: 1,
+		},
+		{
+			name:         "fails without retries",
+			task:         lagoon.Task{Name: "test"},
+			failAttempts: 1,
+			err:          exitErr,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "succeeds on the last retry",
+			task:         lagoon.Task{Name: "test", Retries: 2, RetryDelay: "1ms"},
+			failAttempts: 2,
+			err:          exitErr,
+			wantAttempts: 3,
+		},
+		{
+			name:         "fails after all retries",
+			task:         lagoon.Task{Name: "test", Retries: 2},
+			failAttempts: 5,
+			err:          exitErr,
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "doesn't retry failures that aren't configured",
+			task:         lagoon.Task{Name: "test", Retries: 2, RetryOn: []string{"connection"}},
+			failAttempts: 5,
+			err:          exitErr,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			taskRunner := func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+				attempts++
+				if incoming.Attempt != attempts {
+					t.Errorf("runTaskWithRetries() attempt = %v, want %v", incoming.Attempt, attempts)
+				}
+				if attempts <= tt.failAttempts {
+					return tt.err
+				}
+				return nil
+			}
+			err := runTaskWithRetries(context.Background(), taskRunner, "empty", "Post-Rollout", tt.task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("runTaskWithRetries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("runTaskWithRetries() attempts = %v, want %v", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func Test_unidleThenRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		task       lagoon.Task
+		unidleErr  error
+		wantUnidle bool
+		wantRun    bool
+		wantErr    bool
+	}{
+		{
+			name:       "exec tasks unidle the namespace",
+			task:       lagoon.Task{Name: "test", Service: "cli"},
+			wantUnidle: true,
+			wantRun:    true,
+		},
+		{
+			name:    "job tasks don't unidle the namespace",
+			task:    lagoon.Task{Name: "test", Service: "cli", Executor: lagoon.TaskExecutorJob},
+			wantRun: true,
+		},
+		{
+			name:       "unidling timeout doesn't stop the task",
+			task:       lagoon.Task{Name: "test", Service: "cli"},
+			unidleErr:  lagoon.NamespaceUnidlingTimeoutError,
+			wantUnidle: true,
+			wantRun:    true,
+		},
+		{
+			name:       "unidling timeout stops a task that requires the environment",
+			task:       lagoon.Task{Name: "test", Service: "cli", RequiresEnvironment: true},
+			unidleErr:  lagoon.NamespaceUnidlingTimeoutError,
+			wantUnidle: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unidled := false
+			ran := false
+			unidle := func(ctx context.Context, namespace string, retries int, waitTime int) error {
+				unidled = true
+				return tt.unidleErr
+			}
+			taskRunner := func(ctx context.Context, namespace string, prePost string, incoming lagoon.Task) error {
+				ran = true
+				return nil
+			}
+			tt.task.Stdout = io.Discard
+			err := unidleThenRun(unidle, taskRunner)(context.Background(), "empty", "Pre-Rollout", tt.task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unidleThenRun() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if unidled != tt.wantUnidle {
+				t.Errorf("unidleThenRun() unidled = %v, want %v", unidled, tt.wantUnidle)
+			}
+			if ran != tt.wantRun {
+				t.Errorf("unidleThenRun() ran = %v, want %v", ran, tt.wantRun)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// prefixWriter writes the output of a task with the name of the task at the start of every line, so the output of tasks
+// that run at the same time can be told apart. only whole lines are written, and the lock is shared between the writers of
+// all the tasks so lines from different tasks never interleave
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	lock   *sync.Mutex
+	buf    []byte
+}
+
+func newPrefixWriter(name string, out io.Writer, lock *sync.Mutex) *prefixWriter {
+	return &prefixWriter{
+		prefix: fmt.Sprintf("[%s] ", name),
+		out:    out,
+		lock:   lock,
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := fmt.Fprintf(w.out, "%s%s", w.prefix, w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any output that didn't end with a newline
+func (w *prefixWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+	w.buf = nil
+	return err
+}
+
+// runTaskGraph runs the tasks once all of the tasks they depend on have completed, running up to maxParallel tasks at the
+// same time. tasks that are ready to run are started in the order they are defined. if a task fails no more tasks are started,
+// the tasks that are already running are allowed to complete and the error of the first task that failed is returned.
+// notRun is called for each of the tasks that were never started, with the index of the first task that failed
+func runTaskGraph(tasks []lagoon.Task, maxParallel int, runTask func(lagoon.Task) error, notRun func(task lagoon.Task, failed int)) error {
+	dependencies, err := lagoon.TaskDependencies(tasks)
+	if err != nil {
+		return err
+	}
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+	// count the dependencies that each task is waiting on, and which tasks are waiting on each task
+	waiting := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	for i, taskDependencies := range dependencies {
+		waiting[i] = len(taskDependencies)
+		for _, dependency := range taskDependencies {
+			dependents[dependency] = append(dependents[dependency], i)
+		}
+	}
+
+	type taskResult struct {
+		index int
+		err   error
+	}
+	results := make(chan taskResult)
+	started := make([]bool, len(tasks))
+	outputLock := &sync.Mutex{}
+	running := 0
+	failed := -1
+	var firstErr error
+	for {
+		if firstErr == nil {
+			for i := range tasks {
+				if running >= maxParallel {
+					break
+				}
+				if started[i] || waiting[i] > 0 {
+					continue
+				}
+				started[i] = true
+				running++
+				task := tasks[i]
+				stdout := newPrefixWriter(lagoon.TaskDisplayName(task, i), os.Stdout, outputLock)
+				stderr := newPrefixWriter(lagoon.TaskDisplayName(task, i), os.Stderr, outputLock)
+				task.Stdout = stdout
+				task.Stderr = stderr
+				go func(i int, task lagoon.Task) {
+					err := runTask(task)
+					stdout.Flush()
+					stderr.Flush()
+					results <- taskResult{index: i, err: err}
+				}(i, task)
+			}
+		}
+		// once nothing is running, either all the tasks have completed or a task failed
+		if running == 0 {
+			if firstErr != nil {
+				for i, task := range tasks {
+					if !started[i] {
+						notRun(task, failed)
+					}
+				}
+			}
+			return firstErr
+		}
+		result := <-results
+		running--
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+				failed = result.index
+			}
+			continue
+		}
+		for _, dependent := range dependents[result.index] {
+			waiting[dependent]--
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_prefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	lock := &sync.Mutex{}
+	first := newPrefixWriter("first", &out, lock)
+	second := newPrefixWriter("second", &out, lock)
+	fmt.Fprint(first, "partial ")
+	fmt.Fprint(second, "one\ntwo\n")
+	fmt.Fprint(first, "line\nunterminated")
+	first.Flush()
+	second.Flush()
+	want := "[second] one\n[second] two\n[first] partial line\n[first] unterminated\n"
+	if out.String() != want {
+		t.Errorf("prefixWriter wrote %q, want %q", out.String(), want)
+	}
+}
+
+func Test_runTaskGraph(t *testing.T) {
+	tests := []struct {
+		name        string
+		tasks       []lagoon.Task
+		maxParallel int
+		fail        string
+		wantRun     []string
+		wantNotRun  []string
+		wantMax     int
+		wantErr     bool
+	}{
+		{
+			name: "runs dependencies first, one at a time",
+			tasks: []lagoon.Task{
+				{Name: "warm cache", DependsOn: []string{"deploy"}},
+				{Name: "deploy"},
+				{Name: "notify", DependsOn: []string{"warm cache"}},
+			},
+			maxParallel: 1,
+			wantRun:     []string{"deploy", "warm cache", "notify"},
+			wantMax:     1,
+		},
+		{
+			name: "runs independent tasks at the same time",
+			tasks: []lagoon.Task{
+				{Name: "deploy"},
+				{Name: "warm cache", DependsOn: []string{"deploy"}},
+				{Name: "reindex", DependsOn: []string{"deploy"}},
+				{Name: "purge", DependsOn: []string{"deploy"}},
+			},
+			maxParallel: 2,
+			wantMax:     2,
+		},
+		{
+			name: "doesn't run the dependents of a failed task",
+			tasks: []lagoon.Task{
+				{Name: "deploy"},
+				{Name: "warm cache", DependsOn: []string{"deploy"}},
+			},
+			maxParallel: 4,
+			fail:        "deploy",
+			wantRun:     []string{"deploy"},
+			wantNotRun:  []string{"warm cache"},
+			wantMax:     1,
+			wantErr:     true,
+		},
+		{
+			name: "cycle",
+			tasks: []lagoon.Task{
+				{Name: "deploy", DependsOn: []string{"warm cache"}},
+				{Name: "warm cache", DependsOn: []string{"deploy"}},
+			},
+			maxParallel: 4,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock := &sync.Mutex{}
+			run := []string{}
+			running := 0
+			maxRunning := 0
+			notRun := []string{}
+			err := runTaskGraph(tt.tasks, tt.maxParallel, func(task lagoon.Task) error {
+				lock.Lock()
+				run = append(run, task.Name)
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				lock.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				lock.Lock()
+				running--
+				lock.Unlock()
+				if task.Name == tt.fail {
+					return fmt.Errorf("task %s failed", task.Name)
+				}
+				return nil
+			}, func(task lagoon.Task, failed int) {
+				if tt.tasks[failed].Name != tt.fail {
+					t.Errorf("runTaskGraph() failed task = %v, want %v", tt.tasks[failed].Name, tt.fail)
+				}
+				notRun = append(notRun, task.Name)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("runTaskGraph() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantRun != nil && !reflect.DeepEqual(run, tt.wantRun) {
+				t.Errorf("runTaskGraph() ran %v, want %v", run, tt.wantRun)
+			}
+			if tt.wantNotRun != nil && !reflect.DeepEqual(notRun, tt.wantNotRun) {
+				t.Errorf("runTaskGraph() didn't run %v, want %v", notRun, tt.wantNotRun)
+			}
+			if maxRunning != tt.wantMax {
+				t.Errorf("runTaskGraph() ran %v tasks at the same time, want %v", maxRunning, tt.wantMax)
+			}
+		})
+	}
+}
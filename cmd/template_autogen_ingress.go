@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
-	ingresstemplate "github.com/uselagoon/build-deploy-tool/internal/templating/ingress"
 )
 
 var autogenRouteGeneration = &cobra.Command{
@@ -38,7 +37,7 @@ func AutogeneratedIngressGeneration(g generator.GeneratorInput) error {
 		if g.Debug {
 			fmt.Println(fmt.Sprintf("Templating autogenerated ingress manifest for %s to %s", route.Domain, fmt.Sprintf("%s/%s.yaml", savedTemplates, route.LagoonService)))
 		}
-		templateYAML, err := ingresstemplate.GenerateIngressTemplate(route, *lagoonBuild.BuildValues)
+		templateYAML, err := generateRouteTemplate(route, *lagoonBuild.BuildValues)
 		if err != nil {
 			return fmt.Errorf("couldn't generate template: %v", err)
 		}
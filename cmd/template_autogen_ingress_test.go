@@ -475,6 +475,26 @@ func TestAutogeneratedIngressGeneration(t *testing.T) {
 			emptyDir:     true,
 			want:         "",
 		},
+		{
+			name: "test27 autogenerated routes with the development environment route defaults",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "develop",
+					Branch:          "develop",
+					EnvironmentType: "development",
+					LagoonYAML:      "../internal/testdata/node/lagoon.access.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "STAGING_HTPASSWD",
+							Value: "staging:$apr1$lP3S9Uf1$bR4E1yF2tG3hZ4x5C6v7B8",
+							Scope: "runtime",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/autogen-templates/ingress-15",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
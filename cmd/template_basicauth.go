@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	ingresstemplate "github.com/uselagoon/build-deploy-tool/internal/templating/ingress"
+)
+
+var basicAuthSecretGeneration = &cobra.Command{
+	Use:     "basic-auth-secrets",
+	Aliases: []string{"bas"},
+	Short:   "Generate the route basic auth secret templates for a Lagoon build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		return BasicAuthSecretTemplateGeneration(generator)
+	},
+}
+
+// BasicAuthSecretTemplateGeneration generates the secrets that hold the htpasswd content for routes that use basic auth
+func BasicAuthSecretTemplateGeneration(g generator.GeneratorInput) error {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	savedTemplates := g.SavedTemplatesPath
+
+	// sort the secrets so the templates are generated in a consistent order
+	secretNames := []string{}
+	for secretName := range lagoonBuild.BuildValues.BasicAuthSecrets {
+		secretNames = append(secretNames, secretName)
+	}
+	sort.Strings(secretNames)
+
+	// generate the templates
+	for _, secretName := range secretNames {
+		if g.Debug {
+			fmt.Println(fmt.Sprintf("Templating basic auth secret manifest for %s to %s", secretName, fmt.Sprintf("%s/00-%s.yaml", savedTemplates, secretName)))
+		}
+		templateYAML, err := ingresstemplate.GenerateBasicAuthSecretTemplate(lagoonBuild.BuildValues.BasicAuthSecrets[secretName], *lagoonBuild.BuildValues)
+		if err != nil {
+			return fmt.Errorf("couldn't generate template: %v", err)
+		}
+		// this secret needs to exist before the ingress is created, so prioritise it by putting it numerically ahead of any ingresses
+		helpers.WriteTemplateFile(fmt.Sprintf("%s/00-%s.yaml", savedTemplates, secretName), templateYAML)
+	}
+	return nil
+}
+
+func init() {
+	templateCmd.AddCommand(basicAuthSecretGeneration)
+}
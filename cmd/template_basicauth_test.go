@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+)
+
+func TestBasicAuthSecretTemplateGeneration(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         testdata.TestData
+		templatePath string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "test1 - basic auth secret from a route",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.access.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "EXAMPLE_HTPASSWD",
+							Value: "example:$apr1$Ty4Zp2Ey$2Nj5x0JbNAx6n0rAUp7Ep1",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/basicauth-templates/basicauth-1",
+		},
+		{
+			name: "test2 - basic auth secret from the development environment defaults",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "develop",
+					Branch:          "develop",
+					EnvironmentType: "development",
+					LagoonYAML:      "../internal/testdata/node/lagoon.access.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "STAGING_HTPASSWD",
+							Value: "staging:$apr1$lP3S9Uf1$bR4E1yF2tG3hZ4x5C6v7B8",
+							Scope: "runtime",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/basicauth-templates/basicauth-2",
+		},
+		{
+			name: "test3 - basic auth variable is not defined",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.access.yml",
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			err = os.MkdirAll(savedTemplates, 0755)
+			if err != nil {
+				t.Errorf("couldn't create directory %v: %v", savedTemplates, err)
+			}
+			defer os.RemoveAll(savedTemplates)
+
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			defer os.RemoveAll(savedTemplates)
+
+			err = BasicAuthSecretTemplateGeneration(generator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BasicAuthSecretTemplateGeneration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			files, err := ioutil.ReadDir(savedTemplates)
+			if err != nil {
+				t.Errorf("couldn't read directory %v: %v", savedTemplates, err)
+			}
+			results, err := ioutil.ReadDir(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read directory %v: %v", tt.want, err)
+			}
+			if len(files) != len(results) {
+				for _, f := range files {
+					f1, err := os.ReadFile(fmt.Sprintf("%s/%s", savedTemplates, f.Name()))
+					if err != nil {
+						t.Errorf("couldn't read file %v: %v", savedTemplates, err)
+					}
+					fmt.Println(string(f1))
+				}
+				t.Errorf("number of generated templates doesn't match results %v/%v: %v", len(files), len(results), err)
+			}
+			fCount := 0
+			for _, f := range files {
+				for _, r := range results {
+					if f.Name() == r.Name() {
+						fCount++
+						f1, err := os.ReadFile(fmt.Sprintf("%s/%s", savedTemplates, f.Name()))
+						if err != nil {
+							t.Errorf("couldn't read file %v: %v", savedTemplates, err)
+						}
+						r1, err := os.ReadFile(fmt.Sprintf("%s/%s", tt.want, f.Name()))
+						if err != nil {
+							t.Errorf("couldn't read file %v: %v", tt.want, err)
+						}
+						if !reflect.DeepEqual(f1, r1) {
+							fmt.Println(string(f1))
+							t.Errorf("resulting templates do not match")
+						}
+					}
+				}
+			}
+			if fCount != len(files) {
+				for _, f := range files {
+					f1, err := os.ReadFile(fmt.Sprintf("%s/%s", savedTemplates, f.Name()))
+					if err != nil {
+						t.Errorf("couldn't read file %v: %v", savedTemplates, err)
+					}
+					fmt.Println(string(f1))
+				}
+				t.Errorf("resulting templates do not match")
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars([]helpers.EnvironmentVariable{{Name: "DBAAS_OPERATOR_HTTP"}})
+			})
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
+)
+
+var cronjobsGeneration = &cobra.Command{
+	Use:     "cronjobs",
+	Aliases: []string{"cj"},
+	Short:   "Generate the native cronjob templates for a Lagoon build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		return CronjobsTemplateGeneration(generator)
+	},
+}
+
+// CronjobsTemplateGeneration .
+func CronjobsTemplateGeneration(g generator.GeneratorInput,
+) error {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	savedTemplates := g.SavedTemplatesPath
+
+	// generate the cronjob templates
+	cronjobsYAML, err := servicestemplates.GenerateCronjobTemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	if len(cronjobsYAML) > 0 {
+		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "cronjobs"), cronjobsYAML)
+	}
+	return nil
+}
+
+func init() {
+	templateCmd.AddCommand(cronjobsGeneration)
+}
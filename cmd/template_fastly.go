@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	fastlytemplate "github.com/uselagoon/build-deploy-tool/internal/templating/fastly"
+)
+
+var fastlyAPISecretGeneration = &cobra.Command{
+	Use:     "fastly-api-secrets",
+	Aliases: []string{"fas"},
+	Short:   "Generate the fastly api secret templates for a Lagoon build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		return FastlyAPISecretTemplateGeneration(generator)
+	},
+}
+
+// FastlyAPISecretTemplateGeneration .
+func FastlyAPISecretTemplateGeneration(g generator.GeneratorInput) error {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	savedTemplates := g.SavedTemplatesPath
+
+	// fail if any of the routes reference a fastly api secret that this build will not create
+	err = generator.CheckFastlyAPISecrets(
+		*lagoonBuild.BuildValues,
+		lagoonBuild.AutogeneratedRoutes,
+		lagoonBuild.MainRoutes,
+		lagoonBuild.ActiveStandbyRoutes,
+	)
+	if err != nil {
+		return err
+	}
+
+	// sort the secrets so the templates are generated in a consistent order
+	secretNames := []string{}
+	for secretName := range lagoonBuild.BuildValues.FastlyAPISecrets {
+		secretNames = append(secretNames, secretName)
+	}
+	sort.Strings(secretNames)
+
+	// generate the templates
+	for _, secretName := range secretNames {
+		if g.Debug {
+			fmt.Println(fmt.Sprintf("Templating fastly api secret manifest for %s to %s", secretName, fmt.Sprintf("%s/00-%s.yaml", savedTemplates, secretName)))
+		}
+		templateYAML, err := fastlytemplate.GenerateFastlyAPISecretTemplate(lagoonBuild.BuildValues.FastlyAPISecrets[secretName], *lagoonBuild.BuildValues)
+		if err != nil {
+			return fmt.Errorf("couldn't generate template: %v", err)
+		}
+		// this api secret needs to exist before the ingress is created, so prioritise it by putting it numerically ahead of any ingresses
+		helpers.WriteTemplateFile(fmt.Sprintf("%s/00-%s.yaml", savedTemplates, secretName), templateYAML)
+	}
+	return nil
+}
+
+func init() {
+	templateCmd.AddCommand(fastlyAPISecretGeneration)
+}
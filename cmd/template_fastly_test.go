@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+)
+
+func TestFastlyAPISecretTemplateGeneration(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         testdata.TestData
+		templatePath string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "test1 - fastly api secret from lagoon.yml",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.fastly.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "FASTLY_API_TOKEN",
+							Value: "abcdefg123456",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/fastly-templates/fastly-1",
+		},
+		{
+			name: "test2 - fastly api secrets from lagoon.yml and LAGOON_FASTLY_API_SECRETS",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.fastly.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "FASTLY_API_TOKEN",
+							Value: "abcdefg123456",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FASTLY_API_SECRETS",
+							Value: "anothercom:hijklmn789:B2cdEfGhI23fE353Tet",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/fastly-templates/fastly-2",
+		},
+		{
+			name: "test3 - fastly api secret with no token variable",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:      "example-project",
+					EnvironmentName:  "main",
+					Branch:           "main",
+					LagoonYAML:       "../internal/testdata/node/lagoon.fastly.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{},
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+		{
+			name: "test4 - route references a fastly api secret that is not defined",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "missingsecret",
+					Branch:          "missingsecret",
+					LagoonYAML:      "../internal/testdata/node/lagoon.fastly.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "FASTLY_API_TOKEN",
+							Value: "abcdefg123456",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+		{
+			name: "test5 - invalid LAGOON_FASTLY_API_SECRETS",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.fastly.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "FASTLY_API_TOKEN",
+							Value: "abcdefg123456",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FASTLY_API_SECRETS",
+							Value: "anothercom:hijklmn789",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			err = os.MkdirAll(savedTemplates, 0755)
+			if err != nil {
+				t.Errorf("couldn't create directory %v: %v", savedTemplates, err)
+			}
+			defer os.RemoveAll(savedTemplates)
+
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			defer os.RemoveAll(savedTemplates)
+
+			err = FastlyAPISecretTemplateGeneration(generator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FastlyAPISecretTemplateGeneration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			files, err := ioutil.ReadDir(savedTemplates)
+			if err != nil {
+				t.Errorf("couldn't read directory %v: %v", savedTemplates, err)
+			}
+			results, err := ioutil.ReadDir(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read directory %v: %v", tt.want, err)
+			}
+			if len(files) != len(results) {
+				for _, f := range files {
+					f1, err := os.ReadFile(fmt.Sprintf("%s/%s", savedTemplates, f.Name()))
+					if err != nil {
+						t.Errorf("couldn't read file %v: %v", savedTemplates, err)
+					}
+					fmt.Println(string(f1))
+				}
+				t.Errorf("number of generated templates doesn't match results %v/%v: %v", len(files), len(results), err)
+			}
+			fCount := 0
+			for _, f := range files {
+				for _, r := range results {
+					if f.Name() == r.Name() {
+						fCount++
+						f1, err := os.ReadFile(fmt.Sprintf("%s/%s", savedTemplates, f.Name()))
+						if err != nil {
+							t.Errorf("couldn't read file %v: %v", savedTemplates, err)
+						}
+						r1, err := os.ReadFile(fmt.Sprintf("%s/%s", tt.want, f.Name()))
+						if err != nil {
+							t.Errorf("couldn't read file %v: %v", tt.want, err)
+						}
+						if !reflect.DeepEqual(f1, r1) {
+							fmt.Println(string(f1))
+							t.Errorf("resulting templates do not match")
+						}
+					}
+				}
+			}
+			if fCount != len(files) {
+				for _, f := range files {
+					f1, err := os.ReadFile(fmt.Sprintf("%s/%s", savedTemplates, f.Name()))
+					if err != nil {
+						t.Errorf("couldn't read file %v: %v", savedTemplates, err)
+					}
+					fmt.Println(string(f1))
+				}
+				t.Errorf("resulting templates do not match")
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars([]helpers.EnvironmentVariable{{Name: "DBAAS_OPERATOR_HTTP"}})
+			})
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 	ingresstemplate "github.com/uselagoon/build-deploy-tool/internal/templating/ingress"
 )
 
@@ -37,7 +38,7 @@ func IngressTemplateGeneration(g generator.GeneratorInput) error {
 		if g.Debug {
 			fmt.Println(fmt.Sprintf("Templating ingress manifest for %s to %s", route.Domain, fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain)))
 		}
-		templateYAML, err := ingresstemplate.GenerateIngressTemplate(route, *lagoonBuild.BuildValues)
+		templateYAML, err := generateRouteTemplate(route, *lagoonBuild.BuildValues)
 		if err != nil {
 			return fmt.Errorf("couldn't generate template: %v", err)
 		}
@@ -53,7 +54,7 @@ func IngressTemplateGeneration(g generator.GeneratorInput) error {
 			if g.Debug {
 				fmt.Println(fmt.Sprintf("Templating active/standby ingress manifest for %s to %s", route.Domain, fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain)))
 			}
-			templateYAML, err := ingresstemplate.GenerateIngressTemplate(route, *lagoonBuild.BuildValues)
+			templateYAML, err := generateRouteTemplate(route, *lagoonBuild.BuildValues)
 			if err != nil {
 				return fmt.Errorf("couldn't generate template: %v", err)
 			}
@@ -63,6 +64,35 @@ func IngressTemplateGeneration(g generator.GeneratorInput) error {
 	return nil
 }
 
+// generateRouteTemplate generates the templates for a route, as gateway api httproutes
+// if the gateway api is enabled, otherwise as an ingress. any cert-manager certificate for the route is added to the same template
+func generateRouteTemplate(route lagoon.RouteV2, buildValues generator.BuildValues) ([]byte, error) {
+	var templateYAML []byte
+	var err error
+	if buildValues.GatewayAPI.Enabled {
+		templateYAML, err = ingresstemplate.GenerateHTTPRouteTemplate(route, buildValues)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		templateYAML, err = ingresstemplate.GenerateIngressTemplate(route, buildValues)
+		if err != nil {
+			return nil, err
+		}
+		// the canary ingress is applied alongside the ingress of the route
+		canaryYAML, err := ingresstemplate.GenerateCanaryIngressTemplate(route, buildValues)
+		if err != nil {
+			return nil, err
+		}
+		templateYAML = append(templateYAML, canaryYAML...)
+	}
+	certificateYAML, err := ingresstemplate.GenerateCertificateTemplate(route, buildValues)
+	if err != nil {
+		return nil, err
+	}
+	return append(templateYAML, certificateYAML...), nil
+}
+
 func init() {
 	templateCmd.AddCommand(routeGeneration)
 }
@@ -19,6 +19,7 @@ func TestTemplateRoutes(t *testing.T) {
 		args         testdata.TestData
 		templatePath string
 		want         string
+		wantErr      bool
 	}{
 		{
 			name: "test1 check LAGOON_FASTLY_SERVICE_IDS with secret no values",
@@ -378,6 +379,211 @@ func TestTemplateRoutes(t *testing.T) {
 			templatePath: "testdata/output",
 			want:         "../internal/testdata/node/ingress-templates/ingress-22",
 		},
+		{
+			name: "test23 path based routing",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.paths.yml",
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-23",
+		},
+		{
+			name: "test24 path based routing with a service that doesn't exist",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "missingservice",
+					Branch:          "missingservice",
+					LagoonYAML:      "../internal/testdata/node/lagoon.paths.yml",
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+		{
+			name: "test25 gateway api httproutes with path based routing",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.paths.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_GATEWAY_API_ROUTES",
+							Value: "enabled",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FEATURE_FLAG_GATEWAY_API_PARENT",
+							Value: "gateway-system/lagoon-gateway",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-24",
+		},
+		{
+			name: "test26 cert-manager certificates with alternative names",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "alternativename",
+					Branch:          "alternativename",
+					LagoonYAML:      "../internal/testdata/node/lagoon.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_CERTMANAGER_CERTIFICATES",
+							Value: "enabled",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FEATURE_FLAG_CERTMANAGER_HTTP01_ISSUER",
+							Value: "lagoon-letsencrypt",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FEATURE_FLAG_CERTMANAGER_DNS01_ISSUER",
+							Value: "lagoon-letsencrypt-dns",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-25",
+		},
+		{
+			name: "test27 cert-manager certificates with a wildcard route",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "wildcard",
+					Branch:          "wildcard",
+					LagoonYAML:      "../internal/testdata/node/lagoon.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_CERTMANAGER_CERTIFICATES",
+							Value: "enabled",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FEATURE_FLAG_CERTMANAGER_HTTP01_ISSUER",
+							Value: "lagoon-letsencrypt",
+							Scope: "build",
+						},
+						{
+							Name:  "LAGOON_FEATURE_FLAG_CERTMANAGER_DNS01_ISSUER",
+							Value: "lagoon-letsencrypt-dns",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-26",
+		},
+		{
+			name: "test28 redirecting route",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.redirects.yml",
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-27",
+		},
+		{
+			name: "test30 ip allowlist and basic auth",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.access.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "EXAMPLE_HTPASSWD",
+							Value: "example:$apr1$Ty4Zp2Ey$2Nj5x0JbNAx6n0rAUp7Ep1",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-28",
+		},
+		{
+			name: "test31 ip allowlist and basic auth from the development environment defaults",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "develop",
+					Branch:          "develop",
+					EnvironmentType: "development",
+					LagoonYAML:      "../internal/testdata/node/lagoon.access.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "STAGING_HTPASSWD",
+							Value: "staging:$apr1$lP3S9Uf1$bR4E1yF2tG3hZ4x5C6v7B8",
+							Scope: "runtime",
+						},
+					},
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-29",
+		},
+		{
+			name: "test32 rate limit and maximum body size from the production environment defaults",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.limits.yml",
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-30",
+		},
+		{
+			name: "test33 canary ingress",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.canary.yml",
+				}, true),
+			templatePath: "testdata/output",
+			want:         "../internal/testdata/node/ingress-templates/ingress-31",
+		},
+		{
+			name: "test29 redirecting route with a conflicting service",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "redirectwithpaths",
+					Branch:          "redirectwithpaths",
+					LagoonYAML:      "../internal/testdata/node/lagoon.redirects.yml",
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+		{
+			name: "test34 canary for a service that doesn't exist",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "missingservice",
+					Branch:          "missingservice",
+					LagoonYAML:      "../internal/testdata/node/lagoon.canary.yml",
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -396,8 +602,11 @@ func TestTemplateRoutes(t *testing.T) {
 			defer os.RemoveAll(savedTemplates)
 
 			err = IngressTemplateGeneration(generator)
-			if err != nil {
-				t.Errorf("%v", err)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IngressTemplateGeneration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
 			}
 
 			files, err := ioutil.ReadDir(savedTemplates)
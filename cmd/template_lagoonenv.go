@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	lagoonenvtemplate "github.com/uselagoon/build-deploy-tool/internal/templating/lagoonenv"
+)
+
+var lagoonEnvGeneration = &cobra.Command{
+	Use:     "lagoon-env",
+	Aliases: []string{"le"},
+	Short:   "Generate the lagoon-env configmap template for a Lagoon build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		return LagoonEnvTemplateGeneration(generator)
+	},
+}
+
+// LagoonEnvTemplateGeneration .
+func LagoonEnvTemplateGeneration(g generator.GeneratorInput,
+) error {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	savedTemplates := g.SavedTemplatesPath
+
+	// generate the lagoon-env configmap template from the runtime and global scoped variables
+	templateYAML, err := lagoonenvtemplate.GenerateLagoonEnvConfigMap(*lagoonBuild.BuildValues, *lagoonBuild.LagoonEnvironmentVariables)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	if g.Debug {
+		fmt.Println(fmt.Sprintf("Templating lagoon-env configmap manifest to %s", fmt.Sprintf("%s/%s.yaml", savedTemplates, "lagoon-env")))
+	}
+	helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "lagoon-env"), templateYAML)
+	return nil
+}
+
+func init() {
+	templateCmd.AddCommand(lagoonEnvGeneration)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
+)
+
+var lagoonServiceGeneration = &cobra.Command{
+	Use:     "lagoon-services",
+	Aliases: []string{"ls"},
+	Short:   "Generate the lagoon service templates for a Lagoon build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		// dynamic secrets are discovered from the cluster, unless they have been provided in a file
+		// the client is also used to read the dbaas variables from the lagoon-env configmap for the configmap sha
+		if helpers.GetEnv("DYNAMIC_SECRETS_FILE", generator.DynamicSecretsFile, false) == "" {
+			generator.KubernetesClient, err = lagoon.NewK8sClient()
+			if err != nil {
+				return fmt.Errorf("unable to create kubernetes client to discover dynamic secrets: %v", err)
+			}
+		}
+		return LagoonServiceTemplateGeneration(generator)
+	},
+}
+
+// LagoonServiceTemplateGeneration .
+func LagoonServiceTemplateGeneration(g generator.GeneratorInput,
+) error {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	savedTemplates := g.SavedTemplatesPath
+
+	// generate the service templates
+	servicesYAML, err := servicestemplates.GenerateServiceTemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	if len(servicesYAML) > 0 {
+		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "services"), servicesYAML)
+	}
+	// generate the persistent volume claim templates
+	pvcsYAML, err := servicestemplates.GeneratePVCTemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	if len(pvcsYAML) > 0 {
+		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "pvcs"), pvcsYAML)
+	}
+	// generate the deployment templates
+	deploymentsYAML, err := servicestemplates.GenerateDeploymentTemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	if len(deploymentsYAML) > 0 {
+		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "deployments"), deploymentsYAML)
+	}
+	return nil
+}
+
+func init() {
+	templateCmd.AddCommand(lagoonServiceGeneration)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	networkpolicytemplate "github.com/uselagoon/build-deploy-tool/internal/templating/networkpolicy"
+)
+
+var networkPolicyGeneration = &cobra.Command{
+	Use:     "network-policy",
+	Aliases: []string{"np"},
+	Short:   "Generate the isolation network policy template for a Lagoon build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		return NetworkPolicyTemplateGeneration(generator)
+	},
+}
+
+// NetworkPolicyTemplateGeneration .
+func NetworkPolicyTemplateGeneration(g generator.GeneratorInput,
+) error {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	savedTemplates := g.SavedTemplatesPath
+
+	// generate the isolation network policy template, this is only generated if the feature flag is enabled
+	templateYAML, err := networkpolicytemplate.GenerateNetworkPolicy(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	if len(templateYAML) > 0 {
+		if g.Debug {
+			fmt.Println(fmt.Sprintf("Templating isolation network policy manifest to %s", fmt.Sprintf("%s/%s.yaml", savedTemplates, "isolation-network-policy")))
+		}
+		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "isolation-network-policy"), templateYAML)
+	}
+	return nil
+}
+
+func init() {
+	templateCmd.AddCommand(networkPolicyGeneration)
+}
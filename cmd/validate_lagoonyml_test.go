@@ -131,6 +131,27 @@ func TestValidateLagoonYml(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "task dependencies",
+			args: args{
+				lagoonYml:     "../test-resources/validate-lagoon-yml/tasks/lagoon.yml",
+				wantLagoonYml: "../test-resources/validate-lagoon-yml/tasks/lagoon.yml",
+				lYAML:         &lagoon.YAML{},
+				projectName:   "",
+				debug:         false,
+			},
+			wantErr: false,
+		},
+		{
+			name: "task dependency cycles should fail validation",
+			args: args{
+				lagoonYml:   "../test-resources/validate-lagoon-yml/tasks/lagoon-cycle.yml",
+				lYAML:       &lagoon.YAML{},
+				projectName: "",
+				debug:       false,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+var validateRoutes = &cobra.Command{
+	Use:     "routes",
+	Aliases: []string{"r"},
+	Short:   "Verify that the routes of an environment don't conflict with each other",
+	Run: func(cmd *cobra.Command, args []string) {
+		gen, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		conflicts, err := ValidateRoutes(gen)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		for _, conflict := range conflicts {
+			fmt.Println(fmt.Errorf("error: %s", conflict.String()))
+		}
+		if len(conflicts) > 0 {
+			fmt.Printf("Could not validate your routes - found %d route conflicts\n", len(conflicts))
+			os.Exit(1)
+		}
+	},
+}
+
+// ValidateRoutes generates the routes of an environment and returns any conflicts between them
+// an error is only returned if the routes could not be generated for another reason
+func ValidateRoutes(g generator.GeneratorInput) ([]generator.RouteConflict, error) {
+	_, err := generator.NewGenerator(
+		g,
+	)
+	var conflictErr *generator.RouteConflictError
+	if errors.As(err, &conflictErr) {
+		return conflictErr.Conflicts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []generator.RouteConflict{}, nil
+}
+
+func init() {
+	validateCmd.AddCommand(validateRoutes)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+)
+
+func TestValidateRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         testdata.TestData
+		templatePath string
+		want         []generator.RouteConflict
+		wantErr      bool
+	}{
+		{
+			name: "test1 - no conflicts",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "clean",
+					Branch:          "clean",
+					LagoonYAML:      "../internal/testdata/node/lagoon.conflicts.yml",
+				}, true),
+			templatePath: "testdata/output",
+			want:         []generator.RouteConflict{},
+		},
+		{
+			name: "test2 - environment route conflicts",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.conflicts.yml",
+				}, true),
+			templatePath: "testdata/output",
+			want: []generator.RouteConflict{
+				{
+					Host:   "api.example.net",
+					Reason: "overlaps with wildcard *.example.net",
+					Routes: []string{
+						"route api.example.net in environment routes",
+						"route example.net in environment routes",
+					},
+				},
+				{
+					Host:   "example.com",
+					Reason: "is an alternative name that collides with another route",
+					Routes: []string{
+						"alternative name of route www.example.com in environment routes",
+						"route example.com in environment routes",
+					},
+				},
+			},
+		},
+		{
+			name: "test3 - active environment conflicts with production routes",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:        "example-project",
+					EnvironmentName:    "main",
+					Branch:             "main",
+					ActiveEnvironment:  "main",
+					StandbyEnvironment: "main-sb",
+					LagoonYAML:         "../internal/testdata/node/lagoon.conflicts.yml",
+				}, true),
+			templatePath: "testdata/output",
+			want: []generator.RouteConflict{
+				{
+					Host:   "active.example.com",
+					Reason: "is defined by more than one route",
+					Routes: []string{
+						"route active.example.com in production_routes.active",
+						"route active.example.com in production_routes.standby",
+					},
+				},
+				{
+					Host:   "api.example.net",
+					Reason: "overlaps with wildcard *.example.net",
+					Routes: []string{
+						"route api.example.net in environment routes",
+						"route example.net in environment routes",
+					},
+				},
+				{
+					Host:   "example.com",
+					Reason: "is an alternative name that collides with another route",
+					Routes: []string{
+						"alternative name of route www.example.com in environment routes",
+						"route example.com in environment routes",
+					},
+				},
+				{
+					Host:   "main.example.com",
+					Reason: "is defined by more than one route",
+					Routes: []string{
+						"route main.example.com in environment routes",
+						"route main.example.com in production_routes.active",
+					},
+				},
+			},
+		},
+		{
+			name: "test4 - missing lagoon yaml",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "../internal/testdata/node/lagoon.missing.yml",
+				}, true),
+			templatePath: "testdata/output",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			got, err := ValidateRoutes(generator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRoutes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateRoutes() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars([]helpers.EnvironmentVariable{{Name: "DBAAS_OPERATOR_HTTP"}})
+			})
+		})
+	}
+}
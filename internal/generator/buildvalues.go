@@ -31,6 +31,8 @@ type BuildValues struct {
 	Fastly                        Fastly                      `json:"fastly"`
 	FastlyCacheNoCache            string                      `json:"fastlyCacheNoCahce"`
 	FastlyAPISecretPrefix         string                      `json:"fastlyAPISecretPrefix"`
+	FastlyAPISecrets              map[string]FastlyAPISecret  `json:"fastlyAPISecrets"`
+	BasicAuthSecrets              map[string]BasicAuthSecret  `json:"basicAuthSecrets"`
 	ConfigMapSha                  string                      `json:"configMapSha"`
 	Route                         string                      `json:"route"`
 	Routes                        []string                    `json:"routes"`
@@ -45,11 +47,16 @@ type BuildValues struct {
 	DBaaSEnvironmentTypeOverrides *lagoon.EnvironmentVariable `json:"dbaasEnvironmentTypeOverrides"`
 	DBaaSFallbackSingle           bool                        `json:"dbaasFallbackSingle"`
 	IngressClass                  string                      `json:"ingressClass"`
+	GatewayAPI                    GatewayAPIConfiguration     `json:"gatewayAPI"`
+	CertManager                   CertManagerConfiguration    `json:"certManager"`
 	TaskScaleMaxIterations        int                         `json:"taskScaleMaxIterations"`
 	TaskScaleWaitTime             int                         `json:"taskScaleWaitTime"`
 	ImageCache                    string                      `json:"imageCache"`
+	ImageReferences               map[string]string           `json:"imageReferences"`
 	BackupsEnabled                bool                        `json:"backupsEnabled"`
 	DefaultBackupSchedule         string                      `json:"defaultBackupSchedule"`
+	IsolationNetworkPolicy        bool                        `json:"isolationNetworkPolicy"`
+	DynamicSecrets                []string                    `json:"dynamicSecrets"`
 	DBaaSClient                   *dbaasclient.Client         `json:"-"`
 }
 
@@ -59,6 +66,37 @@ type Fastly struct {
 	Watch         bool   `json:"watch"`
 }
 
+// FastlyAPISecret is the values for a fastly api secret
+type FastlyAPISecret struct {
+	Name                     string `json:"name"`
+	APIToken                 string `json:"apiToken"`
+	PlatformTLSConfiguration string `json:"platformTLSConfiguration"`
+}
+
+// BasicAuthSecret is the values for a route basic auth secret
+type BasicAuthSecret struct {
+	Name     string `json:"name"`
+	Variable string `json:"variable"`
+	Auth     string `json:"-"`
+}
+
+// GatewayAPIConfiguration is the configuration used when routes are templated as gateway api httproutes instead of ingress
+type GatewayAPIConfiguration struct {
+	Enabled         bool   `json:"enabled"`
+	ParentName      string `json:"parentName"`
+	ParentNamespace string `json:"parentNamespace"`
+	HTTPListener    string `json:"httpListener"`
+	HTTPSListener   string `json:"httpsListener"`
+}
+
+// CertManagerConfiguration is the configuration used when cert-manager certificates are generated for routes
+type CertManagerConfiguration struct {
+	Enabled      bool   `json:"enabled"`
+	HTTP01Issuer string `json:"http01Issuer"`
+	DNS01Issuer  string `json:"dns01Issuer"`
+	IssuerKind   string `json:"issuerKind"`
+}
+
 type MonitoringConfig struct {
 	Enabled      bool   `json:"enabled"`
 	AlertContact string `json:"alertContact"`
@@ -67,32 +105,38 @@ type MonitoringConfig struct {
 
 // ServiceValues is the values for a specific service used by a lagoon build
 type ServiceValues struct {
-	Name                          string                   `json:"name"`         // this is the actual compose service name
-	OverrideName                  string                   `json:"overrideName"` // if an override name is provided, use it
-	Type                          string                   `json:"type"`
-	AutogeneratedRoutesEnabled    bool                     `json:"autogeneratedRoutesEnabled"`
-	AutogeneratedRoutesTLSAcme    bool                     `json:"autogeneratedRoutesTLSAcme"`
-	AutogeneratedRouteDomain      string                   `json:"autogeneratedRouteDomain"`
-	ShortAutogeneratedRouteDomain string                   `json:"shortAutogeneratedRouteDomain"`
-	DBaaSEnvironment              string                   `json:"dbaasEnvironment"`
-	NativeCronjobs                map[string]CronjobValues `json:"nativeCronjobs"`
-	InPodCronjobs                 string                   `json:"inPodCronjobs"`
-	ImageName                     string                   `json:"imageName"`
-	DeploymentServiceType         string                   `json:"deploymentServiecType"`
-	ServicePort                   int32                    `json:"servicePort,omitempty"`
-	PersistentVolumePath          string                   `json:"persistentVolumePath,omitempty"`
-	PersistentVolumeName          string                   `json:"persistentVolumeName,omitempty"`
-	PersistentVolumeSize          string                   `json:"persistentVolumeSize,omitempty"`
-	UseSpotInstances              bool                     `json:"useSpot"`
-	NodeSelectors                 *map[string]string       `json:"nodeSelectors"`
-	Tolerations                   *[]corev1.Toleration     `json:"tolerations"`
-	Affinity                      *corev1.Affinity         `json:"affinity"`
-	CronjobUseSpotInstances       bool                     `json:"cronjobUseSpot"`
-	CronjobNodeSelectors          *map[string]string       `json:"cronjobNodeSelectors"`
-	CronjobTolerations            *[]corev1.Toleration     `json:"cronjobTolerations"`
-	CronjobAffinity               *corev1.Affinity         `json:"cronjobAffinity"`
-	DBaasReadReplica              bool                     `json:"dBaasReadReplica"`
-	BackupsEnabled                bool                     `json:"backupsEnabled"`
+	Name                          string                            `json:"name"`         // this is the actual compose service name
+	OverrideName                  string                            `json:"overrideName"` // if an override name is provided, use it
+	Type                          string                            `json:"type"`
+	AutogeneratedRoutesEnabled    bool                              `json:"autogeneratedRoutesEnabled"`
+	AutogeneratedRoutesTLSAcme    bool                              `json:"autogeneratedRoutesTLSAcme"`
+	AutogeneratedRouteDomain      string                            `json:"autogeneratedRouteDomain"`
+	ShortAutogeneratedRouteDomain string                            `json:"shortAutogeneratedRouteDomain"`
+	DBaaSEnvironment              string                            `json:"dbaasEnvironment"`
+	NativeCronjobs                map[string]CronjobValues          `json:"nativeCronjobs"`
+	InPodCronjobs                 string                            `json:"inPodCronjobs"`
+	ImageName                     string                            `json:"imageName"`
+	DeploymentServiceType         string                            `json:"deploymentServiecType"`
+	ServicePort                   int32                             `json:"servicePort,omitempty"`
+	PersistentVolumePath          string                            `json:"persistentVolumePath,omitempty"`
+	PersistentVolumeName          string                            `json:"persistentVolumeName,omitempty"`
+	PersistentVolumeSize          string                            `json:"persistentVolumeSize,omitempty"`
+	PersistentVolumeAccessMode    corev1.PersistentVolumeAccessMode `json:"persistentVolumeAccessMode,omitempty"`
+	PersistentVolumeStorageClass  string                            `json:"persistentVolumeStorageClass,omitempty"`
+	UseSpotInstances              bool                              `json:"useSpot"`
+	Replicas                      int32                             `json:"replicas,omitempty"`
+	NodeSelectors                 *map[string]string                `json:"nodeSelectors"`
+	Tolerations                   *[]corev1.Toleration              `json:"tolerations"`
+	Affinity                      *corev1.Affinity                  `json:"affinity"`
+	CronjobUseSpotInstances       bool                              `json:"cronjobUseSpot"`
+	CronjobNodeSelectors          *map[string]string                `json:"cronjobNodeSelectors"`
+	CronjobTolerations            *[]corev1.Toleration              `json:"cronjobTolerations"`
+	CronjobAffinity               *corev1.Affinity                  `json:"cronjobAffinity"`
+	DBaasReadReplica              bool                              `json:"dBaasReadReplica"`
+	BackupsEnabled                bool                              `json:"backupsEnabled"`
+	Resources                     corev1.ResourceRequirements       `json:"resources"`
+	DynamicSecretMounts           []corev1.VolumeMount              `json:"dynamicSecretMounts,omitempty"`
+	DynamicSecretVolumes          []corev1.Volume                   `json:"dynamicSecretVolumes,omitempty"`
 }
 
 // CronjobValues is the values for cronjobs
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const defaultCertManagerIssuerKind = "ClusterIssuer"
+
+// generateCertManagerValues checks the `CERTMANAGER_CERTIFICATES` feature flag to see if cert-manager certificates should be generated
+// for routes, instead of relying on the `kubernetes.io/tls-acme` annotation and the cert-manager ingress-shim
+// routes use the issuer defined by the `CERTMANAGER_HTTP01_ISSUER` feature flag, and wildcard routes use the issuer defined by the
+// `CERTMANAGER_DNS01_ISSUER` feature flag, as wildcard certificates can only be issued with a dns-01 challenge
+func generateCertManagerValues(
+	buildValues *BuildValues,
+	lagoonEnvVars []lagoon.EnvironmentVariable,
+	debug bool,
+) error {
+	if CheckFeatureFlag("CERTMANAGER_CERTIFICATES", lagoonEnvVars, debug) != "enabled" {
+		return nil
+	}
+	http01Issuer := CheckFeatureFlag("CERTMANAGER_HTTP01_ISSUER", lagoonEnvVars, debug)
+	if http01Issuer == "" {
+		return fmt.Errorf("cert-manager certificates are enabled, but no issuer has been defined with the CERTMANAGER_HTTP01_ISSUER feature flag")
+	}
+	dns01Issuer := CheckFeatureFlag("CERTMANAGER_DNS01_ISSUER", lagoonEnvVars, debug)
+	for _, issuer := range []string{http01Issuer, dns01Issuer} {
+		if issuer == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(issuer); len(errs) != 0 {
+			return fmt.Errorf("the cert-manager issuer %s is not valid: %v", issuer, errs)
+		}
+	}
+	issuerKind := CheckFeatureFlag("CERTMANAGER_ISSUER_KIND", lagoonEnvVars, debug)
+	switch issuerKind {
+	case "":
+		issuerKind = defaultCertManagerIssuerKind
+	case "Issuer", "ClusterIssuer":
+	default:
+		return fmt.Errorf("the cert-manager issuer kind %s is not valid, it should be Issuer or ClusterIssuer", issuerKind)
+	}
+	buildValues.CertManager = CertManagerConfiguration{
+		Enabled:      true,
+		HTTP01Issuer: http01Issuer,
+		DNS01Issuer:  dns01Issuer,
+		IssuerKind:   issuerKind,
+	}
+	return nil
+}
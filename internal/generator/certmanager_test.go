@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_generateCertManagerValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		lagoonEnvVars []lagoon.EnvironmentVariable
+		vars          []helpers.EnvironmentVariable
+		want          CertManagerConfiguration
+		wantErr       bool
+	}{
+		{
+			name: "test1 - certificates not enabled",
+			want: CertManagerConfiguration{},
+		},
+		{
+			name: "test2 - certificates enabled with http-01 and dns-01 issuers",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_CERTIFICATES", Value: "enabled"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_HTTP01_ISSUER", Value: "lagoon-letsencrypt"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_DNS01_ISSUER", Value: "lagoon-letsencrypt-dns"},
+			},
+			want: CertManagerConfiguration{
+				Enabled:      true,
+				HTTP01Issuer: "lagoon-letsencrypt",
+				DNS01Issuer:  "lagoon-letsencrypt-dns",
+				IssuerKind:   "ClusterIssuer",
+			},
+		},
+		{
+			name: "test3 - certificates enabled with a namespaced issuer",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_CERTMANAGER_ISSUER_KIND", Value: "Issuer", Scope: "build"},
+			},
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_DEFAULT_CERTMANAGER_CERTIFICATES", Value: "enabled"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_HTTP01_ISSUER", Value: "lagoon-letsencrypt"},
+			},
+			want: CertManagerConfiguration{
+				Enabled:      true,
+				HTTP01Issuer: "lagoon-letsencrypt",
+				IssuerKind:   "Issuer",
+			},
+		},
+		{
+			name: "test4 - certificates enabled without an issuer",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_CERTIFICATES", Value: "enabled"},
+			},
+			want:    CertManagerConfiguration{},
+			wantErr: true,
+		},
+		{
+			name: "test5 - certificates enabled with an invalid issuer kind",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_CERTIFICATES", Value: "enabled"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_HTTP01_ISSUER", Value: "lagoon-letsencrypt"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CERTMANAGER_ISSUER_KIND", Value: "ExternalIssuer"},
+			},
+			want:    CertManagerConfiguration{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, envVar := range tt.vars {
+				err := os.Setenv(envVar.Name, envVar.Value)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(tt.vars)
+			})
+			buildValues := &BuildValues{}
+			if err := generateCertManagerValues(buildValues, tt.lagoonEnvVars, false); (err != nil) != tt.wantErr {
+				t.Errorf("generateCertManagerValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			lValues, _ := json.Marshal(buildValues.CertManager)
+			wValues, _ := json.Marshal(tt.want)
+			if !reflect.DeepEqual(string(lValues), string(wValues)) {
+				t.Errorf("generateCertManagerValues() = %v, want %v", string(lValues), string(wValues))
+			}
+		})
+	}
+}
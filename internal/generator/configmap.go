@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// dbaasVariableSuffixes are the suffixes of the variables that the build adds to the `lagoon-env` configmap for each dbaas
+// service once its consumer has been provisioned, the variables are prefixed with the uppercased name of the service
+var dbaasVariableSuffixes = []string{
+	"_HOST",
+	"_USERNAME",
+	"_PASSWORD",
+	"_DATABASE",
+	"_PORT",
+	"_READREPLICA_HOSTS",
+	"_AUTHSOURCE",
+	"_AUTHMECHANISM",
+	"_AUTHTLS",
+}
+
+// LagoonEnvConfigMapData returns the variables that are injected into the `lagoon-env` configmap
+// only variables with a `runtime` or `global` scope are added, if a variable is defined more than once the last one wins
+func LagoonEnvConfigMapData(lagoonEnvVars []lagoon.EnvironmentVariable) map[string]string {
+	data := map[string]string{}
+	for _, envVar := range lagoonEnvVars {
+		if envVar.Scope == "runtime" || envVar.Scope == "global" {
+			data[envVar.Name] = envVar.Value
+		}
+	}
+	return data
+}
+
+// getDBaaSVariables returns the dbaas variables of the services that are in the `lagoon-env` configmap in the namespace
+// the credentials of a dbaas consumer are only known once it has been provisioned, so the build patches them into the
+// configmap after it has been applied. if there is no client or configmap, then there are no dbaas variables
+func getDBaaSVariables(client kubernetes.Interface, namespace string, services []ServiceValues) (map[string]string, error) {
+	dbaasVars := map[string]string{}
+	if client == nil {
+		return dbaasVars, nil
+	}
+	hasDBaaS := false
+	for _, service := range services {
+		if strings.HasSuffix(service.Type, "-dbaas") {
+			hasDBaaS = true
+			break
+		}
+	}
+	if !hasDBaaS {
+		return dbaasVars, nil
+	}
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), "lagoon-env", metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return dbaasVars, nil
+		}
+		return nil, fmt.Errorf("unable to get the lagoon-env configmap in namespace %s: %v", namespace, err)
+	}
+	for _, service := range services {
+		if !strings.HasSuffix(service.Type, "-dbaas") {
+			continue
+		}
+		prefix := strings.ReplaceAll(strings.ToUpper(service.OverrideName), "-", "_")
+		for _, suffix := range dbaasVariableSuffixes {
+			if value, ok := configMap.Data[prefix+suffix]; ok {
+				dbaasVars[prefix+suffix] = value
+			}
+		}
+	}
+	return dbaasVars, nil
+}
+
+// generateConfigMapSha calculates the sha256 of the `lagoon-env` configmap data, including any dbaas variables
+// this value is added to the deployments so that they will trigger a rollout if only the configmap has changed
+func generateConfigMapSha(lagoonEnvVars []lagoon.EnvironmentVariable, dbaasVars map[string]string) (string, error) {
+	data := LagoonEnvConfigMapData(lagoonEnvVars)
+	for name, value := range dbaasVars {
+		data[name] = value
+	}
+	// json marshalling a map sorts the keys, so the sha is the same no matter which order the variables were defined in
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("unable to calculate the configmap sha: %v", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(dataBytes)), nil
+}
@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLagoonEnvConfigMapData(t *testing.T) {
+	tests := []struct {
+		name          string
+		lagoonEnvVars []lagoon.EnvironmentVariable
+		want          map[string]string
+	}{
+		{
+			name: "test1 - only runtime and global variables",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{
+				{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+				{Name: "MY_GLOBAL_VAR", Value: "global", Scope: "global"},
+				{Name: "MY_BUILD_VAR", Value: "build", Scope: "build"},
+				{Name: "LAGOON_FEATURE_FLAG_ROOTLESS_WORKLOAD", Value: "enabled", Scope: "build"},
+				{Name: "MY_CONTAINER_REGISTRY", Value: "registry", Scope: "container_registry"},
+			},
+			want: map[string]string{
+				"LAGOON_PROJECT": "example-project",
+				"MY_GLOBAL_VAR":  "global",
+			},
+		},
+		{
+			name: "test2 - duplicate variables",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{
+				{Name: "MY_VAR", Value: "first", Scope: "runtime"},
+				{Name: "MY_VAR", Value: "second", Scope: "global"},
+			},
+			want: map[string]string{
+				"MY_VAR": "second",
+			},
+		},
+		{
+			name:          "test3 - no variables",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{},
+			want:          map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LagoonEnvConfigMapData(tt.lagoonEnvVars); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LagoonEnvConfigMapData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_generateConfigMapSha(t *testing.T) {
+	tests := []struct {
+		name          string
+		lagoonEnvVars []lagoon.EnvironmentVariable
+		dbaasVars     map[string]string
+		want          string
+	}{
+		{
+			name: "test1 - runtime variables",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{
+				{Name: "MY_VAR", Value: "myvar", Scope: "runtime"},
+				{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+			},
+			want: "048ad326adf1ad9c79e0d8782766e668deb4351f556d186e2ddaff2fc66ff865",
+		},
+		{
+			name: "test2 - variables in any order generate the same sha",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{
+				{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+				{Name: "MY_VAR", Value: "myvar", Scope: "runtime"},
+				{Name: "MY_BUILD_VAR", Value: "build", Scope: "build"},
+			},
+			want: "048ad326adf1ad9c79e0d8782766e668deb4351f556d186e2ddaff2fc66ff865",
+		},
+		{
+			name:          "test3 - no variables",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{},
+			want:          "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+		},
+		{
+			name: "test4 - dbaas variables change the sha",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{
+				{Name: "MY_VAR", Value: "myvar", Scope: "runtime"},
+				{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+			},
+			dbaasVars: map[string]string{
+				"MARIADB_PASSWORD": "abcdefg",
+			},
+			want: "5dd686929d0f37f5186a109b856b3554aa3f5fc05a9f7bdec70e2bd75b12ba7e",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateConfigMapSha(tt.lagoonEnvVars, tt.dbaasVars)
+			if err != nil {
+				t.Errorf("generateConfigMapSha() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("generateConfigMapSha() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getDBaaSVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		objects  []runtime.Object
+		noClient bool
+		services []ServiceValues
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "test1 - dbaas variables from the configmap",
+			objects: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "lagoon-env",
+						Namespace: "example-project-main",
+					},
+					Data: map[string]string{
+						"LAGOON_PROJECT":               "example-project",
+						"MARIADB_DB_HOST":              "mariadb.example.com",
+						"MARIADB_DB_PASSWORD":          "abcdefg",
+						"MARIADB_DB_READREPLICA_HOSTS": "replica.example.com",
+						"MONGO_AUTHSOURCE":             "admin",
+						"NGINX_HOST":                   "nginx",
+					},
+				},
+			},
+			services: []ServiceValues{
+				{Name: "nginx", OverrideName: "nginx", Type: "nginx-php"},
+				{Name: "mariadb-db", OverrideName: "mariadb-db", Type: "mariadb-dbaas"},
+				{Name: "mongo", OverrideName: "mongo", Type: "mongodb-dbaas"},
+			},
+			want: map[string]string{
+				"MARIADB_DB_HOST":              "mariadb.example.com",
+				"MARIADB_DB_PASSWORD":          "abcdefg",
+				"MARIADB_DB_READREPLICA_HOSTS": "replica.example.com",
+				"MONGO_AUTHSOURCE":             "admin",
+			},
+		},
+		{
+			name:    "test2 - no configmap",
+			objects: []runtime.Object{},
+			services: []ServiceValues{
+				{Name: "mariadb", OverrideName: "mariadb", Type: "mariadb-dbaas"},
+			},
+			want: map[string]string{},
+		},
+		{
+			name:     "test3 - no client",
+			noClient: true,
+			services: []ServiceValues{
+				{Name: "mariadb", OverrideName: "mariadb", Type: "mariadb-dbaas"},
+			},
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var client kubernetes.Interface
+			if !tt.noClient {
+				client = fake.NewSimpleClientset(tt.objects...)
+			}
+			got, err := getDBaaSVariables(client, "example-project-main", tt.services)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getDBaaSVariables() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getDBaaSVariables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// generateCronjobValues populates the native and in pod cronjobs for a service from the cronjobs defined
+// in the environment block of the .lagoon.yml file
+func generateCronjobValues(
+	buildValues *BuildValues,
+	serviceValues *ServiceValues,
+	lYAML *lagoon.YAML,
+	mergedVariables []lagoon.EnvironmentVariable,
+) error {
+	// cronjobs can be disabled entirely for an environment
+	cronjobsDisabled, _ := lagoon.GetLagoonVariable("LAGOON_CRONJOBS_DISABLED", []string{"build"}, mergedVariables)
+	if cronjobsDisabled != nil && cronjobsDisabled.Value == "true" {
+		return nil
+	}
+	inPodCronjobs := []string{}
+	for _, cronjob := range lYAML.Environments[buildValues.Branch].Cronjobs {
+		if cronjob.Service != serviceValues.OverrideName {
+			continue
+		}
+		cronjobName := sanitizeCronjobName(cronjob.Name)
+		schedule, err := helpers.ConvertCrontab(buildValues.Namespace, cronjob.Schedule)
+		if err != nil {
+			return fmt.Errorf("unable to convert crontab for cronjob %s: %v", cronjob.Name, err)
+		}
+		if cronScheduleMoreOftenThan30Minutes(cronjob.Schedule) {
+			// if the cronjob runs more often than every 30 minutes, it is run inside the pod itself
+			inPodCronjobs = append(inPodCronjobs, fmt.Sprintf("%s %s", schedule, cronjob.Command))
+		} else {
+			// otherwise a kubernetes native cronjob is created for it
+			if serviceValues.NativeCronjobs == nil {
+				serviceValues.NativeCronjobs = map[string]CronjobValues{}
+			}
+			serviceValues.NativeCronjobs[strings.ToLower(cronjobName)] = CronjobValues{
+				Schedule: schedule,
+				Command:  cronjob.Command,
+			}
+		}
+	}
+	serviceValues.InPodCronjobs = strings.Join(inPodCronjobs, "\n")
+	return nil
+}
+
+// sanitizeCronjobName replaces any characters that are not alphanumeric or a hyphen and strips a leading hyphen
+func sanitizeCronjobName(name string) string {
+	name = regexp.MustCompile(`[^[:alnum:]-]`).ReplaceAllString(name, "-")
+	return strings.TrimPrefix(name, "-")
+}
+
+// cronScheduleMoreOftenThan30Minutes checks the unconverted cron schedule and returns true if
+// the schedule runs more often than every 30 minutes
+func cronScheduleMoreOftenThan30Minutes(schedule string) bool {
+	minute := strings.Split(schedule, " ")[0]
+	match := regexp.MustCompile(`^(M|H|\*)/([0-5]?[0-9])$`).FindStringSubmatch(minute)
+	if match != nil {
+		// a schedule like M/xx, H/xx or */xx with a step smaller than 30 runs more often than every 30 minutes
+		step, _ := strconv.Atoi(match[2])
+		return step < 30
+	}
+	// running every minute is more often than every 30 minutes, all other cases are not
+	return minute == "*"
+}
@@ -0,0 +1,184 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_generateCronjobValues(t *testing.T) {
+	type args struct {
+		buildValues     *BuildValues
+		serviceValues   *ServiceValues
+		lYAML           *lagoon.YAML
+		mergedVariables []lagoon.EnvironmentVariable
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *ServiceValues
+		wantErr bool
+	}{
+		{
+			name: "test1 - native and in pod cronjobs",
+			args: args{
+				buildValues: &BuildValues{
+					Branch:    "main",
+					Namespace: "example-project-main",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "cli",
+					OverrideName: "cli",
+					Type:         "cli-persistent",
+				},
+				lYAML: &lagoon.YAML{
+					Environments: lagoon.Environments{
+						"main": lagoon.Environment{
+							Cronjobs: []lagoon.Cronjob{
+								{Name: "drush cron", Service: "cli", Schedule: "M/5 * * * *", Command: "drush cron"},
+								{Name: "every minute", Service: "cli", Schedule: "* * * * *", Command: "date"},
+								{Name: "Nightly Cleanup", Service: "cli", Schedule: "M 4 * * *", Command: "drush cleanup"},
+								{Name: "half hourly", Service: "cli", Schedule: "M/30 * * * *", Command: "drush half"},
+								{Name: "other service", Service: "nginx", Schedule: "M 4 * * *", Command: "date"},
+							},
+						},
+					},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "cli",
+				OverrideName: "cli",
+				Type:         "cli-persistent",
+				NativeCronjobs: map[string]CronjobValues{
+					"nightly-cleanup": {Schedule: "48 4 * * *", Command: "drush cleanup"},
+					"half-hourly":     {Schedule: "18,48 * * * *", Command: "drush half"},
+				},
+				InPodCronjobs: "3,8,13,18,23,28,33,38,43,48,53,58 * * * * drush cron\n* * * * * date",
+			},
+		},
+		{
+			name: "test2 - cronjobs disabled",
+			args: args{
+				buildValues: &BuildValues{
+					Branch:    "main",
+					Namespace: "example-project-main",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "cli",
+					OverrideName: "cli",
+					Type:         "cli-persistent",
+				},
+				lYAML: &lagoon.YAML{
+					Environments: lagoon.Environments{
+						"main": lagoon.Environment{
+							Cronjobs: []lagoon.Cronjob{
+								{Name: "drush cron", Service: "cli", Schedule: "M/5 * * * *", Command: "drush cron"},
+								{Name: "nightly", Service: "cli", Schedule: "M 4 * * *", Command: "drush cleanup"},
+							},
+						},
+					},
+				},
+				mergedVariables: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_CRONJOBS_DISABLED", Value: "true", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "cli",
+				OverrideName: "cli",
+				Type:         "cli-persistent",
+			},
+		},
+		{
+			name: "test3 - cronjobs for a different environment",
+			args: args{
+				buildValues: &BuildValues{
+					Branch:    "develop",
+					Namespace: "example-project-develop",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "cli",
+					OverrideName: "cli",
+					Type:         "cli-persistent",
+				},
+				lYAML: &lagoon.YAML{
+					Environments: lagoon.Environments{
+						"main": lagoon.Environment{
+							Cronjobs: []lagoon.Cronjob{
+								{Name: "nightly", Service: "cli", Schedule: "M 4 * * *", Command: "drush cleanup"},
+							},
+						},
+					},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "cli",
+				OverrideName: "cli",
+				Type:         "cli-persistent",
+			},
+		},
+		{
+			name: "test4 - invalid schedule",
+			args: args{
+				buildValues: &BuildValues{
+					Branch:    "main",
+					Namespace: "example-project-main",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "cli",
+					OverrideName: "cli",
+					Type:         "cli-persistent",
+				},
+				lYAML: &lagoon.YAML{
+					Environments: lagoon.Environments{
+						"main": lagoon.Environment{
+							Cronjobs: []lagoon.Cronjob{
+								{Name: "nightly", Service: "cli", Schedule: "M 4 * * * *", Command: "drush cleanup"},
+							},
+						},
+					},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "cli",
+				OverrideName: "cli",
+				Type:         "cli-persistent",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := generateCronjobValues(tt.args.buildValues, tt.args.serviceValues, tt.args.lYAML, tt.args.mergedVariables); (err != nil) != tt.wantErr {
+				t.Errorf("generateCronjobValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			lValues, _ := json.Marshal(tt.args.serviceValues)
+			wValues, _ := json.Marshal(tt.want)
+			if !reflect.DeepEqual(string(lValues), string(wValues)) {
+				t.Errorf("generateCronjobValues() = %v, want %v", string(lValues), string(wValues))
+			}
+		})
+	}
+}
+
+func Test_cronScheduleMoreOftenThan30Minutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		want     bool
+	}{
+		{name: "every 15 minutes", schedule: "M/15 * * * *", want: true},
+		{name: "every minute", schedule: "* * * * *", want: true},
+		{name: "every 30 minutes", schedule: "*/30 * * * *", want: false},
+		{name: "hourly", schedule: "M * * * *", want: false},
+		{name: "list of minutes", schedule: "0,15,30,45 * * * *", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cronScheduleMoreOftenThan30Minutes(tt.schedule); got != tt.want {
+				t.Errorf("cronScheduleMoreOftenThan30Minutes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
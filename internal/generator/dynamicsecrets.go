@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	dynamicSecretLabel         = "lagoon.sh/dynamic-secret"
+	dynamicSecretMountBasePath = "/var/run/secrets/lagoon/dynamic/"
+	dynamicSecretVolumePrefix  = "dynamic-"
+)
+
+// getDynamicSecrets returns the names of any secrets in the namespace that have the `lagoon.sh/dynamic-secret` label
+// if a dynamic secrets file is provided, the secret names are read from the file instead of the cluster
+// if neither a client or file are provided, then there are no dynamic secrets
+func getDynamicSecrets(client kubernetes.Interface, namespace, dynamicSecretsFile string) ([]string, error) {
+	dynamicSecrets := []string{}
+	if dynamicSecretsFile != "" {
+		file, err := os.Open(dynamicSecretsFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read dynamic secrets file %s: %v", dynamicSecretsFile, err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			secretName := strings.TrimSpace(scanner.Text())
+			if secretName != "" {
+				dynamicSecrets = append(dynamicSecrets, secretName)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("unable to read dynamic secrets file %s: %v", dynamicSecretsFile, err)
+		}
+	} else if client != nil {
+		secrets, err := client.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: dynamicSecretLabel,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list dynamic secrets in namespace %s: %v", namespace, err)
+		}
+		for _, secret := range secrets.Items {
+			dynamicSecrets = append(dynamicSecrets, secret.ObjectMeta.Name)
+		}
+	}
+	// sort the secrets so the volumes are generated in a consistent order
+	sort.Strings(dynamicSecrets)
+	return dynamicSecrets, nil
+}
+
+// generateDynamicSecretValues populates the volumes and volume mounts of a service for any dynamic secrets
+func generateDynamicSecretValues(buildValues *BuildValues, serviceValues *ServiceValues) {
+	// dbaas services don't run any pods, so there is nothing to mount the secrets into
+	if strings.HasSuffix(serviceValues.Type, "-dbaas") {
+		return
+	}
+	for _, secretName := range buildValues.DynamicSecrets {
+		volumeName := fmt.Sprintf("%s%s", dynamicSecretVolumePrefix, secretName)
+		serviceValues.DynamicSecretMounts = append(serviceValues.DynamicSecretMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: fmt.Sprintf("%s%s", dynamicSecretMountBasePath, secretName),
+			ReadOnly:  true,
+		})
+		serviceValues.DynamicSecretVolumes = append(serviceValues.DynamicSecretVolumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+					Optional:   helpers.BoolPtr(false),
+				},
+			},
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_getDynamicSecrets(t *testing.T) {
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		noClient     bool
+		fileContents string
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name: "test1 - labelled secrets from the cluster",
+			objects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "insights-token",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/dynamic-secret": "insights-token"},
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "example-dynamic-secret",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/dynamic-secret": "example"},
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "lagoon-sshkey",
+						Namespace: "example-project-main",
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "other-namespace-secret",
+						Namespace: "example-project-develop",
+						Labels:    map[string]string{"lagoon.sh/dynamic-secret": "other"},
+					},
+				},
+			},
+			want: []string{"example-dynamic-secret", "insights-token"},
+		},
+		{
+			name:    "test2 - no labelled secrets in the cluster",
+			objects: []runtime.Object{},
+			want:    []string{},
+		},
+		{
+			name: "test3 - secrets from a file instead of the cluster",
+			objects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "insights-token",
+						Namespace: "example-project-main",
+						Labels:    map[string]string{"lagoon.sh/dynamic-secret": "insights-token"},
+					},
+				},
+			},
+			fileContents: "secret-b\n\n  secret-a  \n",
+			want:         []string{"secret-a", "secret-b"},
+		},
+		{
+			name:     "test4 - no client or file",
+			noClient: true,
+			want:     []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var client kubernetes.Interface
+			if !tt.noClient {
+				client = fake.NewSimpleClientset(tt.objects...)
+			}
+			dynamicSecretsFile := ""
+			if tt.fileContents != "" {
+				dynamicSecretsFile = filepath.Join(t.TempDir(), "dynamic-secrets")
+				if err := os.WriteFile(dynamicSecretsFile, []byte(tt.fileContents), 0644); err != nil {
+					t.Errorf("couldn't write file %v: %v", dynamicSecretsFile, err)
+				}
+			}
+			got, err := getDynamicSecrets(client, "example-project-main", dynamicSecretsFile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getDynamicSecrets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getDynamicSecrets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_generateDynamicSecretValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		buildValues   *BuildValues
+		serviceValues *ServiceValues
+		want          *ServiceValues
+	}{
+		{
+			name: "test1 - dynamic secrets",
+			buildValues: &BuildValues{
+				DynamicSecrets: []string{"insights-token"},
+			},
+			serviceValues: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+				DynamicSecretMounts: []corev1.VolumeMount{
+					{
+						Name:      "dynamic-insights-token",
+						MountPath: "/var/run/secrets/lagoon/dynamic/insights-token",
+						ReadOnly:  true,
+					},
+				},
+				DynamicSecretVolumes: []corev1.Volume{
+					{
+						Name: "dynamic-insights-token",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: "insights-token",
+								Optional:   helpers.BoolPtr(false),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test2 - dbaas services don't mount dynamic secrets",
+			buildValues: &BuildValues{
+				DynamicSecrets: []string{"insights-token"},
+			},
+			serviceValues: &ServiceValues{
+				Name:         "mariadb",
+				OverrideName: "mariadb",
+				Type:         "mariadb-dbaas",
+			},
+			want: &ServiceValues{
+				Name:         "mariadb",
+				OverrideName: "mariadb",
+				Type:         "mariadb-dbaas",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generateDynamicSecretValues(tt.buildValues, tt.serviceValues)
+			lValues, _ := json.Marshal(tt.serviceValues)
+			wValues, _ := json.Marshal(tt.want)
+			if !reflect.DeepEqual(string(lValues), string(wValues)) {
+				t.Errorf("generateDynamicSecretValues() = %v, want %v", string(lValues), string(wValues))
+			}
+		})
+	}
+}
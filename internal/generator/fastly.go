@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// generateFastlyAPISecrets collects the fastly api secrets that should be created for an environment from the
+// .lagoon.yml file and the `LAGOON_FASTLY_API_SECRETS` lagoon variable
+func generateFastlyAPISecrets(
+	buildValues *BuildValues,
+	lYAML *lagoon.YAML,
+	mergedVariables []lagoon.EnvironmentVariable,
+) error {
+	buildValues.FastlyAPISecrets = map[string]FastlyAPISecret{}
+	// if a customer is using their own fastly configuration, then they can define their api token and platform tls configuration ID
+	// in the .lagoon.yml file, the token itself is sourced from the lagoon variable defined in `apiTokenVariableName`
+	for _, apiSecret := range lYAML.Fastly.APISecrets {
+		if apiSecret.Name == "" {
+			return fmt.Errorf("a fastly api secret was defined in the .lagoon.yml file, but no name could be found the .lagoon.yml, please check if the name has been set correctly")
+		}
+		secretName := fmt.Sprintf("%s%s", buildValues.FastlyAPISecretPrefix, apiSecret.Name)
+		if apiSecret.APITokenVariableName == "" {
+			return fmt.Errorf("no 'apiTokenVariableName' defined for fastly secret %s", secretName)
+		}
+		apiToken, _ := lagoon.GetLagoonVariable(apiSecret.APITokenVariableName, []string{"build", "global"}, mergedVariables)
+		if apiToken == nil || apiToken.Value == "" {
+			return fmt.Errorf("a fastly api secret was defined in the .lagoon.yml file, but no token could be found in the Lagoon API matching the variable name provided, please check if the token has been set correctly")
+		}
+		if apiSecret.PlatformTLSConfiguration == "" {
+			return fmt.Errorf("a fastly api secret was defined in the .lagoon.yml file, but no platform tls configuration id could be found in the .lagoon.yml, please check if the platform tls configuration id has been set correctly")
+		}
+		buildValues.FastlyAPISecrets[secretName] = FastlyAPISecret{
+			Name:                     secretName,
+			APIToken:                 apiToken.Value,
+			PlatformTLSConfiguration: apiSecret.PlatformTLSConfiguration,
+		}
+	}
+
+	// fastly api secrets can also be defined using the `LAGOON_FASTLY_API_SECRETS` lagoon variable
+	// this accepts colon separated values like so `SECRET_NAME:FASTLY_API_TOKEN:FASTLY_PLATFORMTLS_CONFIGURATION_ID`, and multiple
+	// secrets separated by commas, any secrets defined here will replace secrets with the same name from the .lagoon.yml file
+	lfaSecrets, _ := lagoon.GetLagoonVariable("LAGOON_FASTLY_API_SECRETS", []string{"build", "global"}, mergedVariables)
+	if lfaSecrets != nil {
+		for _, lfaSecret := range strings.Split(lfaSecrets.Value, ",") {
+			lfaSecretSplit := strings.Split(lfaSecret, ":")
+			if len(lfaSecretSplit) != 3 || lfaSecretSplit[0] == "" || lfaSecretSplit[1] == "" || lfaSecretSplit[2] == "" {
+				return fmt.Errorf("an override was defined in the lagoon API with LAGOON_FASTLY_API_SECRETS but was not structured correctly, the format should be NAME:FASTLY_API_TOKEN:FASTLY_PLATFORMTLS_CONFIGURATION_ID and comma separated for multiples")
+			}
+			secretName := fmt.Sprintf("%s%s", buildValues.FastlyAPISecretPrefix, lfaSecretSplit[0])
+			buildValues.FastlyAPISecrets[secretName] = FastlyAPISecret{
+				Name:                     secretName,
+				APIToken:                 lfaSecretSplit[1],
+				PlatformTLSConfiguration: lfaSecretSplit[2],
+			}
+		}
+	}
+	return nil
+}
+
+// CheckFastlyAPISecrets checks that any fastly api secret referenced by a route will be created by the build
+func CheckFastlyAPISecrets(buildValues BuildValues, routes ...*lagoon.RoutesV2) error {
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		for _, route := range r.Routes {
+			if route.Fastly.APISecretName == "" {
+				continue
+			}
+			if _, ok := buildValues.FastlyAPISecrets[route.Fastly.APISecretName]; !ok {
+				return fmt.Errorf("the route %s references the fastly api secret %s, but this secret is not defined in the .lagoon.yml file or the LAGOON_FASTLY_API_SECRETS variable", route.Domain, route.Fastly.APISecretName)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_generateFastlyAPISecrets(t *testing.T) {
+	type args struct {
+		buildValues     *BuildValues
+		lYAML           *lagoon.YAML
+		mergedVariables []lagoon.EnvironmentVariable
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]FastlyAPISecret
+		wantErr bool
+	}{
+		{
+			name: "test1 - api secret from lagoon.yml",
+			args: args{
+				buildValues: &BuildValues{
+					FastlyAPISecretPrefix: "fastly-api-",
+				},
+				lYAML: &lagoon.YAML{
+					Fastly: lagoon.LagoonFastly{
+						APISecrets: []lagoon.FastlyAPISecret{
+							{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+						},
+					},
+				},
+				mergedVariables: []lagoon.EnvironmentVariable{
+					{Name: "FASTLY_API_TOKEN", Value: "abcdefg123456", Scope: "build"},
+				},
+			},
+			want: map[string]FastlyAPISecret{
+				"fastly-api-examplecom": {Name: "fastly-api-examplecom", APIToken: "abcdefg123456", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+			},
+		},
+		{
+			name: "test2 - LAGOON_FASTLY_API_SECRETS replaces the lagoon.yml secret",
+			args: args{
+				buildValues: &BuildValues{
+					FastlyAPISecretPrefix: "fastly-api-",
+				},
+				lYAML: &lagoon.YAML{
+					Fastly: lagoon.LagoonFastly{
+						APISecrets: []lagoon.FastlyAPISecret{
+							{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+						},
+					},
+				},
+				mergedVariables: []lagoon.EnvironmentVariable{
+					{Name: "FASTLY_API_TOKEN", Value: "abcdefg123456", Scope: "build"},
+					{Name: "LAGOON_FASTLY_API_SECRETS", Value: "examplecom:hijklmn789:B2cdEfGhI23fE353Tet,anothercom:opqrst:C3deFgHiJ34gF464Ufu", Scope: "build"},
+				},
+			},
+			want: map[string]FastlyAPISecret{
+				"fastly-api-examplecom": {Name: "fastly-api-examplecom", APIToken: "hijklmn789", PlatformTLSConfiguration: "B2cdEfGhI23fE353Tet"},
+				"fastly-api-anothercom": {Name: "fastly-api-anothercom", APIToken: "opqrst", PlatformTLSConfiguration: "C3deFgHiJ34gF464Ufu"},
+			},
+		},
+		{
+			name: "test3 - missing platform tls configuration",
+			args: args{
+				buildValues: &BuildValues{
+					FastlyAPISecretPrefix: "fastly-api-",
+				},
+				lYAML: &lagoon.YAML{
+					Fastly: lagoon.LagoonFastly{
+						APISecrets: []lagoon.FastlyAPISecret{
+							{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN"},
+						},
+					},
+				},
+				mergedVariables: []lagoon.EnvironmentVariable{
+					{Name: "FASTLY_API_TOKEN", Value: "abcdefg123456", Scope: "build"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generateFastlyAPISecrets(tt.args.buildValues, tt.args.lYAML, tt.args.mergedVariables)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("generateFastlyAPISecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(tt.args.buildValues.FastlyAPISecrets, tt.want) {
+				t.Errorf("generateFastlyAPISecrets() = %v, want %v", tt.args.buildValues.FastlyAPISecrets, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+const (
+	defaultGatewayAPIHTTPListener  = "http"
+	defaultGatewayAPIHTTPSListener = "https"
+)
+
+// generateGatewayAPIValues checks the `GATEWAY_API_ROUTES` feature flag to see if routes should be templated as gateway api httproutes
+// the gateway the routes attach to is defined by the `GATEWAY_API_PARENT` feature flag in the format `namespace/name`, and the
+// listeners on that gateway used for http and https can be changed with `GATEWAY_API_HTTP_LISTENER` and `GATEWAY_API_HTTPS_LISTENER`
+func generateGatewayAPIValues(
+	buildValues *BuildValues,
+	lagoonEnvVars []lagoon.EnvironmentVariable,
+	debug bool,
+) error {
+	if CheckFeatureFlag("GATEWAY_API_ROUTES", lagoonEnvVars, debug) != "enabled" {
+		return nil
+	}
+	parent := CheckFeatureFlag("GATEWAY_API_PARENT", lagoonEnvVars, debug)
+	if parent == "" {
+		return fmt.Errorf("the gateway api is enabled, but no parent gateway has been defined with the GATEWAY_API_PARENT feature flag")
+	}
+	parentSplit := strings.Split(parent, "/")
+	switch len(parentSplit) {
+	case 1:
+		buildValues.GatewayAPI.ParentName = parentSplit[0]
+	case 2:
+		buildValues.GatewayAPI.ParentNamespace = parentSplit[0]
+		buildValues.GatewayAPI.ParentName = parentSplit[1]
+	default:
+		buildValues.GatewayAPI.ParentName = ""
+	}
+	if buildValues.GatewayAPI.ParentName == "" || (len(parentSplit) == 2 && buildValues.GatewayAPI.ParentNamespace == "") {
+		return fmt.Errorf("the gateway api parent gateway %s is not valid, it should be in the format namespace/name", parent)
+	}
+	buildValues.GatewayAPI.HTTPListener = CheckFeatureFlag("GATEWAY_API_HTTP_LISTENER", lagoonEnvVars, debug)
+	if buildValues.GatewayAPI.HTTPListener == "" {
+		buildValues.GatewayAPI.HTTPListener = defaultGatewayAPIHTTPListener
+	}
+	buildValues.GatewayAPI.HTTPSListener = CheckFeatureFlag("GATEWAY_API_HTTPS_LISTENER", lagoonEnvVars, debug)
+	if buildValues.GatewayAPI.HTTPSListener == "" {
+		buildValues.GatewayAPI.HTTPSListener = defaultGatewayAPIHTTPSListener
+	}
+	buildValues.GatewayAPI.Enabled = true
+	return nil
+}
@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_generateGatewayAPIValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		lagoonEnvVars []lagoon.EnvironmentVariable
+		vars          []helpers.EnvironmentVariable
+		want          GatewayAPIConfiguration
+		wantErr       bool
+	}{
+		{
+			name: "test1 - gateway api not enabled",
+			want: GatewayAPIConfiguration{},
+		},
+		{
+			name: "test2 - gateway api enabled with a namespaced parent",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_GATEWAY_API_ROUTES", Value: "enabled"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_GATEWAY_API_PARENT", Value: "gateway-system/lagoon-gateway"},
+			},
+			want: GatewayAPIConfiguration{
+				Enabled:         true,
+				ParentName:      "lagoon-gateway",
+				ParentNamespace: "gateway-system",
+				HTTPListener:    "http",
+				HTTPSListener:   "https",
+			},
+		},
+		{
+			name: "test3 - gateway api enabled with custom listeners",
+			lagoonEnvVars: []lagoon.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_GATEWAY_API_HTTPS_LISTENER", Value: "websecure", Scope: "build"},
+			},
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_DEFAULT_GATEWAY_API_ROUTES", Value: "enabled"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_GATEWAY_API_PARENT", Value: "lagoon-gateway"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_GATEWAY_API_HTTP_LISTENER", Value: "web"},
+			},
+			want: GatewayAPIConfiguration{
+				Enabled:       true,
+				ParentName:    "lagoon-gateway",
+				HTTPListener:  "web",
+				HTTPSListener: "websecure",
+			},
+		},
+		{
+			name: "test4 - gateway api enabled without a parent",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_GATEWAY_API_ROUTES", Value: "enabled"},
+			},
+			want:    GatewayAPIConfiguration{},
+			wantErr: true,
+		},
+		{
+			name: "test5 - gateway api enabled with an invalid parent",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_GATEWAY_API_ROUTES", Value: "enabled"},
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_GATEWAY_API_PARENT", Value: "gateway-system/"},
+			},
+			want: GatewayAPIConfiguration{
+				ParentNamespace: "gateway-system",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, envVar := range tt.vars {
+				err := os.Setenv(envVar.Name, envVar.Value)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(tt.vars)
+			})
+			buildValues := &BuildValues{}
+			if err := generateGatewayAPIValues(buildValues, tt.lagoonEnvVars, false); (err != nil) != tt.wantErr {
+				t.Errorf("generateGatewayAPIValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			lValues, _ := json.Marshal(buildValues.GatewayAPI)
+			wValues, _ := json.Marshal(tt.want)
+			if !reflect.DeepEqual(string(lValues), string(wValues)) {
+				t.Errorf("generateGatewayAPIValues() = %v, want %v", string(lValues), string(wValues))
+			}
+		})
+	}
+}
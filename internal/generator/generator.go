@@ -12,6 +12,8 @@ import (
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 	"github.com/uselagoon/machinery/utils/conversion"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	gatewayclient "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 	"sigs.k8s.io/yaml"
 )
 
@@ -53,6 +55,10 @@ type GeneratorInput struct {
 	IgnoreMissingEnvFiles    bool
 	Debug                    bool
 	DBaaSClient              *dbaasclient.Client
+	KubernetesClient         kubernetes.Interface
+	GatewayAPIClient         gatewayclient.Interface
+	DynamicSecretsFile       string
+	ImageReferencesFile      string
 	Namespace                string
 	DefaultBackupSchedule    string
 }
@@ -78,7 +84,7 @@ func NewGenerator(
 	environmentName := helpers.GetEnv("ENVIRONMENT", generator.EnvironmentName, generator.Debug)
 	branch := helpers.GetEnv("BRANCH", generator.Branch, generator.Debug)
 	prNumber := helpers.GetEnv("PR_NUMBER", generator.PRNumber, generator.Debug)
-	prTitle := helpers.GetEnv("PR_NUMBER", generator.PRTitle, generator.Debug)
+	prTitle := helpers.GetEnv("PR_TITLE", generator.PRTitle, generator.Debug)
 	prHeadBranch := helpers.GetEnv("PR_HEAD_BRANCH", generator.PRHeadBranch, generator.Debug)
 	prBaseBranch := helpers.GetEnv("PR_BASE_BRANCH", generator.PRBaseBranch, generator.Debug)
 	environmentType := helpers.GetEnv("ENVIRONMENT_TYPE", generator.EnvironmentType, generator.Debug)
@@ -86,8 +92,10 @@ func NewGenerator(
 	activeEnvironment := helpers.GetEnv("ACTIVE_ENVIRONMENT", generator.ActiveEnvironment, generator.Debug)
 	standbyEnvironment := helpers.GetEnv("STANDBY_ENVIRONMENT", generator.StandbyEnvironment, generator.Debug)
 	fastlyCacheNoCahce := helpers.GetEnv("LAGOON_FASTLY_NOCACHE_SERVICE_ID", generator.FastlyCacheNoCahce, generator.Debug)
-	fastlyAPISecretPrefix := helpers.GetEnv("ROUTE_FASTLY_SERVICE_ID", generator.FastlyAPISecretPrefix, generator.Debug)
+	fastlyAPISecretPrefix := helpers.GetEnv("FASTLY_API_SECRET_PREFIX", generator.FastlyAPISecretPrefix, generator.Debug)
 	lagoonVersion := helpers.GetEnv("LAGOON_VERSION", generator.LagoonVersion, generator.Debug)
+	dynamicSecretsFile := helpers.GetEnv("DYNAMIC_SECRETS_FILE", generator.DynamicSecretsFile, generator.Debug)
+	imageReferencesFile := helpers.GetEnv("IMAGE_REFERENCES_FILE", generator.ImageReferencesFile, generator.Debug)
 
 	defaultBackupSchedule := helpers.GetEnv("DEFAULT_BACKUP_SCHEDULE", generator.DefaultBackupSchedule, generator.Debug)
 	if defaultBackupSchedule == "" {
@@ -208,6 +216,18 @@ func NewGenerator(
 	ingressClass := CheckFeatureFlag("INGRESS_CLASS", lagoonEnvVars, generator.Debug)
 	buildValues.IngressClass = ingressClass
 
+	// check if routes should be templated as gateway api httproutes instead of ingress
+	err = generateGatewayAPIValues(&buildValues, lagoonEnvVars, generator.Debug)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if cert-manager certificates should be generated for routes
+	err = generateCertManagerValues(&buildValues, lagoonEnvVars, generator.Debug)
+	if err != nil {
+		return nil, err
+	}
+
 	// get any variables from the API here
 	lagoonServiceTypes, _ := lagoon.GetLagoonVariable("LAGOON_SERVICE_TYPES", nil, lagoonEnvVars)
 	buildValues.ServiceTypeOverrides = lagoonServiceTypes
@@ -241,6 +261,12 @@ func NewGenerator(
 		}
 	}
 
+	// check for the isolation network policy feature flag, disabled by default
+	isolationNetworkPolicy := CheckFeatureFlag("ISOLATION_NETWORK_POLICY", lagoonEnvVars, generator.Debug)
+	if isolationNetworkPolicy == "enabled" {
+		buildValues.IsolationNetworkPolicy = true
+	}
+
 	// @TODO: eventually fail builds if this is not set https://github.com/uselagoon/build-deploy-tool/issues/56
 	// lagoonDBaaSFallbackSingle, _ := lagoon.GetLagoonVariable("LAGOON_FEATURE_FLAG_DBAAS_FALLBACK_SINGLE", nil, lagoonEnvVars)
 	// buildValues.DBaaSFallbackSingle = helpers.StrToBool(lagoonDBaaSFallbackSingle.Value)
@@ -252,6 +278,27 @@ func NewGenerator(
 	}
 	/* end backups configuration */
 
+	/* start fastly configuration */
+	err = generateFastlyAPISecrets(&buildValues, lYAML, lagoonEnvVars)
+	if err != nil {
+		return nil, err
+	}
+	/* end fastly configuration */
+
+	/* start dynamic secrets configuration */
+	buildValues.DynamicSecrets, err = getDynamicSecrets(generator.KubernetesClient, buildValues.Namespace, dynamicSecretsFile)
+	if err != nil {
+		return nil, err
+	}
+	/* end dynamic secrets configuration */
+
+	/* start image references configuration */
+	buildValues.ImageReferences, err = getImageReferences(imageReferencesFile)
+	if err != nil {
+		return nil, err
+	}
+	/* end image references configuration */
+
 	/* start compose->service configuration */
 	err = generateServicesFromDockerCompose(&buildValues, lYAML, lagoonEnvVars, generator.IgnoreNonStringKeyErrors, generator.IgnoreMissingEnvFiles, generator.Debug)
 	if err != nil {
@@ -274,8 +321,29 @@ func NewGenerator(
 	if err != nil {
 		return nil, err
 	}
+	// apply any access restrictions to the routes, and collect the basic auth secrets they use
+	err = generateRouteAccessValues(&buildValues, *lYAML, lagoonEnvVars, autogenRoutes, mainRoutes, activeStandbyRoutes)
+	if err != nil {
+		return nil, err
+	}
 	/* end route generation configuration */
 
+	/* start lagoon-env configuration */
+	// the route variables are only known once the routes have been generated, so collect the build variables again
+	// to refresh them in the variables that are injected into the `lagoon-env` configmap
+	configVars = collectBuildVariables(buildValues)
+	lagoonEnvVars = lagoon.MergeVariables(mergedVariables, configVars)
+	// the dbaas variables aren't known to the build, but they are part of the configmap so they need to be in the sha
+	dbaasVars, err := getDBaaSVariables(generator.KubernetesClient, buildValues.Namespace, buildValues.Services)
+	if err != nil {
+		return nil, err
+	}
+	buildValues.ConfigMapSha, err = generateConfigMapSha(lagoonEnvVars, dbaasVars)
+	if err != nil {
+		return nil, err
+	}
+	/* end lagoon-env configuration */
+
 	// finally return the generator values
 	return &Generator{
 		BuildValues:                &buildValues,
@@ -349,6 +417,13 @@ func LoadAndUnmarshalLagoonYml(lagoonYml string, lagoonYmlOverride string, lagoo
 			return fmt.Errorf("unable to merge LAGOON_YAML_OVERRIDE over %v: %v", lagoonYml, err)
 		}
 	}
+	// check the task dependencies now, so a cycle is found before any tasks are run
+	if _, err := lagoon.TaskDependencies(lagoon.UnwindTaskRuns(lYAML.Tasks.Prerollout)); err != nil {
+		return fmt.Errorf("invalid pre-rollout tasks: %v", err)
+	}
+	if _, err := lagoon.TaskDependencies(lagoon.UnwindTaskRuns(lYAML.Tasks.Postrollout)); err != nil {
+		return fmt.Errorf("invalid post-rollout tasks: %v", err)
+	}
 	return nil
 }
 
@@ -385,10 +460,7 @@ func collectBuildVariables(buildValues BuildValues) []lagoon.EnvironmentVariable
 // checks the provided environment variables looking for feature flag based variables
 func CheckFeatureFlag(key string, envVariables []lagoon.EnvironmentVariable, debug bool) string {
 	// check for force value
-	if value, ok := os.LookupEnv(fmt.Sprintf("LAGOON_FEATURE_FLAG_FORCE_%s", key)); ok {
-		if debug {
-			fmt.Println(fmt.Sprintf("Using forced flag value from build variable %s", fmt.Sprintf("LAGOON_FEATURE_FLAG_FORCE_%s", key)))
-		}
+	if value, ok := checkForcedFeatureFlag(key, debug); ok {
 		return value
 	}
 	// check lagoon environment variables
@@ -410,3 +482,12 @@ func CheckFeatureFlag(key string, envVariables []lagoon.EnvironmentVariable, deb
 	// otherwise nothing
 	return ""
 }
+
+// checkForcedFeatureFlag returns the value of a forced feature flag, and if it is set
+func checkForcedFeatureFlag(key string, debug bool) (string, bool) {
+	value, ok := os.LookupEnv(fmt.Sprintf("LAGOON_FEATURE_FLAG_FORCE_%s", key))
+	if ok && debug {
+		fmt.Println(fmt.Sprintf("Using forced flag value from build variable %s", fmt.Sprintf("LAGOON_FEATURE_FLAG_FORCE_%s", key)))
+	}
+	return value, ok
+}
@@ -106,6 +106,14 @@ func GenerateInput(rootCmd cobra.Command, debug bool) (GeneratorInput, error) {
 	if err != nil {
 		return GeneratorInput{}, fmt.Errorf("error reading default-backup-schedule flag: %v", err)
 	}
+	dynamicSecretsFile, err := rootCmd.PersistentFlags().GetString("dynamic-secrets-file")
+	if err != nil {
+		return GeneratorInput{}, fmt.Errorf("error reading dynamic-secrets-file flag: %v", err)
+	}
+	imageReferencesFile, err := rootCmd.PersistentFlags().GetString("image-references-file")
+	if err != nil {
+		return GeneratorInput{}, fmt.Errorf("error reading image-references-file flag: %v", err)
+	}
 	// create a dbaas client with the default configuration
 	dbaas := dbaasclient.NewClient(dbaasclient.Client{})
 	return GeneratorInput{
@@ -135,5 +143,7 @@ func GenerateInput(rootCmd cobra.Command, debug bool) (GeneratorInput, error) {
 		IgnoreNonStringKeyErrors: ignoreNonStringKeyErrors,
 		DBaaSClient:              dbaas,
 		DefaultBackupSchedule:    defaultBackupSchedule,
+		DynamicSecretsFile:       dynamicSecretsFile,
+		ImageReferencesFile:      imageReferencesFile,
 	}, nil
 }
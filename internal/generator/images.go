@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/types"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// getImageReferences reads the references of the images that were built or pulled during the build from a json file
+// the file is a map of the docker-compose service name to the image reference with its digest, eg
+// {"node":"registry.example.com/example-project/main/node@sha256:b2001bab5d2d3f0c..."}
+// if no file is provided, then there are no image references
+func getImageReferences(imageReferencesFile string) (map[string]string, error) {
+	imageReferences := map[string]string{}
+	if imageReferencesFile == "" {
+		return imageReferences, nil
+	}
+	imageBytes, err := os.ReadFile(imageReferencesFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read image references file %s: %v", imageReferencesFile, err)
+	}
+	if err := json.Unmarshal(imageBytes, &imageReferences); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal image references file %s: %v", imageReferencesFile, err)
+	}
+	return imageReferences, nil
+}
+
+// generateImageValues resolves the image reference that a service will be deployed with
+// an image that was built or pulled during the build will use the reference that was provided for it, otherwise
+// images that are pulled use the `lagoon.image` label or the docker-compose image, prefixed with the image cache
+// services in a multi-container deployment (`lagoon.deployment.servicetype`) each resolve their own image, the templates will
+// look up the siblings of a service to find the image for each container
+func generateImageValues(
+	buildValues *BuildValues,
+	serviceValues *ServiceValues,
+	composeServiceValues composetypes.ServiceConfig,
+) {
+	// dbaas services don't run any pods, so there are no images to deploy
+	if strings.HasSuffix(serviceValues.Type, "-dbaas") {
+		return
+	}
+	if imageReference, ok := buildValues.ImageReferences[serviceValues.Name]; ok {
+		serviceValues.ImageName = imageReference
+		return
+	}
+	// images that are built don't have a reference until they have been pushed
+	if composeServiceValues.Build != nil {
+		return
+	}
+	pullImage := composeServiceValues.Image
+	if overrideImage := lagoon.CheckServiceLagoonLabel(composeServiceValues.Labels, "lagoon.image"); overrideImage != "" {
+		// expand any environment variables in the override image
+		pullImage = os.ExpandEnv(overrideImage)
+	}
+	if pullImage == "" {
+		return
+	}
+	// if the image is just an image name (like "alpine") prefix it with `library/` as the image cache does not understand
+	// the magic docker hub images
+	if !strings.Contains(pullImage, "/") {
+		pullImage = fmt.Sprintf("library/%s", pullImage)
+	}
+	// images from a private registry are pulled directly from the registry, not through the image cache
+	for _, registryURL := range buildValues.PrivateRegistryURLS {
+		registryURL = strings.TrimPrefix(strings.TrimPrefix(registryURL, "http://"), "https://")
+		if strings.HasPrefix(pullImage, registryURL) {
+			serviceValues.ImageName = pullImage
+			return
+		}
+	}
+	serviceValues.ImageName = fmt.Sprintf("%s%s", buildValues.ImageCache, pullImage)
+}
@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	composetypes "github.com/compose-spec/compose-go/types"
+)
+
+func Test_getImageReferences(t *testing.T) {
+	tests := []struct {
+		name                string
+		imageReferencesFile string
+		want                map[string]string
+		wantErr             bool
+	}{
+		{
+			name:                "test1 - image references from file",
+			imageReferencesFile: "test-resources/images/image-references.json",
+			want: map[string]string{
+				"node":  "harbor.example.com/example-project/main/node@sha256:b2001bab5d2d3f0c6d5a5e5e1f3a3e0f1c5b1b1d6e4b6a4f7e3b0d6c2a1f9e8d7",
+				"nginx": "harbor.example.com/example-project/main/nginx@sha256:6d5a5e5e1f3a3e0f1c5b1b1d6e4b6a4f7e3b0d6c2a1f9e8d7b2001bab5d2d3f0c",
+			},
+		},
+		{
+			name: "test2 - no file",
+			want: map[string]string{},
+		},
+		{
+			name:                "test3 - missing file",
+			imageReferencesFile: "test-resources/images/missing.json",
+			wantErr:             true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getImageReferences(tt.imageReferencesFile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getImageReferences() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getImageReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_generateImageValues(t *testing.T) {
+	type args struct {
+		buildValues          *BuildValues
+		serviceValues        *ServiceValues
+		composeServiceValues composetypes.ServiceConfig
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "test1 - built image with a reference",
+			args: args{
+				buildValues: &BuildValues{
+					ImageCache: "imagecache.example.com/",
+					ImageReferences: map[string]string{
+						"node": "harbor.example.com/example-project/main/node@sha256:b2001bab5d2d3f0c",
+					},
+				},
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				composeServiceValues: composetypes.ServiceConfig{
+					Build: &composetypes.BuildConfig{
+						Context:    ".",
+						Dockerfile: "node.dockerfile",
+					},
+				},
+			},
+			want: "harbor.example.com/example-project/main/node@sha256:b2001bab5d2d3f0c",
+		},
+		{
+			name: "test2 - built image without a reference",
+			args: args{
+				buildValues: &BuildValues{},
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				composeServiceValues: composetypes.ServiceConfig{
+					Build: &composetypes.BuildConfig{
+						Context:    ".",
+						Dockerfile: "node.dockerfile",
+					},
+				},
+			},
+			want: "",
+		},
+		{
+			name: "test3 - pulled image with image cache",
+			args: args{
+				buildValues: &BuildValues{
+					ImageCache: "imagecache.example.com/",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "opensearch",
+					OverrideName: "opensearch",
+					Type:         "opensearch",
+				},
+				composeServiceValues: composetypes.ServiceConfig{
+					Image: "uselagoon/opensearch-2:latest",
+				},
+			},
+			want: "imagecache.example.com/uselagoon/opensearch-2:latest",
+		},
+		{
+			name: "test4 - lagoon.image label on a docker hub library image",
+			args: args{
+				buildValues: &BuildValues{
+					ImageCache: "imagecache.example.com/",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "redis",
+					OverrideName: "redis",
+					Type:         "redis",
+				},
+				composeServiceValues: composetypes.ServiceConfig{
+					Image: "uselagoon/redis-6:latest",
+					Labels: composetypes.Labels{
+						"lagoon.image": "redis:7",
+					},
+				},
+			},
+			want: "imagecache.example.com/library/redis:7",
+		},
+		{
+			name: "test5 - private registry image",
+			args: args{
+				buildValues: &BuildValues{
+					ImageCache:          "imagecache.example.com/",
+					PrivateRegistryURLS: []string{"https://registry.example.com"},
+				},
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				composeServiceValues: composetypes.ServiceConfig{
+					Image: "registry.example.com/private/node:18",
+				},
+			},
+			want: "registry.example.com/private/node:18",
+		},
+		{
+			name: "test6 - multi-container sibling uses its own reference",
+			args: args{
+				buildValues: &BuildValues{
+					ImageReferences: map[string]string{
+						"nginx": "harbor.example.com/example-project/main/nginx@sha256:6d5a5e5e1f3a3e0f",
+						"php":   "harbor.example.com/example-project/main/php@sha256:1c5b1b1d6e4b6a4f",
+					},
+				},
+				serviceValues: &ServiceValues{
+					Name:                  "php",
+					OverrideName:          "nginx-php",
+					Type:                  "nginx-php-persistent",
+					DeploymentServiceType: "php",
+				},
+				composeServiceValues: composetypes.ServiceConfig{
+					Build: &composetypes.BuildConfig{
+						Context:    ".",
+						Dockerfile: "php.dockerfile",
+					},
+				},
+			},
+			want: "harbor.example.com/example-project/main/php@sha256:1c5b1b1d6e4b6a4f",
+		},
+		{
+			name: "test7 - dbaas service has no image",
+			args: args{
+				buildValues: &BuildValues{
+					ImageCache: "imagecache.example.com/",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "mariadb",
+					OverrideName: "mariadb",
+					Type:         "mariadb-dbaas",
+				},
+				composeServiceValues: composetypes.ServiceConfig{
+					Image: "uselagoon/mariadb-10.5-drupal:latest",
+				},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generateImageValues(tt.args.buildValues, tt.args.serviceValues, tt.args.composeServiceValues)
+			if tt.args.serviceValues.ImageName != tt.want {
+				t.Errorf("generateImageValues() = %v, want %v", tt.args.serviceValues.ImageName, tt.want)
+			}
+		})
+	}
+}
@@ -53,6 +53,12 @@ func generateRoutes(
 		return "", []string{}, []string{}, fmt.Errorf("couldn't generate and merge routes: %v", err)
 	}
 
+	// check that the services used by any path based routing rules exist
+	err = validateRoutePaths(*mainRoutes, buildValues.Services)
+	if err != nil {
+		return "", []string{}, []string{}, err
+	}
+
 	// get the first route from the list of routes, replace the previous one if necessary
 	if len(mainRoutes.Routes) > 0 {
 		// if primary != "" {
@@ -80,6 +86,10 @@ func generateRoutes(
 		if err != nil {
 			return "", []string{}, []string{}, fmt.Errorf("couldn't generate and merge routes: %v", err)
 		}
+		err = validateRoutePaths(*activeStanbyRoutes, buildValues.Services)
+		if err != nil {
+			return "", []string{}, []string{}, err
+		}
 		// get the first route from the list of routes, replace the previous one if necessary
 		if len(activeStanbyRoutes.Routes) > 0 {
 			// if primary != "" {
@@ -100,9 +110,41 @@ func generateRoutes(
 		}
 	}
 
+	// check that no host is claimed by more than one route
+	sources := []routeSource{
+		{name: "autogenerated routes", routes: *autogenRoutes},
+		{name: "environment routes", routes: *mainRoutes},
+	}
+	if buildValues.IsActiveEnvironment || buildValues.IsStandbyEnvironment {
+		sources = append(sources, routeSource{name: productionRoutesName(buildValues), routes: *activeStanbyRoutes})
+		// the production routes of the other environment in the active/standby pair are swapped with these ones
+		// during a switch, so they can't share any hosts with this environment either
+		otherValues := buildValues
+		otherValues.IsActiveEnvironment, otherValues.IsStandbyEnvironment = buildValues.IsStandbyEnvironment, buildValues.IsActiveEnvironment
+		otherRoutes, err := generateActiveStandbyRoutes(lagoonEnvVars, lYAML, otherValues)
+		if err != nil {
+			return "", []string{}, []string{}, fmt.Errorf("couldn't generate and merge routes: %v", err)
+		}
+		if buildValues.IsActiveEnvironment != buildValues.IsStandbyEnvironment {
+			sources = append(sources, routeSource{name: productionRoutesName(otherValues), routes: otherRoutes})
+		}
+	}
+	err = validateRouteConflicts(sources...)
+	if err != nil {
+		return "", []string{}, []string{}, err
+	}
+
 	return primary, remainders, autogen, nil
 }
 
+// productionRoutesName returns the name of the `production_routes` block that the active/standby routes of an environment come from
+func productionRoutesName(buildValues BuildValues) string {
+	if buildValues.IsActiveEnvironment {
+		return "production_routes.active"
+	}
+	return "production_routes.standby"
+}
+
 func generateIngress(
 	envVars []lagoon.EnvironmentVariable,
 	values BuildValues,
@@ -327,3 +369,29 @@ func generateAndMerge(
 	}
 	return mainRoutes, nil
 }
+
+// validateRoutePaths checks that every service referenced by the path based routing rules or the canary of the routes
+// is a service that will be deployed in this environment
+func validateRoutePaths(routes lagoon.RoutesV2, services []ServiceValues) error {
+	for _, route := range routes.Routes {
+		for _, routePath := range route.Paths {
+			if !routeServiceExists(routePath.Service, services) {
+				return fmt.Errorf("Route %s has path %s for service %s, but this service does not exist in the docker-compose.yml", route.Domain, routePath.Path, routePath.Service)
+			}
+		}
+		if route.Canary != nil && !routeServiceExists(route.Canary.Service, services) {
+			return fmt.Errorf("Route %s has a canary for service %s, but this service does not exist in the docker-compose.yml", route.Domain, route.Canary.Service)
+		}
+	}
+	return nil
+}
+
+// routeServiceExists checks if a service referenced by a route will be deployed in this environment
+func routeServiceExists(serviceName string, services []ServiceValues) bool {
+	for _, service := range services {
+		if service.OverrideName != "" && service.OverrideName == serviceName {
+			return true
+		}
+	}
+	return false
+}
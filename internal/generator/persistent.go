@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// generatePersistentVolumeValues populates the access mode and storage class of the persistent volume for a service
+// the `RWX_TO_RWO` feature flag switches `-persistent` service types to use ReadWriteOnce, for clusters without ReadWriteMany storage
+// the `lagoon.persistent.class` label can be used to override the storage class of the persistent volume
+func generatePersistentVolumeValues(
+	serviceValues *ServiceValues,
+	composeServiceLabels map[string]string,
+	lagoonEnvVars []lagoon.EnvironmentVariable,
+	debug bool,
+) error {
+	if storageClass := lagoon.CheckServiceLagoonLabel(composeServiceLabels, "lagoon.persistent.class"); storageClass != "" {
+		if errs := validation.IsDNS1123Subdomain(storageClass); len(errs) != 0 {
+			return fmt.Errorf("the persistent storage class %s for service %s is not valid: %v", storageClass, serviceValues.OverrideName, strings.Join(errs, ", "))
+		}
+		serviceValues.PersistentVolumeStorageClass = storageClass
+	}
+	if strings.HasSuffix(serviceValues.Type, "-persistent") && CheckFeatureFlag("RWX_TO_RWO", lagoonEnvVars, debug) == "enabled" {
+		serviceValues.PersistentVolumeAccessMode = corev1.ReadWriteOnce
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_generatePersistentVolumeValues(t *testing.T) {
+	type args struct {
+		serviceValues        *ServiceValues
+		composeServiceLabels map[string]string
+		lagoonEnvVars        []lagoon.EnvironmentVariable
+	}
+	tests := []struct {
+		name    string
+		args    args
+		vars    []helpers.EnvironmentVariable
+		want    *ServiceValues
+		wantErr bool
+	}{
+		{
+			name: "test1 - no feature flag or label",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "nginx",
+					OverrideName: "nginx",
+					Type:         "nginx-php-persistent",
+				},
+			},
+			want: &ServiceValues{
+				Name:         "nginx",
+				OverrideName: "nginx",
+				Type:         "nginx-php-persistent",
+			},
+		},
+		{
+			name: "test2 - rwx to rwo from lagoon variable",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "nginx",
+					OverrideName: "nginx",
+					Type:         "nginx-php-persistent",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_RWX_TO_RWO", Value: "enabled", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:                       "nginx",
+				OverrideName:               "nginx",
+				Type:                       "nginx-php-persistent",
+				PersistentVolumeAccessMode: corev1.ReadWriteOnce,
+			},
+		},
+		{
+			name: "test3 - rwx to rwo forced, non persistent type",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "mariadb",
+					OverrideName: "mariadb",
+					Type:         "mariadb-single",
+				},
+			},
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_RWX_TO_RWO", Value: "enabled"},
+			},
+			want: &ServiceValues{
+				Name:         "mariadb",
+				OverrideName: "mariadb",
+				Type:         "mariadb-single",
+			},
+		},
+		{
+			name: "test4 - storage class label",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node-persistent",
+				},
+				composeServiceLabels: map[string]string{
+					"lagoon.persistent.class": "fast-storage",
+				},
+			},
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_DEFAULT_RWX_TO_RWO", Value: "enabled"},
+			},
+			want: &ServiceValues{
+				Name:                         "node",
+				OverrideName:                 "node",
+				Type:                         "node-persistent",
+				PersistentVolumeAccessMode:   corev1.ReadWriteOnce,
+				PersistentVolumeStorageClass: "fast-storage",
+			},
+		},
+		{
+			name: "test5 - invalid storage class label",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node-persistent",
+				},
+				composeServiceLabels: map[string]string{
+					"lagoon.persistent.class": "Fast_Storage",
+				},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node-persistent",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, envVar := range tt.vars {
+				err := os.Setenv(envVar.Name, envVar.Value)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(tt.vars)
+			})
+			if err := generatePersistentVolumeValues(tt.args.serviceValues, tt.args.composeServiceLabels, tt.args.lagoonEnvVars, false); (err != nil) != tt.wantErr {
+				t.Errorf("generatePersistentVolumeValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			lValues, _ := json.Marshal(tt.args.serviceValues)
+			wValues, _ := json.Marshal(tt.want)
+			if !reflect.DeepEqual(string(lValues), string(wValues)) {
+				t.Errorf("generatePersistentVolumeValues() = %v, want %v", string(lValues), string(wValues))
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// serviceResource is a resource that can be configured for a service, using either a feature flag
+// or a label on the service in the docker-compose file
+type serviceResource struct {
+	featureFlag  string
+	label        string
+	resourceName corev1.ResourceName
+	limit        bool
+}
+
+var serviceResources = []serviceResource{
+	{
+		featureFlag:  "CONTAINER_MEMORY_LIMIT",
+		label:        "lagoon.resources.memory",
+		resourceName: corev1.ResourceMemory,
+		limit:        true,
+	},
+	{
+		featureFlag:  "EPHEMERAL_STORAGE_REQUESTS",
+		label:        "lagoon.resources.ephemeral-storage.requests",
+		resourceName: corev1.ResourceEphemeralStorage,
+	},
+	{
+		featureFlag:  "EPHEMERAL_STORAGE_LIMIT",
+		label:        "lagoon.resources.ephemeral-storage.limit",
+		resourceName: corev1.ResourceEphemeralStorage,
+		limit:        true,
+	},
+}
+
+// generateResourceValues populates the resources of a service using the resource feature flags
+// a forced feature flag will always be used, otherwise a label on the service will override any lagoon variable or default feature flag
+func generateResourceValues(
+	serviceValues *ServiceValues,
+	composeServiceLabels map[string]string,
+	lagoonEnvVars []lagoon.EnvironmentVariable,
+	debug bool,
+) error {
+	// dbaas services don't run any pods, so there are no resources to set
+	if strings.HasSuffix(serviceValues.Type, "-dbaas") {
+		return nil
+	}
+	for _, sr := range serviceResources {
+		var value string
+		if forced, ok := checkForcedFeatureFlag(sr.featureFlag, debug); ok {
+			value = forced
+		} else if label := lagoon.CheckServiceLagoonLabel(composeServiceLabels, sr.label); label != "" {
+			value = label
+		} else {
+			value = CheckFeatureFlag(sr.featureFlag, lagoonEnvVars, debug)
+		}
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("the %s resource value %s for service %s is not a valid quantity: %v", sr.resourceName, value, serviceValues.OverrideName, err)
+		}
+		// negative quantities parse, but are rejected by kubernetes when the deployment is applied
+		if quantity.Sign() < 0 {
+			return fmt.Errorf("the %s resource value %s for service %s from the %s flag or %s label can't be negative", sr.resourceName, value, serviceValues.OverrideName, sr.featureFlag, sr.label)
+		}
+		if sr.limit {
+			if serviceValues.Resources.Limits == nil {
+				serviceValues.Resources.Limits = corev1.ResourceList{}
+			}
+			serviceValues.Resources.Limits[sr.resourceName] = quantity
+		} else {
+			if serviceValues.Resources.Requests == nil {
+				serviceValues.Resources.Requests = corev1.ResourceList{}
+			}
+			serviceValues.Resources.Requests[sr.resourceName] = quantity
+		}
+	}
+	// kubernetes rejects a deployment that requests more of a resource than its limit
+	for resourceName, request := range serviceValues.Resources.Requests {
+		if limit, ok := serviceValues.Resources.Limits[resourceName]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("the %s resource request %s for service %s is greater than the limit %s", resourceName, request.String(), serviceValues.OverrideName, limit.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_generateResourceValues(t *testing.T) {
+	type args struct {
+		serviceValues        *ServiceValues
+		composeServiceLabels map[string]string
+		lagoonEnvVars        []lagoon.EnvironmentVariable
+	}
+	tests := []struct {
+		name    string
+		args    args
+		vars    []helpers.EnvironmentVariable
+		want    *ServiceValues
+		wantErr bool
+	}{
+		{
+			name: "test1 - resources from lagoon variables",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT", Value: "8Gi", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_EPHEMERAL_STORAGE_REQUESTS", Value: "1Gi", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_EPHEMERAL_STORAGE_LIMIT", Value: "16Gi", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory:           resource.MustParse("8Gi"),
+						corev1.ResourceEphemeralStorage: resource.MustParse("16Gi"),
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+					},
+				},
+			},
+		},
+		{
+			name: "test2 - default feature flags",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+			},
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_DEFAULT_CONTAINER_MEMORY_LIMIT", Value: "4Gi"},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+		},
+		{
+			name: "test3 - service label overrides lagoon variable and default",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				composeServiceLabels: map[string]string{
+					"lagoon.type":             "node",
+					"lagoon.resources.memory": "2Gi",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT", Value: "8Gi", Scope: "build"},
+				},
+			},
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_DEFAULT_EPHEMERAL_STORAGE_LIMIT", Value: "16Gi"},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory:           resource.MustParse("2Gi"),
+						corev1.ResourceEphemeralStorage: resource.MustParse("16Gi"),
+					},
+				},
+			},
+		},
+		{
+			name: "test4 - forced feature flag overrides service label",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				composeServiceLabels: map[string]string{
+					"lagoon.type":             "node",
+					"lagoon.resources.memory": "32Gi",
+				},
+			},
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_CONTAINER_MEMORY_LIMIT", Value: "16Gi"},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("16Gi"),
+					},
+				},
+			},
+		},
+		{
+			name: "test5 - invalid quantity",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				composeServiceLabels: map[string]string{
+					"lagoon.type": "node",
+					"lagoon.resources.ephemeral-storage.limit": "16 gigabytes",
+				},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+			},
+			wantErr: true,
+		},
+		{
+			name: "test6 - negative quantity",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT", Value: "-500Mi", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+			},
+			wantErr: true,
+		},
+		{
+			name: "test7 - dbaas services have no resources",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "mariadb",
+					OverrideName: "mariadb",
+					Type:         "mariadb-dbaas",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT", Value: "8Gi", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "mariadb",
+				OverrideName: "mariadb",
+				Type:         "mariadb-dbaas",
+			},
+		},
+		{
+			name: "test8 - request greater than the limit",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_EPHEMERAL_STORAGE_REQUESTS", Value: "10Gi", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_EPHEMERAL_STORAGE_LIMIT", Value: "1Gi", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("10Gi"),
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test9 - request equal to the limit",
+			args: args{
+				serviceValues: &ServiceValues{
+					Name:         "node",
+					OverrideName: "node",
+					Type:         "node",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_EPHEMERAL_STORAGE_REQUESTS", Value: "1024Mi", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_EPHEMERAL_STORAGE_LIMIT", Value: "1Gi", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "node",
+				OverrideName: "node",
+				Type:         "node",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("1024Mi"),
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, envVar := range tt.vars {
+				err := os.Setenv(envVar.Name, envVar.Value)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(tt.vars)
+			})
+			if err := generateResourceValues(tt.args.serviceValues, tt.args.composeServiceLabels, tt.args.lagoonEnvVars, false); (err != nil) != tt.wantErr {
+				t.Errorf("generateResourceValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			lValues, _ := json.Marshal(tt.args.serviceValues)
+			wValues, _ := json.Marshal(tt.want)
+			if !reflect.DeepEqual(string(lValues), string(wValues)) {
+				t.Errorf("generateResourceValues() = %v, want %v", string(lValues), string(wValues))
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// generateRouteAccessValues applies the ip allowlist, basic auth and request limit defaults for the environment type from the `.lagoon.yml`
+// to any routes that don't define their own, then collects the basic auth secrets that the routes use.
+// a route can opt out of a default with an empty `ipAllowlist` or `rateLimit`, a `basicAuth` with no variable, or a `maxBodySize`
+// of "0" which removes the limit
+func generateRouteAccessValues(
+	buildValues *BuildValues,
+	lYAML lagoon.YAML,
+	lagoonEnvVars []lagoon.EnvironmentVariable,
+	routes ...*lagoon.RoutesV2,
+) error {
+	buildValues.BasicAuthSecrets = map[string]BasicAuthSecret{}
+	defaults := lYAML.Routes.Defaults[buildValues.EnvironmentType]
+	if err := lagoon.ValidateIPAllowlist(fmt.Sprintf("defaults for %s environments", buildValues.EnvironmentType), defaults.IPAllowlist); err != nil {
+		return err
+	}
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		for idx := range r.Routes {
+			route := &r.Routes[idx]
+			if route.IPAllowlist == nil && defaults.IPAllowlist != nil {
+				route.IPAllowlist = defaults.IPAllowlist
+			}
+			if route.BasicAuth == nil && defaults.BasicAuth != nil {
+				basicAuth := *defaults.BasicAuth
+				route.BasicAuth = &basicAuth
+			}
+			if route.RateLimit == nil && defaults.RateLimit != nil {
+				rateLimit := *defaults.RateLimit
+				route.RateLimit = &rateLimit
+			}
+			if route.RateLimit != nil && *route.RateLimit == (lagoon.RouteRateLimit{}) {
+				// the route has opted out of rate limiting
+				route.RateLimit = nil
+			}
+			// an empty maxBodySize inherits the default, a route opts out with "0" as nginx doesn't limit the body size then
+			if route.MaxBodySize == "" {
+				route.MaxBodySize = defaults.MaxBodySize
+			}
+			if route.BasicAuth == nil {
+				continue
+			}
+			if route.BasicAuth.Variable == "" {
+				// the route has opted out of basic auth
+				route.BasicAuth = nil
+				continue
+			}
+			secret, err := generateBasicAuthSecret(route.Domain, route.BasicAuth.Variable, lagoonEnvVars)
+			if err != nil {
+				return err
+			}
+			route.BasicAuth.SecretName = secret.Name
+			buildValues.BasicAuthSecrets[secret.Name] = secret
+		}
+	}
+	return nil
+}
+
+// generateBasicAuthSecret reads the htpasswd content for a basic auth secret from the lagoon variable
+// routes that use the same variable share the same secret
+func generateBasicAuthSecret(domain, variable string, lagoonEnvVars []lagoon.EnvironmentVariable) (BasicAuthSecret, error) {
+	secretName := fmt.Sprintf("basic-auth-%s", strings.ReplaceAll(strings.ToLower(variable), "_", "-"))
+	if errs := validation.IsDNS1123Subdomain(secretName); len(errs) != 0 {
+		return BasicAuthSecret{}, fmt.Errorf("the route %s uses basic auth variable %s, which can't be used as the name of a secret: %v", domain, variable, strings.Join(errs, ", "))
+	}
+	htpasswd, err := lagoon.GetLagoonVariable(variable, nil, lagoonEnvVars)
+	if err != nil {
+		return BasicAuthSecret{}, fmt.Errorf("the route %s uses basic auth variable %s, but this variable is not defined", domain, variable)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(htpasswd.Value), "\n") {
+		if !strings.Contains(line, ":") {
+			return BasicAuthSecret{}, fmt.Errorf("the route %s uses basic auth variable %s, but it doesn't contain valid htpasswd content", domain, variable)
+		}
+	}
+	return BasicAuthSecret{
+		Name:     secretName,
+		Variable: variable,
+		Auth:     htpasswd.Value,
+	}, nil
+}
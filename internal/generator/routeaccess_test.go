@@ -0,0 +1,250 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_generateRouteAccessValues(t *testing.T) {
+	type args struct {
+		buildValues   *BuildValues
+		lYAML         lagoon.YAML
+		lagoonEnvVars []lagoon.EnvironmentVariable
+		routes        *lagoon.RoutesV2
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantRoutes  *lagoon.RoutesV2
+		wantSecrets map[string]BasicAuthSecret
+		wantErr     bool
+	}{
+		{
+			name: "test1 - development defaults applied to routes without their own",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "development",
+				},
+				lYAML: lagoon.YAML{
+					Routes: lagoon.Routes{
+						Defaults: map[string]lagoon.RouteDefaults{
+							"development": {
+								IPAllowlist: []string{"10.0.0.0/8"},
+								BasicAuth: &lagoon.RouteBasicAuth{
+									Variable: "STAGING_HTPASSWD",
+								},
+							},
+						},
+					},
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "STAGING_HTPASSWD", Value: "staging:$apr1$lP3S9Uf1$bR4E1yF2tG3hZ4x5C6v7B8", Scope: "runtime"},
+				},
+				routes: &lagoon.RoutesV2{
+					Routes: []lagoon.RouteV2{
+						{
+							Domain:   "develop.example.com",
+							Insecure: helpers.StrPtr("Redirect"),
+						},
+						{
+							Domain:      "public.develop.example.com",
+							Insecure:    helpers.StrPtr("Redirect"),
+							IPAllowlist: []string{},
+							BasicAuth:   &lagoon.RouteBasicAuth{},
+						},
+					},
+				},
+			},
+			wantRoutes: &lagoon.RoutesV2{
+				Routes: []lagoon.RouteV2{
+					{
+						Domain:      "develop.example.com",
+						Insecure:    helpers.StrPtr("Redirect"),
+						IPAllowlist: []string{"10.0.0.0/8"},
+						BasicAuth: &lagoon.RouteBasicAuth{
+							Variable:   "STAGING_HTPASSWD",
+							SecretName: "basic-auth-staging-htpasswd",
+						},
+					},
+					{
+						Domain:      "public.develop.example.com",
+						Insecure:    helpers.StrPtr("Redirect"),
+						IPAllowlist: []string{},
+					},
+				},
+			},
+			wantSecrets: map[string]BasicAuthSecret{
+				"basic-auth-staging-htpasswd": {
+					Name:     "basic-auth-staging-htpasswd",
+					Variable: "STAGING_HTPASSWD",
+					Auth:     "staging:$apr1$lP3S9Uf1$bR4E1yF2tG3hZ4x5C6v7B8",
+				},
+			},
+		},
+		{
+			name: "test2 - defaults are not applied to other environment types",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "production",
+				},
+				lYAML: lagoon.YAML{
+					Routes: lagoon.Routes{
+						Defaults: map[string]lagoon.RouteDefaults{
+							"development": {
+								IPAllowlist: []string{"10.0.0.0/8"},
+							},
+						},
+					},
+				},
+				routes: &lagoon.RoutesV2{
+					Routes: []lagoon.RouteV2{
+						{
+							Domain:   "example.com",
+							Insecure: helpers.StrPtr("Redirect"),
+						},
+					},
+				},
+			},
+			wantRoutes: &lagoon.RoutesV2{
+				Routes: []lagoon.RouteV2{
+					{
+						Domain:   "example.com",
+						Insecure: helpers.StrPtr("Redirect"),
+					},
+				},
+			},
+			wantSecrets: map[string]BasicAuthSecret{},
+		},
+		{
+			name: "test3 - invalid htpasswd content",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "production",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "EXAMPLE_HTPASSWD", Value: "not-htpasswd", Scope: "build"},
+				},
+				routes: &lagoon.RoutesV2{
+					Routes: []lagoon.RouteV2{
+						{
+							Domain:   "example.com",
+							Insecure: helpers.StrPtr("Redirect"),
+							BasicAuth: &lagoon.RouteBasicAuth{
+								Variable: "EXAMPLE_HTPASSWD",
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test4 - invalid default ip allowlist",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "development",
+				},
+				lYAML: lagoon.YAML{
+					Routes: lagoon.Routes{
+						Defaults: map[string]lagoon.RouteDefaults{
+							"development": {
+								IPAllowlist: []string{"10.0.0.0/33"},
+							},
+						},
+					},
+				},
+				routes: &lagoon.RoutesV2{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test5 - request limit defaults",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "production",
+				},
+				lYAML: lagoon.YAML{
+					Routes: lagoon.Routes{
+						Defaults: map[string]lagoon.RouteDefaults{
+							"production": {
+								RateLimit: &lagoon.RouteRateLimit{
+									RPS: 20,
+								},
+								MaxBodySize: "10m",
+							},
+						},
+					},
+				},
+				routes: &lagoon.RoutesV2{
+					Routes: []lagoon.RouteV2{
+						{
+							Domain:      "example.com",
+							Insecure:    helpers.StrPtr("Redirect"),
+							MaxBodySize: "100m",
+						},
+						{
+							Domain:    "api.example.com",
+							Insecure:  helpers.StrPtr("Redirect"),
+							RateLimit: &lagoon.RouteRateLimit{},
+						},
+						{
+							Domain:      "upload.example.com",
+							Insecure:    helpers.StrPtr("Redirect"),
+							MaxBodySize: "0",
+						},
+					},
+				},
+			},
+			wantRoutes: &lagoon.RoutesV2{
+				Routes: []lagoon.RouteV2{
+					{
+						Domain:   "example.com",
+						Insecure: helpers.StrPtr("Redirect"),
+						RateLimit: &lagoon.RouteRateLimit{
+							RPS: 20,
+						},
+						MaxBodySize: "100m",
+					},
+					{
+						Domain:      "api.example.com",
+						Insecure:    helpers.StrPtr("Redirect"),
+						MaxBodySize: "10m",
+					},
+					{
+						Domain:   "upload.example.com",
+						Insecure: helpers.StrPtr("Redirect"),
+						RateLimit: &lagoon.RouteRateLimit{
+							RPS: 20,
+						},
+						MaxBodySize: "0",
+					},
+				},
+			},
+			wantSecrets: map[string]BasicAuthSecret{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generateRouteAccessValues(tt.args.buildValues, tt.args.lYAML, tt.args.lagoonEnvVars, tt.args.routes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("generateRouteAccessValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(tt.args.routes, tt.wantRoutes) {
+				lValues, _ := json.Marshal(tt.args.routes)
+				wValues, _ := json.Marshal(tt.wantRoutes)
+				t.Errorf("generateRouteAccessValues() routes = %v, want %v", string(lValues), string(wValues))
+			}
+			if !reflect.DeepEqual(tt.args.buildValues.BasicAuthSecrets, tt.wantSecrets) {
+				t.Errorf("generateRouteAccessValues() secrets = %v, want %v", tt.args.buildValues.BasicAuthSecrets, tt.wantSecrets)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// RouteConflict is a host that more than one route in an environment claims
+type RouteConflict struct {
+	Host   string   `json:"host"`
+	Reason string   `json:"reason"`
+	Routes []string `json:"routes"`
+}
+
+func (c RouteConflict) String() string {
+	return fmt.Sprintf("%s %s: %s", c.Host, c.Reason, strings.Join(c.Routes, ", "))
+}
+
+// RouteConflictError is returned when the routes of an environment have conflicts, it contains all of the conflicts that were found
+type RouteConflictError struct {
+	Conflicts []RouteConflict
+}
+
+func (e *RouteConflictError) Error() string {
+	conflicts := []string{}
+	for _, conflict := range e.Conflicts {
+		conflicts = append(conflicts, conflict.String())
+	}
+	return fmt.Sprintf("found %d route conflicts: %s", len(e.Conflicts), strings.Join(conflicts, "; "))
+}
+
+// routeSource is a set of routes, and the name of where they are defined, eg `environment routes` or `production_routes.active`
+type routeSource struct {
+	name   string
+	routes lagoon.RoutesV2
+}
+
+// routeHost is a single host that a route claims, either its domain or one of its alternative names
+type routeHost struct {
+	host          string
+	wildcard      bool
+	alternative   bool
+	autogenerated bool
+	owner         string
+}
+
+// findRouteConflicts checks all of the hosts of the routes in the sources and returns any conflicts
+// a conflict is a host that is defined by more than one route, an alternative name that is also used by another route (or the same route),
+// or a host that is covered by a wildcard route from a different route
+// autogenerated routes are allowed to be covered by a wildcard route, as the router pattern is often a subdomain of a custom wildcard domain
+func findRouteConflicts(sources ...routeSource) []RouteConflict {
+	hosts := []routeHost{}
+	for _, source := range sources {
+		for _, route := range source.routes.Routes {
+			wildcard := route.Wildcard != nil && *route.Wildcard
+			host := strings.ToLower(route.Domain)
+			if wildcard {
+				host = fmt.Sprintf("*.%s", host)
+			}
+			hosts = append(hosts, routeHost{
+				host:          host,
+				wildcard:      wildcard,
+				autogenerated: route.Autogenerated,
+				owner:         fmt.Sprintf("route %s in %s", route.Domain, source.name),
+			})
+			for _, alternativeName := range route.AlternativeNames {
+				hosts = append(hosts, routeHost{
+					host:          strings.ToLower(alternativeName),
+					alternative:   true,
+					autogenerated: route.Autogenerated,
+					owner:         fmt.Sprintf("alternative name of route %s in %s", route.Domain, source.name),
+				})
+			}
+		}
+	}
+
+	conflicts := []RouteConflict{}
+	// check for hosts that are claimed more than once
+	claimed := map[string][]routeHost{}
+	for _, host := range hosts {
+		claimed[host.host] = append(claimed[host.host], host)
+	}
+	for host, claims := range claimed {
+		if len(claims) < 2 {
+			continue
+		}
+		reason := "is defined by more than one route"
+		owners := []string{}
+		for _, claim := range claims {
+			if claim.alternative {
+				reason = "is an alternative name that collides with another route"
+			}
+			owners = append(owners, claim.owner)
+		}
+		sort.Strings(owners)
+		conflicts = append(conflicts, RouteConflict{
+			Host:   host,
+			Reason: reason,
+			Routes: owners,
+		})
+	}
+	// check for hosts that a wildcard route also matches, a wildcard only matches a single label
+	for _, wildcard := range hosts {
+		if !wildcard.wildcard {
+			continue
+		}
+		suffix := strings.TrimPrefix(wildcard.host, "*")
+		for _, host := range hosts {
+			if host.wildcard || host.autogenerated || !strings.HasSuffix(host.host, suffix) {
+				continue
+			}
+			if strings.Contains(strings.TrimSuffix(host.host, suffix), ".") {
+				continue
+			}
+			conflicts = append(conflicts, RouteConflict{
+				Host:   host.host,
+				Reason: fmt.Sprintf("overlaps with wildcard %s", wildcard.host),
+				Routes: []string{host.owner, wildcard.owner},
+			})
+		}
+	}
+	// sort the conflicts so that they are always reported in the same order
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].Host == conflicts[j].Host {
+			return conflicts[i].Reason < conflicts[j].Reason
+		}
+		return conflicts[i].Host < conflicts[j].Host
+	})
+	return conflicts
+}
+
+// validateRouteConflicts returns a RouteConflictError containing all of the conflicts between the routes in the sources
+func validateRouteConflicts(sources ...routeSource) error {
+	conflicts := findRouteConflicts(sources...)
+	if len(conflicts) > 0 {
+		return &RouteConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
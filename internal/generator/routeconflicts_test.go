@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_findRouteConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []routeSource
+		want    []RouteConflict
+	}{
+		{
+			name: "test1 - no conflicts",
+			sources: []routeSource{
+				{
+					name: "environment routes",
+					routes: lagoon.RoutesV2{
+						Routes: []lagoon.RouteV2{
+							{Domain: "example.com", AlternativeNames: []string{"www.example.com"}},
+							{Domain: "example.net", Wildcard: helpers.BoolPtr(true)},
+							{Domain: "www.api.example.net"},
+						},
+					},
+				},
+			},
+			want: []RouteConflict{},
+		},
+		{
+			name: "test2 - alternative name of the same route",
+			sources: []routeSource{
+				{
+					name: "environment routes",
+					routes: lagoon.RoutesV2{
+						Routes: []lagoon.RouteV2{
+							{Domain: "example.com", AlternativeNames: []string{"Example.com"}},
+						},
+					},
+				},
+			},
+			want: []RouteConflict{
+				{
+					Host:   "example.com",
+					Reason: "is an alternative name that collides with another route",
+					Routes: []string{
+						"alternative name of route example.com in environment routes",
+						"route example.com in environment routes",
+					},
+				},
+			},
+		},
+		{
+			name: "test3 - duplicate wildcard routes across sources",
+			sources: []routeSource{
+				{
+					name: "environment routes",
+					routes: lagoon.RoutesV2{
+						Routes: []lagoon.RouteV2{
+							{Domain: "example.com", Wildcard: helpers.BoolPtr(true)},
+						},
+					},
+				},
+				{
+					name: "production_routes.active",
+					routes: lagoon.RoutesV2{
+						Routes: []lagoon.RouteV2{
+							{Domain: "example.com", Wildcard: helpers.BoolPtr(true)},
+						},
+					},
+				},
+			},
+			want: []RouteConflict{
+				{
+					Host:   "*.example.com",
+					Reason: "is defined by more than one route",
+					Routes: []string{
+						"route example.com in environment routes",
+						"route example.com in production_routes.active",
+					},
+				},
+			},
+		},
+		{
+			name: "test4 - wildcard overlaps an alternative name but not an autogenerated route",
+			sources: []routeSource{
+				{
+					name: "autogenerated routes",
+					routes: lagoon.RoutesV2{
+						Routes: []lagoon.RouteV2{
+							{Domain: "node-example-project-main.example.com", Autogenerated: true},
+						},
+					},
+				},
+				{
+					name: "environment routes",
+					routes: lagoon.RoutesV2{
+						Routes: []lagoon.RouteV2{
+							{Domain: "example.com", Wildcard: helpers.BoolPtr(true)},
+							{Domain: "example.net", AlternativeNames: []string{"www.example.com"}},
+						},
+					},
+				},
+			},
+			want: []RouteConflict{
+				{
+					Host:   "www.example.com",
+					Reason: "overlaps with wildcard *.example.com",
+					Routes: []string{
+						"alternative name of route example.net in environment routes",
+						"route example.com in environment routes",
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findRouteConflicts(tt.sources...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findRouteConflicts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -57,6 +57,18 @@ var typesWithBackups = []string{
 
 // just some default values for services
 var defaultServiceValues = map[string]map[string]string{
+	"basic-persistent": map[string]string{
+		"persistentSize": "5Gi",
+	},
+	"node-persistent": map[string]string{
+		"persistentSize": "5Gi",
+	},
+	"nginx-php-persistent": map[string]string{
+		"persistentSize": "5Gi",
+	},
+	"python-persistent": map[string]string{
+		"persistentSize": "5Gi",
+	},
 	"elasticsearch": map[string]string{
 		"persistentPath": "/usr/share/elasticsearch/data",
 		"persistentSize": "5Gi",
@@ -89,6 +101,10 @@ var defaultServiceValues = map[string]map[string]string{
 		"persistentPath": "/data",
 		"persistentSize": "5Gi",
 	},
+	"solr": map[string]string{
+		"persistentPath": "/var/solr",
+		"persistentSize": "5Gi",
+	},
 }
 
 // generateServicesFromDockerCompose unmarshals the docker-compose file and processes the services using composeToServiceValues
@@ -125,6 +141,24 @@ func generateServicesFromDockerCompose(
 				if cService.BackupsEnabled {
 					buildValues.BackupsEnabled = true
 				}
+				// services with a type of none are still added as empty services, there are no cronjobs, spot or resource configurations to calculate for these
+				if cService.OverrideName != "" {
+					generateSpotValues(buildValues, &cService, lagoonEnvVars, debug)
+					generateDynamicSecretValues(buildValues, &cService)
+					generateImageValues(buildValues, &cService, composeServiceValues)
+					err = generateResourceValues(&cService, composeServiceValues.Labels, lagoonEnvVars, debug)
+					if err != nil {
+						return err
+					}
+					err = generatePersistentVolumeValues(&cService, composeServiceValues.Labels, lagoonEnvVars, debug)
+					if err != nil {
+						return err
+					}
+					err = generateCronjobValues(buildValues, &cService, lYAML, lagoonEnvVars)
+					if err != nil {
+						return err
+					}
+				}
 				buildValues.Services = append(buildValues.Services, cService)
 			}
 		}
@@ -238,8 +272,9 @@ func composeToServiceValues(
 		}
 		servicePersistentName := lagoon.CheckServiceLagoonLabel(composeServiceValues.Labels, "lagoon.persistent.name")
 		if servicePersistentName == "" && servicePersistentPath != "" {
-			// if there is a persistent path defined, then set the persistent name to be the compose service if no persistent name is provided
-			servicePersistentName = composeService
+			// if there is a persistent path defined, then set the persistent name to be the lagoon service name if no persistent name is provided
+			// this is the same name that the service will be deployed as, so services that share a `lagoon.name` share the volume
+			servicePersistentName = lagoonOverrideName
 		}
 		servicePersistentSize := lagoon.CheckServiceLagoonLabel(composeServiceValues.Labels, "lagoon.persistent.size")
 		if servicePersistentSize == "" {
@@ -349,6 +384,7 @@ func composeToServiceValues(
 			AutogeneratedRoutesEnabled: autogenEnabled,
 			AutogeneratedRoutesTLSAcme: autogenTLSAcmeEnabled,
 			DBaaSEnvironment:           dbaasEnvironment,
+			DeploymentServiceType:      serviceDeploymentServiceType,
 			PersistentVolumePath:       servicePersistentPath,
 			PersistentVolumeName:       servicePersistentName,
 			PersistentVolumeSize:       servicePersistentSize,
@@ -52,6 +52,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "nginx",
 				AutogeneratedRoutesEnabled: true,
 				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "nginx",
 			},
 		},
 		{
@@ -82,6 +83,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "nginx",
 				AutogeneratedRoutesEnabled: true,
 				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "nginx",
 			},
 		},
 		{
@@ -115,6 +117,8 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "nginx-php-persistent",
 				AutogeneratedRoutesEnabled: true,
 				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "nginx",
+				PersistentVolumeSize:       "5Gi",
 				BackupsEnabled:             true,
 			},
 		},
@@ -148,6 +152,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "nginx-php-persistent",
 				AutogeneratedRoutesEnabled: true,
 				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "nginx",
 				BackupsEnabled:             true,
 			},
 		},
@@ -180,6 +185,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "nginx",
 				AutogeneratedRoutesEnabled: false,
 				AutogeneratedRoutesTLSAcme: false,
+				DeploymentServiceType:      "nginx",
 			},
 		},
 		{
@@ -216,6 +222,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "nginx-php",
 				AutogeneratedRoutesEnabled: true,
 				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "nginx",
 			},
 		},
 		{
@@ -253,6 +260,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "nginx-php",
 				AutogeneratedRoutesEnabled: false,
 				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "nginx",
 			},
 		},
 		{
@@ -349,6 +357,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "mariadb-dbaas",
 				AutogeneratedRoutesEnabled: false,
 				AutogeneratedRoutesTLSAcme: false,
+				DeploymentServiceType:      "mariadb",
 				DBaaSEnvironment:           "development",
 				BackupsEnabled:             true,
 			},
@@ -393,6 +402,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "mariadb-single",
 				AutogeneratedRoutesEnabled: false,
 				AutogeneratedRoutesTLSAcme: false,
+				DeploymentServiceType:      "mariadb",
 				DBaaSEnvironment:           "development2",
 				BackupsEnabled:             true,
 			},
@@ -432,6 +442,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "postgres-dbaas",
 				AutogeneratedRoutesEnabled: false,
 				AutogeneratedRoutesTLSAcme: false,
+				DeploymentServiceType:      "postgres",
 				DBaaSEnvironment:           "development",
 				BackupsEnabled:             true,
 			},
@@ -463,6 +474,7 @@ func Test_composeToServiceValues(t *testing.T) {
 				Type:                       "python",
 				AutogeneratedRoutesEnabled: true,
 				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "python-ckan",
 			},
 		},
 		{
@@ -490,6 +502,43 @@ func Test_composeToServiceValues(t *testing.T) {
 			want:    ServiceValues{},
 			wantErr: true,
 		},
+		{
+			name: "test15 - deployment servicetype and persistent name",
+			args: args{
+				lYAML: &lagoon.YAML{
+					Environments: lagoon.Environments{
+						"main": lagoon.Environment{},
+					},
+				},
+				buildValues: &BuildValues{
+					Environment:          "main",
+					Branch:               "main",
+					BuildType:            "branch",
+					ServiceTypeOverrides: &lagoon.EnvironmentVariable{},
+				},
+				composeService: "php-fpm",
+				composeServiceValues: composetypes.ServiceConfig{
+					Labels: composetypes.Labels{
+						"lagoon.type":                   "nginx-php-persistent",
+						"lagoon.name":                   "nginx",
+						"lagoon.persistent":             "/app/web/sites/default/files/",
+						"lagoon.deployment.servicetype": "php",
+					},
+				},
+			},
+			want: ServiceValues{
+				Name:                       "php-fpm",
+				OverrideName:               "nginx",
+				Type:                       "nginx-php-persistent",
+				AutogeneratedRoutesEnabled: true,
+				AutogeneratedRoutesTLSAcme: true,
+				DeploymentServiceType:      "php",
+				PersistentVolumePath:       "/app/web/sites/default/files/",
+				PersistentVolumeName:       "nginx",
+				PersistentVolumeSize:       "5Gi",
+				BackupsEnabled:             true,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// spotReplicaTypes are the service types that can properly support multiple replicas under spot
+var spotReplicaTypes = []string{"nginx", "nginx-persistent", "nginx-php", "nginx-php-persistent"}
+
+// generateSpotValues populates the spot instance configuration of a service using the `SPOT_INSTANCE_X` feature flags
+func generateSpotValues(
+	buildValues *BuildValues,
+	serviceValues *ServiceValues,
+	lagoonEnvVars []lagoon.EnvironmentVariable,
+	debug bool,
+) {
+	spotTypes := ""
+	spotCronjobTypes := ""
+	switch buildValues.EnvironmentType {
+	case "production":
+		if CheckFeatureFlag("SPOT_INSTANCE_PRODUCTION", lagoonEnvVars, debug) != "enabled" {
+			return
+		}
+		// production environments can support different spot instance types than development environments
+		spotTypes = CheckFeatureFlag("SPOT_INSTANCE_PRODUCTION_TYPES", lagoonEnvVars, debug)
+		spotCronjobTypes = CheckFeatureFlag("SPOT_INSTANCE_PRODUCTION_CRONJOB_TYPES", lagoonEnvVars, debug)
+	case "development":
+		if CheckFeatureFlag("SPOT_INSTANCE_DEVELOPMENT", lagoonEnvVars, debug) != "enabled" {
+			return
+		}
+		spotTypes = CheckFeatureFlag("SPOT_INSTANCE_DEVELOPMENT_TYPES", lagoonEnvVars, debug)
+		spotCronjobTypes = CheckFeatureFlag("SPOT_INSTANCE_DEVELOPMENT_CRONJOB_TYPES", lagoonEnvVars, debug)
+	default:
+		return
+	}
+
+	// set deployment spot configurations
+	if useSpot, force := checkSpotType(spotTypes, serviceValues.Type); useSpot {
+		serviceValues.UseSpotInstances = true
+		// spot on production gets 2 replicas if the service type is in the supported spot replica types
+		if buildValues.EnvironmentType == "production" {
+			for _, replicaType := range spotReplicaTypes {
+				if replicaType == serviceValues.Type {
+					serviceValues.Replicas = 2
+				}
+			}
+		}
+		serviceValues.Tolerations = spotTolerations()
+		serviceValues.Affinity = spotAffinity()
+		if force {
+			serviceValues.NodeSelectors = &map[string]string{"lagoon.sh/spot": "true"}
+		}
+	}
+
+	// set cronjob spot configurations
+	if useSpot, force := checkSpotType(spotCronjobTypes, serviceValues.Type); useSpot {
+		serviceValues.CronjobUseSpotInstances = true
+		serviceValues.CronjobTolerations = spotTolerations()
+		serviceValues.CronjobAffinity = spotAffinity()
+		if force {
+			serviceValues.CronjobNodeSelectors = &map[string]string{"lagoon.sh/spot": "true"}
+		}
+	}
+}
+
+// checkSpotType checks if the service type is in the comma separated list of spot types
+// a type can be suffixed with `:force` to force the service onto spot instances using a node selector
+func checkSpotType(spotTypes, serviceType string) (bool, bool) {
+	for _, spotType := range strings.Split(spotTypes, ",") {
+		spotTypeSplit := strings.SplitN(strings.TrimSpace(spotType), ":", 2)
+		if spotTypeSplit[0] == serviceType {
+			return true, len(spotTypeSplit) == 2 && spotTypeSplit[1] == "force"
+		}
+	}
+	return false, false
+}
+
+func spotTolerations() *[]corev1.Toleration {
+	return &[]corev1.Toleration{
+		{
+			Key:      "lagoon.sh/spot",
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+		{
+			Key:      "lagoon.sh/spot",
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectPreferNoSchedule,
+		},
+	}
+}
+
+func spotAffinity() *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+				{
+					Weight: 1,
+					Preference: corev1.NodeSelectorTerm{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      "lagoon.sh/spot",
+								Operator: corev1.NodeSelectorOpExists,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_generateSpotValues(t *testing.T) {
+	type args struct {
+		buildValues   *BuildValues
+		serviceValues *ServiceValues
+		lagoonEnvVars []lagoon.EnvironmentVariable
+	}
+	tests := []struct {
+		name string
+		args args
+		want *ServiceValues
+	}{
+		{
+			name: "test1 - production spot with replicas",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "production",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "nginx",
+					OverrideName: "nginx",
+					Type:         "nginx-php-persistent",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION", Value: "enabled", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION_TYPES", Value: "nginx-php-persistent,cli-persistent", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:             "nginx",
+				OverrideName:     "nginx",
+				Type:             "nginx-php-persistent",
+				UseSpotInstances: true,
+				Replicas:         2,
+				Tolerations:      spotTolerations(),
+				Affinity:         spotAffinity(),
+			},
+		},
+		{
+			name: "test2 - production spot forced with cronjobs",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "production",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "cli",
+					OverrideName: "cli",
+					Type:         "cli-persistent",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION", Value: "enabled", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION_TYPES", Value: "nginx-php-persistent,cli-persistent:force", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION_CRONJOB_TYPES", Value: "cli-persistent", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:                    "cli",
+				OverrideName:            "cli",
+				Type:                    "cli-persistent",
+				UseSpotInstances:        true,
+				NodeSelectors:           &map[string]string{"lagoon.sh/spot": "true"},
+				Tolerations:             spotTolerations(),
+				Affinity:                spotAffinity(),
+				CronjobUseSpotInstances: true,
+				CronjobTolerations:      spotTolerations(),
+				CronjobAffinity:         spotAffinity(),
+			},
+		},
+		{
+			name: "test3 - development spot cronjobs forced, no replicas",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "development",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "nginx",
+					OverrideName: "nginx",
+					Type:         "nginx-php-persistent",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_DEVELOPMENT", Value: "enabled", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_DEVELOPMENT_TYPES", Value: "nginx-php-persistent", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_DEVELOPMENT_CRONJOB_TYPES", Value: "nginx-php-persistent:force", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:                    "nginx",
+				OverrideName:            "nginx",
+				Type:                    "nginx-php-persistent",
+				UseSpotInstances:        true,
+				Tolerations:             spotTolerations(),
+				Affinity:                spotAffinity(),
+				CronjobUseSpotInstances: true,
+				CronjobNodeSelectors:    &map[string]string{"lagoon.sh/spot": "true"},
+				CronjobTolerations:      spotTolerations(),
+				CronjobAffinity:         spotAffinity(),
+			},
+		},
+		{
+			name: "test4 - spot only enabled for development",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "production",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "nginx",
+					OverrideName: "nginx",
+					Type:         "nginx-php-persistent",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_DEVELOPMENT", Value: "enabled", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_DEVELOPMENT_TYPES", Value: "nginx-php-persistent", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION_TYPES", Value: "nginx-php-persistent", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "nginx",
+				OverrideName: "nginx",
+				Type:         "nginx-php-persistent",
+			},
+		},
+		{
+			name: "test5 - service type not a spot type",
+			args: args{
+				buildValues: &BuildValues{
+					EnvironmentType: "production",
+				},
+				serviceValues: &ServiceValues{
+					Name:         "redis",
+					OverrideName: "redis",
+					Type:         "redis",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION", Value: "enabled", Scope: "build"},
+					{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION_TYPES", Value: "nginx-php-persistent,redis-persistent", Scope: "build"},
+				},
+			},
+			want: &ServiceValues{
+				Name:         "redis",
+				OverrideName: "redis",
+				Type:         "redis",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generateSpotValues(tt.args.buildValues, tt.args.serviceValues, tt.args.lagoonEnvVars, false)
+			lValues, _ := json.Marshal(tt.args.serviceValues)
+			wValues, _ := json.Marshal(tt.want)
+			if !reflect.DeepEqual(string(lValues), string(wValues)) {
+				t.Errorf("generateSpotValues() = %v, want %v", string(lValues), string(wValues))
+			}
+		})
+	}
+}
+
+func Test_checkSpotType(t *testing.T) {
+	tests := []struct {
+		name        string
+		spotTypes   string
+		serviceType string
+		wantSpot    bool
+		wantForce   bool
+	}{
+		{name: "in list", spotTypes: "nginx,cli-persistent", serviceType: "cli-persistent", wantSpot: true},
+		{name: "in list forced", spotTypes: "nginx,cli-persistent:force", serviceType: "cli-persistent", wantSpot: true, wantForce: true},
+		{name: "partial match", spotTypes: "nginx-php-persistent", serviceType: "nginx", wantSpot: false},
+		{name: "empty list", spotTypes: "", serviceType: "nginx", wantSpot: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSpot, gotForce := checkSpotType(tt.spotTypes, tt.serviceType)
+			if gotSpot != tt.wantSpot || gotForce != tt.wantForce {
+				t.Errorf("checkSpotType() = %v, %v, want %v, %v", gotSpot, gotForce, tt.wantSpot, tt.wantForce)
+			}
+		})
+	}
+}
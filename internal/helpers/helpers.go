@@ -36,6 +36,11 @@ func Int32Ptr(i int32) *int32 {
 	return &i
 }
 
+// Int64Ptr
+func Int64Ptr(i int64) *int64 {
+	return &i
+}
+
 // GetMD5HashWithNewLine .
 // in Lagoon this bash is used to generated the hash, but the `echo "${ROUTE_DOMAIN}"` adds a new line at the end, so the generated
 // sum has this in it, we need to replicate this here
@@ -20,8 +20,10 @@ type ProductionRoutes struct {
 
 // Cronjob represents a Lagoon cronjob.
 type Cronjob struct {
-	Name    string `json:"name"`
-	Command string `json:"command"`
+	Name     string `json:"name"`
+	Command  string `json:"command"`
+	Service  string `json:"service"`
+	Schedule string `json:"schedule"`
 }
 
 // Environment represents a Lagoon environment.
@@ -40,6 +42,15 @@ type TaskRun struct {
 	Run Task `json:"run"`
 }
 
+// UnwindTaskRuns simply reformats a []TaskRun structure. It gets rid of the nested "run" field so that the array is flatter.
+func UnwindTaskRuns(taskRuns []TaskRun) []Task {
+	var tasks []Task
+	for _, taskRun := range taskRuns {
+		tasks = append(tasks, taskRun.Run)
+	}
+	return tasks
+}
+
 // Tasks .
 type Tasks struct {
 	Prerollout  []TaskRun `json:"pre-rollout"`
@@ -55,6 +66,20 @@ type YAML struct {
 	Routes            Routes            `json:"routes"`
 	BackupRetention   BackupRetention   `json:"backup-retention"`
 	BackupSchedule    BackupSchedule    `json:"backup-schedule"`
+	Fastly            LagoonFastly      `json:"fastly"`
+}
+
+// LagoonFastly represents the fastly configuration in the .lagoon.yml file.
+type LagoonFastly struct {
+	APISecrets []FastlyAPISecret `json:"api-secrets"`
+}
+
+// FastlyAPISecret represents a fastly api secret, the token for the secret is sourced from the Lagoon variable
+// named in APITokenVariableName.
+type FastlyAPISecret struct {
+	Name                     string `json:"name"`
+	APITokenVariableName     string `json:"apiTokenVariableName"`
+	PlatformTLSConfiguration string `json:"platformTLSConfiguration"`
 }
 
 type BackupRetention struct {
@@ -74,7 +99,16 @@ type Retention struct {
 
 // Routes .
 type Routes struct {
-	Autogenerate Autogenerate `json:"autogenerate"`
+	Autogenerate Autogenerate             `json:"autogenerate"`
+	Defaults     map[string]RouteDefaults `json:"defaults,omitempty"`
+}
+
+// RouteDefaults are the defaults applied to every route of an environment type that doesn't define its own
+type RouteDefaults struct {
+	IPAllowlist []string        `json:"ipAllowlist,omitempty"`
+	BasicAuth   *RouteBasicAuth `json:"basicAuth,omitempty"`
+	RateLimit   *RouteRateLimit `json:"rateLimit,omitempty"`
+	MaxBodySize string          `json:"maxBodySize,omitempty"`
 }
 
 // Autogenerate .
@@ -120,7 +154,13 @@ func (a *Routes) UnmarshalJSON(data []byte) error {
 			}
 		}
 		newData, _ := json.Marshal(value)
-		return json.Unmarshal(newData, &a.Autogenerate)
+		if err := json.Unmarshal(newData, &a.Autogenerate); err != nil {
+			return err
+		}
+	}
+	if value, ok := tmpMap["defaults"]; ok {
+		newData, _ := json.Marshal(value)
+		return json.Unmarshal(newData, &a.Defaults)
 	}
 	return nil
 }
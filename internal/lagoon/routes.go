@@ -3,11 +3,14 @@ package lagoon
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
@@ -39,6 +42,62 @@ type RouteV2 struct {
 	HSTSPreload           *bool             `json:"hstsPreload,omitempty"`
 	Autogenerated         bool              `json:"-"`
 	Wildcard              *bool             `json:"wildcard,omitempty"`
+	Paths                 []RoutePath       `json:"paths,omitempty"`
+	Redirect              *RouteRedirect    `json:"redirect,omitempty"`
+	IPAllowlist           []string          `json:"ipAllowlist,omitempty"`
+	BasicAuth             *RouteBasicAuth   `json:"basicAuth,omitempty"`
+	RateLimit             *RouteRateLimit   `json:"rateLimit,omitempty"`
+	MaxBodySize           string            `json:"maxBodySize,omitempty"`
+	Canary                *RouteCanary      `json:"canary,omitempty"`
+	Headers               *RouteHeaders     `json:"headers,omitempty"`
+}
+
+// RouteBasicAuth protects a route with basic authentication, the htpasswd content is read from the lagoon variable
+type RouteBasicAuth struct {
+	Variable   string `json:"variable"`
+	Realm      string `json:"realm,omitempty"`
+	SecretName string `json:"-"`
+}
+
+// RouteRateLimit limits the number of requests or connections that a single client ip can make to a route
+type RouteRateLimit struct {
+	RPS             int `json:"rps,omitempty"`
+	RPM             int `json:"rpm,omitempty"`
+	Connections     int `json:"connections,omitempty"`
+	BurstMultiplier int `json:"burstMultiplier,omitempty"`
+}
+
+// RouteHeaders are the security response headers added to every response of a route, custom headers are keyed by the header name
+type RouteHeaders struct {
+	ContentSecurityPolicy string            `json:"contentSecurityPolicy,omitempty"`
+	FrameOptions          string            `json:"frameOptions,omitempty"`
+	ReferrerPolicy        string            `json:"referrerPolicy,omitempty"`
+	Custom                map[string]string `json:"custom,omitempty"`
+}
+
+// RouteCanary sends a percentage of the requests for a route, or the requests that match a header or cookie, to a different service
+type RouteCanary struct {
+	Service     string              `json:"service"`
+	Port        *intstr.IntOrString `json:"port,omitempty"`
+	Weight      int                 `json:"weight,omitempty"`
+	Header      string              `json:"header,omitempty"`
+	HeaderValue string              `json:"headerValue,omitempty"`
+	Cookie      string              `json:"cookie,omitempty"`
+}
+
+// RouteRedirect redirects all requests for a route to the target url instead of sending them to a service
+type RouteRedirect struct {
+	Target       string `json:"target"`
+	StatusCode   int    `json:"statusCode,omitempty"`
+	PreservePath bool   `json:"preservePath,omitempty"`
+}
+
+// RoutePath is a path based routing rule for a route, requests matching the path are sent to the service and port defined
+type RoutePath struct {
+	Path     string              `json:"path"`
+	PathType string              `json:"pathType,omitempty"`
+	Service  string              `json:"service"`
+	Port     *intstr.IntOrString `json:"port,omitempty"`
 }
 
 // Ingress represents a Lagoon route.
@@ -56,6 +115,14 @@ type Ingress struct {
 	HSTSPreload           *bool             `json:"hstsPreload,omitempty"`
 	AlternativeNames      []string          `json:"alternativenames,omitempty"`
 	Wildcard              *bool             `json:"wildcard,omitempty"`
+	Paths                 []RoutePath       `json:"paths,omitempty"`
+	Redirect              *RouteRedirect    `json:"redirect,omitempty"`
+	IPAllowlist           []string          `json:"ipAllowlist,omitempty"`
+	BasicAuth             *RouteBasicAuth   `json:"basicAuth,omitempty"`
+	RateLimit             *RouteRateLimit   `json:"rateLimit,omitempty"`
+	MaxBodySize           string            `json:"maxBodySize,omitempty"`
+	Canary                *RouteCanary      `json:"canary,omitempty"`
+	Headers               *RouteHeaders     `json:"headers,omitempty"`
 }
 
 // Route can be either a string or a map[string]Ingress, so we must
@@ -75,6 +142,10 @@ var (
 	defaultTLSAcme        *bool             = helpers.BoolPtr(true)
 	defaultActiveStandby  *bool             = helpers.BoolPtr(true)
 	defaultAnnotations    map[string]string = map[string]string{}
+	defaultPathType       string            = "Prefix"
+	supportedPathTypes    []string          = []string{"Exact", "Prefix", "ImplementationSpecific"}
+	defaultRedirectCode   int               = 301
+	supportedRedirectCode []int             = []int{301, 302, 308}
 )
 
 // UnmarshalJSON implements json.Unmarshaler.
@@ -177,6 +248,57 @@ func GenerateRoutesV2(yamlRoutes *RoutesV2, routeMap map[string][]Route, variabl
 					}
 					// hsts end
 
+					// handle response headers
+					if ingress.Headers != nil {
+						newRoute.Headers = ingress.Headers
+					}
+
+					// handle path based routing
+					if ingress.Paths != nil {
+						paths, err := generatePaths(newRoute.Domain, ingress.Paths)
+						if err != nil {
+							return err
+						}
+						newRoute.Paths = paths
+					}
+
+					// handle redirects
+					if ingress.Redirect != nil {
+						redirect, err := generateRedirect(newRoute, ingress.Redirect)
+						if err != nil {
+							return err
+						}
+						newRoute.Redirect = redirect
+					}
+
+					// handle canaries
+					if ingress.Canary != nil {
+						canary, err := generateCanary(newRoute, ingress.Canary)
+						if err != nil {
+							return err
+						}
+						newRoute.Canary = canary
+					}
+
+					// handle access restrictions
+					if ingress.IPAllowlist != nil {
+						if err := ValidateIPAllowlist(newRoute.Domain, ingress.IPAllowlist); err != nil {
+							return err
+						}
+						newRoute.IPAllowlist = ingress.IPAllowlist
+					}
+					if ingress.BasicAuth != nil {
+						newRoute.BasicAuth = ingress.BasicAuth
+					}
+
+					// handle request limits
+					if ingress.RateLimit != nil {
+						newRoute.RateLimit = ingress.RateLimit
+					}
+					if ingress.MaxBodySize != "" {
+						newRoute.MaxBodySize = ingress.MaxBodySize
+					}
+
 					// handle wildcards
 					if ingress.Wildcard != nil {
 						newRoute.Wildcard = ingress.Wildcard
@@ -342,6 +464,40 @@ func handleAPIRoute(defaultIngressClass string, apiRoute RouteV2) (RouteV2, erro
 	}
 	// hsts end
 
+	// handle path based routing
+	if apiRoute.Paths != nil {
+		paths, err := generatePaths(routeAdd.Domain, apiRoute.Paths)
+		if err != nil {
+			return routeAdd, err
+		}
+		routeAdd.Paths = paths
+	}
+
+	// handle redirects
+	if apiRoute.Redirect != nil {
+		redirect, err := generateRedirect(routeAdd, apiRoute.Redirect)
+		if err != nil {
+			return routeAdd, err
+		}
+		routeAdd.Redirect = redirect
+	}
+
+	// handle canaries
+	if apiRoute.Canary != nil {
+		canary, err := generateCanary(routeAdd, apiRoute.Canary)
+		if err != nil {
+			return routeAdd, err
+		}
+		routeAdd.Canary = canary
+	}
+
+	// handle access restrictions
+	if apiRoute.IPAllowlist != nil {
+		if err := ValidateIPAllowlist(routeAdd.Domain, apiRoute.IPAllowlist); err != nil {
+			return routeAdd, err
+		}
+	}
+
 	// handle wildcards
 	if apiRoute.Wildcard != nil {
 		routeAdd.Wildcard = apiRoute.Wildcard
@@ -358,3 +514,97 @@ func handleAPIRoute(defaultIngressClass string, apiRoute RouteV2) (RouteV2, erro
 	}
 	return routeAdd, nil
 }
+
+// generatePaths validates the path based routing rules of a route and sets the default path type
+// the services referenced by the paths are validated by the generator once all the services are known
+func generatePaths(domain string, routePaths []RoutePath) ([]RoutePath, error) {
+	paths := []RoutePath{}
+	seen := map[string]bool{}
+	for _, routePath := range routePaths {
+		if !strings.HasPrefix(routePath.Path, "/") {
+			return nil, fmt.Errorf("Route %s has path %s, paths must start with /", domain, routePath.Path)
+		}
+		if routePath.Service == "" {
+			return nil, fmt.Errorf("Route %s has path %s with no service defined", domain, routePath.Path)
+		}
+		if routePath.PathType == "" {
+			routePath.PathType = defaultPathType
+		}
+		if !helpers.Contains(supportedPathTypes, routePath.PathType) {
+			return nil, fmt.Errorf("Route %s has path %s with pathType %s, supported types are %s", domain, routePath.Path, routePath.PathType, strings.Join(supportedPathTypes, ", "))
+		}
+		if seen[fmt.Sprintf("%s:%s", routePath.PathType, routePath.Path)] {
+			return nil, fmt.Errorf("Route %s has path %s with pathType %s defined more than once", domain, routePath.Path, routePath.PathType)
+		}
+		seen[fmt.Sprintf("%s:%s", routePath.PathType, routePath.Path)] = true
+		paths = append(paths, routePath)
+	}
+	return paths, nil
+}
+
+// generateRedirect validates the redirect of a route and sets the default status code
+// a redirecting route can't also send requests to services with path based routing rules
+func generateRedirect(route RouteV2, routeRedirect *RouteRedirect) (*RouteRedirect, error) {
+	redirect := *routeRedirect
+	if len(route.Paths) > 0 {
+		return nil, fmt.Errorf("Route %s has a redirect and paths defined, a redirecting route can't send requests to a service", route.Domain)
+	}
+	target, err := url.Parse(redirect.Target)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("Route %s has redirect target %s, the target must be a http or https url", route.Domain, redirect.Target)
+	}
+	if strings.EqualFold(target.Hostname(), route.Domain) || helpers.Contains(route.AlternativeNames, strings.ToLower(target.Hostname())) {
+		return nil, fmt.Errorf("Route %s has redirect target %s, a route can't redirect to itself", route.Domain, redirect.Target)
+	}
+	if redirect.StatusCode == 0 {
+		redirect.StatusCode = defaultRedirectCode
+	}
+	supported := false
+	for _, code := range supportedRedirectCode {
+		if redirect.StatusCode == code {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("Route %s has redirect statusCode %d, supported codes are 301, 302 and 308", route.Domain, redirect.StatusCode)
+	}
+	return &redirect, nil
+}
+
+// generateCanary validates the canary of a route, a canary splits the requests for the default path of the route
+// between the route service and the canary service by weight, or by a header or cookie
+func generateCanary(route RouteV2, routeCanary *RouteCanary) (*RouteCanary, error) {
+	canary := *routeCanary
+	if canary.Service == "" {
+		return nil, fmt.Errorf("Route %s has a canary with no service defined", route.Domain)
+	}
+	if canary.Service == route.LagoonService {
+		return nil, fmt.Errorf("Route %s has a canary for service %s, the canary service must be different to the route service", route.Domain, canary.Service)
+	}
+	if route.Redirect != nil {
+		return nil, fmt.Errorf("Route %s has a redirect and canary defined, a redirecting route can't send requests to a service", route.Domain)
+	}
+	if len(route.Paths) > 0 {
+		return nil, fmt.Errorf("Route %s has a canary and paths defined, this is not supported", route.Domain)
+	}
+	if canary.Weight < 0 || canary.Weight > 100 {
+		return nil, fmt.Errorf("Route %s has a canary weight of %d, the weight must be between 0 and 100", route.Domain, canary.Weight)
+	}
+	if canary.HeaderValue != "" && canary.Header == "" {
+		return nil, fmt.Errorf("Route %s has a canary headerValue with no header defined", route.Domain)
+	}
+	if canary.Weight == 0 && canary.Header == "" && canary.Cookie == "" {
+		return nil, fmt.Errorf("Route %s has a canary with no weight, header or cookie defined, no requests would be sent to the canary", route.Domain)
+	}
+	return &canary, nil
+}
+
+// ValidateIPAllowlist checks that every entry in the ip allowlist of a route is a valid CIDR
+func ValidateIPAllowlist(domain string, ipAllowlist []string) error {
+	for _, cidr := range ipAllowlist {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("Route %s has ipAllowlist entry %s, entries must be a valid CIDR", domain, cidr)
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestGenerateRouteStructure(t *testing.T) {
@@ -413,6 +414,537 @@ func TestGenerateRouteStructure(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test8 - path based routing",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"www.example.com": {
+									Paths: []RoutePath{
+										{
+											Path:    "/api",
+											Service: "node",
+											Port:    &intstr.IntOrString{Type: intstr.Int, IntVal: 3000},
+										},
+										{
+											Path:     "/static/logo.png",
+											PathType: "Exact",
+											Service:  "nginx",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			want: &RoutesV2{
+				Routes: []RouteV2{
+					{
+						Domain:           "www.example.com",
+						LagoonService:    "nginx",
+						MonitoringPath:   "/",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "www.example.com",
+						Paths: []RoutePath{
+							{
+								Path:     "/api",
+								PathType: "Prefix",
+								Service:  "node",
+								Port:     &intstr.IntOrString{Type: intstr.Int, IntVal: 3000},
+							},
+							{
+								Path:     "/static/logo.png",
+								PathType: "Exact",
+								Service:  "nginx",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test9 - path based routing with invalid path (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"www.example.com": {
+									Paths: []RoutePath{
+										{
+											Path:    "api",
+											Service: "node",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test10 - path based routing with invalid path type (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"www.example.com": {
+									Paths: []RoutePath{
+										{
+											Path:     "/api",
+											PathType: "Regex",
+											Service:  "node",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test11 - redirect",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Redirect: &RouteRedirect{
+										Target:       "https://www.example.com",
+										PreservePath: true,
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			want: &RoutesV2{
+				Routes: []RouteV2{
+					{
+						Domain:           "example.com",
+						LagoonService:    "nginx",
+						MonitoringPath:   "/",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "example.com",
+						Redirect: &RouteRedirect{
+							Target:       "https://www.example.com",
+							StatusCode:   301,
+							PreservePath: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test12 - redirect with paths (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Redirect: &RouteRedirect{
+										Target: "https://www.example.com",
+									},
+									Paths: []RoutePath{
+										{
+											Path:    "/api",
+											Service: "node",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test13 - redirect with invalid target (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Redirect: &RouteRedirect{
+										Target: "www.example.com",
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test14 - redirect to itself (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									AlternativeNames: []string{"www.example.com"},
+									Redirect: &RouteRedirect{
+										Target: "https://www.example.com/new",
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test15 - redirect with unsupported status code (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Redirect: &RouteRedirect{
+										Target:     "https://www.example.com",
+										StatusCode: 307,
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test16 - ip allowlist and basic auth",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									IPAllowlist: []string{"203.0.113.0/24", "2001:db8::/32"},
+									BasicAuth: &RouteBasicAuth{
+										Variable: "EXAMPLE_HTPASSWD",
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			want: &RoutesV2{
+				Routes: []RouteV2{
+					{
+						Domain:           "example.com",
+						LagoonService:    "nginx",
+						MonitoringPath:   "/",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "example.com",
+						IPAllowlist:      []string{"203.0.113.0/24", "2001:db8::/32"},
+						BasicAuth: &RouteBasicAuth{
+							Variable: "EXAMPLE_HTPASSWD",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test17 - ip allowlist with an invalid cidr (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									IPAllowlist: []string{"203.0.113.1"},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test18 - rate limit and maximum body size",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									RateLimit: &RouteRateLimit{
+										RPM:         600,
+										Connections: 10,
+									},
+									MaxBodySize: "32m",
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			want: &RoutesV2{
+				Routes: []RouteV2{
+					{
+						Domain:           "example.com",
+						LagoonService:    "nginx",
+						MonitoringPath:   "/",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "example.com",
+						RateLimit: &RouteRateLimit{
+							RPM:         600,
+							Connections: 10,
+						},
+						MaxBodySize: "32m",
+					},
+				},
+			},
+		},
+		{
+			name: "test19 - canary",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Canary: &RouteCanary{
+										Service: "nginx-next",
+										Header:  "X-Canary",
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			want: &RoutesV2{
+				Routes: []RouteV2{
+					{
+						Domain:           "example.com",
+						LagoonService:    "nginx",
+						MonitoringPath:   "/",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "example.com",
+						Canary: &RouteCanary{
+							Service: "nginx-next",
+							Header:  "X-Canary",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test20 - canary for the route service (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Canary: &RouteCanary{
+										Service: "nginx",
+										Weight:  10,
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test21 - canary with an invalid weight (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Canary: &RouteCanary{
+										Service: "nginx-next",
+										Weight:  150,
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test22 - canary with nothing selecting requests (should error)",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Canary: &RouteCanary{
+										Service: "nginx-next",
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			wantErr: true,
+			want: &RoutesV2{
+				Routes: nil,
+			},
+		},
+		{
+			name: "test23 - security headers",
+			args: args{
+				yamlRoutes: &RoutesV2{},
+				yamlRouteMap: map[string][]Route{
+					"nginx": {
+						{
+							Ingresses: map[string]Ingress{
+								"example.com": {
+									Headers: &RouteHeaders{
+										ContentSecurityPolicy: "default-src 'self'",
+										FrameOptions:          "DENY",
+										Custom: map[string]string{
+											"X-Content-Type-Options": "nosniff",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				secretPrefix:  "fastly-api-",
+				activeStandby: false,
+			},
+			want: &RoutesV2{
+				Routes: []RouteV2{
+					{
+						Domain:           "example.com",
+						LagoonService:    "nginx",
+						MonitoringPath:   "/",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "example.com",
+						Headers: &RouteHeaders{
+							ContentSecurityPolicy: "default-src 'self'",
+							FrameOptions:          "DENY",
+							Custom: map[string]string{
+								"X-Content-Type-Options": "nosniff",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -757,6 +1289,65 @@ func TestMergeRouteStructures(t *testing.T) {
 				Routes: nil,
 			},
 		},
+		{
+			name: "test6 - api route with redirect",
+			args: args{
+				yamlRoutes: RoutesV2{
+					Routes: []RouteV2{
+						{
+							Domain:           "example.com",
+							LagoonService:    "nginx",
+							MonitoringPath:   "/",
+							Insecure:         helpers.StrPtr("Redirect"),
+							TLSAcme:          helpers.BoolPtr(true),
+							Annotations:      map[string]string{},
+							AlternativeNames: []string{},
+							IngressName:      "example.com",
+						},
+					},
+				},
+				apiRoutes: RoutesV2{
+					Routes: []RouteV2{
+						{
+							Domain:        "old-example.com",
+							LagoonService: "nginx",
+							Redirect: &RouteRedirect{
+								Target:     "https://example.com/welcome",
+								StatusCode: 302,
+							},
+						},
+					},
+				},
+				secretPrefix: "fastly-api-",
+			},
+			want: RoutesV2{
+				Routes: []RouteV2{
+					{
+						Domain:           "example.com",
+						LagoonService:    "nginx",
+						MonitoringPath:   "/",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "example.com",
+					},
+					{
+						Domain:           "old-example.com",
+						LagoonService:    "nginx",
+						Insecure:         helpers.StrPtr("Redirect"),
+						TLSAcme:          helpers.BoolPtr(true),
+						Annotations:      map[string]string{},
+						AlternativeNames: []string{},
+						IngressName:      "old-example.com",
+						Redirect: &RouteRedirect{
+							Target:     "https://example.com/welcome",
+							StatusCode: 302,
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
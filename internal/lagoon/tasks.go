@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"time"
@@ -17,25 +18,49 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+	gatewayclient "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 )
 
 var debug bool
 
 // Task .
 type Task struct {
-	Name                string `json:"name"`
-	Command             string `json:"command"`
-	Namespace           string `json:"namespace"`
-	Service             string `json:"service"`
-	Shell               string `json:"shell"`
-	Container           string `json:"container"`
-	When                string `json:"when"`
-	Weight              int    `json:"weight"`
-	ScaleWaitTime       int    `json:"scaleWaitTime"`
-	ScaleMaxIterations  int    `json:"scaleMaxIterations"`
-	RequiresEnvironment bool   `json:"requiresEnvironment"`
+	Name                string   `json:"name"`
+	Command             string   `json:"command"`
+	Namespace           string   `json:"namespace"`
+	Service             string   `json:"service"`
+	Shell               string   `json:"shell"`
+	Container           string   `json:"container"`
+	When                string   `json:"when"`
+	Weight              int      `json:"weight"`
+	ScaleWaitTime       int      `json:"scaleWaitTime"`
+	ScaleMaxIterations  int      `json:"scaleMaxIterations"`
+	RequiresEnvironment bool     `json:"requiresEnvironment"`
+	Timeout             string   `json:"timeout"`
+	Retries             int      `json:"retries"`
+	RetryDelay          string   `json:"retryDelay"`
+	RetryOn             []string `json:"retryOn"`
+	DependsOn           []string `json:"dependsOn"`
+	Executor            string   `json:"executor"`
+	Attempt             int      `json:"-"`
+	// Stdout and Stderr are where the output of the task is written, if they aren't set os.Stdout and os.Stderr are used
+	Stdout io.Writer `json:"-"`
+	Stderr io.Writer `json:"-"`
 }
 
+const (
+	// TaskRetryOnExitCode retries a task when the command exits with a non-zero exit code
+	TaskRetryOnExitCode = "exitCode"
+	// TaskRetryOnConnection retries a task when the command could not be run, eg the pod couldn't be scaled or the exec failed
+	TaskRetryOnConnection = "connection"
+	// TaskRetryOnTimeout retries a task when the command did not complete within the timeout of the task
+	TaskRetryOnTimeout = "timeout"
+)
+
+// defaultTaskRetryOn are the failures that are retried if a task has retries but doesn't define which failures to retry
+var defaultTaskRetryOn = []string{TaskRetryOnExitCode, TaskRetryOnConnection}
+
 // NewTask .
 func NewTask() Task {
 	return Task{
@@ -62,10 +87,121 @@ func (e *PodScalingError) Error() string {
 	return e.ErrorText
 }
 
+// TaskTimeoutError is returned when a task does not complete within its timeout
+type TaskTimeoutError struct {
+	ErrorText string
+}
+
+func (e *TaskTimeoutError) Error() string {
+	return e.ErrorText
+}
+
 func (t Task) String() string {
 	return fmt.Sprintf("{command: '%v', ns: '%v', service: '%v', shell:'%v'}", t.Command, t.Namespace, t.Service, t.Shell)
 }
 
+// GetStdout returns where the output of the task is written
+func (t Task) GetStdout() io.Writer {
+	if t.Stdout == nil {
+		return os.Stdout
+	}
+	return t.Stdout
+}
+
+// GetStderr returns where the errors of the task are written
+func (t Task) GetStderr() io.Writer {
+	if t.Stderr == nil {
+		return os.Stderr
+	}
+	return t.Stderr
+}
+
+// TimeoutDuration returns the timeout of the task, the timeout is a duration like `30s` or `1h30m`
+// a task without a timeout returns 0 and will run until it completes or the build is cancelled
+func (t Task) TimeoutDuration() (time.Duration, error) {
+	if t.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(t.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("the timeout %s of task %s is not a valid duration: %v", t.Timeout, t.Name, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("the timeout %s of task %s must be greater than 0", t.Timeout, t.Name)
+	}
+	return timeout, nil
+}
+
+// RetryDelayDuration returns the time to wait between attempts of the task, the delay is a duration like `10s`
+func (t Task) RetryDelayDuration() (time.Duration, error) {
+	if t.RetryDelay == "" {
+		return 0, nil
+	}
+	retryDelay, err := time.ParseDuration(t.RetryDelay)
+	if err != nil {
+		return 0, fmt.Errorf("the retryDelay %s of task %s is not a valid duration: %v", t.RetryDelay, t.Name, err)
+	}
+	if retryDelay < 0 {
+		return 0, fmt.Errorf("the retryDelay %s of task %s can't be negative", t.RetryDelay, t.Name)
+	}
+	return retryDelay, nil
+}
+
+// Validate checks the timeout and retry policy of the task
+func (t Task) Validate() error {
+	if _, err := t.TimeoutDuration(); err != nil {
+		return err
+	}
+	if t.Executor != "" && t.Executor != TaskExecutorExec && t.Executor != TaskExecutorJob {
+		return fmt.Errorf("the executor %s of task %s is not supported, supported values are %s and %s", t.Executor, t.Name, TaskExecutorExec, TaskExecutorJob)
+	}
+	if t.Retries < 0 {
+		return fmt.Errorf("the retries of task %s can't be negative", t.Name)
+	}
+	if _, err := t.RetryDelayDuration(); err != nil {
+		return err
+	}
+	for _, retryOn := range t.RetryOn {
+		if !helpers.Contains([]string{TaskRetryOnExitCode, TaskRetryOnConnection, TaskRetryOnTimeout}, retryOn) {
+			return fmt.Errorf("the retryOn %s of task %s is not supported, supported values are %s, %s and %s",
+				retryOn, t.Name, TaskRetryOnExitCode, TaskRetryOnConnection, TaskRetryOnTimeout)
+		}
+	}
+	return nil
+}
+
+// Retryable returns true if the task is configured to retry the failure that caused the error
+// a task is never retried if it was cancelled, or if the deployment of the service doesn't exist
+func (t Task) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var deploymentMissing *DeploymentMissingError
+	if errors.As(err, &deploymentMissing) {
+		return false
+	}
+	retryOn := t.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = defaultTaskRetryOn
+	}
+	return helpers.Contains(retryOn, taskFailureClass(err))
+}
+
+// taskFailureClass returns the class of failure that caused the error, a non-zero exit code of the command is returned
+// by the exec as an ExitError, any other failure to run the command is a connection failure
+func taskFailureClass(err error) string {
+	var timeoutErr *TaskTimeoutError
+	var exitErr utilexec.ExitError
+	switch {
+	case errors.As(err, &timeoutErr):
+		return TaskRetryOnTimeout
+	case errors.As(err, &exitErr):
+		return TaskRetryOnExitCode
+	default:
+		return TaskRetryOnConnection
+	}
+}
+
 // GetK8sClient .
 func GetK8sClient(config *rest.Config) (*kubernetes.Clientset, error) {
 	// create the clientset
@@ -76,6 +212,24 @@ func GetK8sClient(config *rest.Config) (*kubernetes.Clientset, error) {
 	return clientset, nil
 }
 
+// NewK8sClient returns a client for the cluster the build is running in, or the cluster defined by KUBECONFIG
+func NewK8sClient() (*kubernetes.Clientset, error) {
+	restCfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	return GetK8sClient(restCfg)
+}
+
+// NewGatewayAPIClient returns a gateway api client for the cluster the build is running in, or the cluster defined by KUBECONFIG
+func NewGatewayAPIClient() (*gatewayclient.Clientset, error) {
+	restCfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	return gatewayclient.NewForConfig(restCfg)
+}
+
 func getConfig() (*rest.Config, error) {
 	var kubeconfig *string
 	kubeconfig = new(string)
@@ -108,8 +262,19 @@ func getConfig() (*rest.Config, error) {
 	return config, err
 }
 
-// ExecuteTaskInEnvironment .
-func ExecuteTaskInEnvironment(task Task, prePost string) error {
+// ExecuteTaskInEnvironment runs the task in the environment, if the task has a timeout it is cancelled once the timeout is reached
+// and a TaskTimeoutError is returned. cancelling the context also stops the task
+func ExecuteTaskInEnvironment(ctx context.Context, task Task, prePost string) error {
+	timeout, err := task.TimeoutDuration()
+	if err != nil {
+		return err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	command := make([]string, 0, 5)
 	if task.Shell != "" {
 		command = append(command, task.Shell)
@@ -120,25 +285,45 @@ func ExecuteTaskInEnvironment(task Task, prePost string) error {
 	command = append(command, "-c")
 	command = append(command, task.Command)
 
-	fmt.Printf("##############################################\nBEGIN %s %s\n##############################################\n", prePost, task.Name)
+	// show the attempt in the banners of tasks that can be retried
+	attempt := ""
+	if task.Retries > 0 {
+		attempt = fmt.Sprintf(" (attempt %d/%d)", task.Attempt, task.Retries+1)
+	}
+	fmt.Fprintf(task.GetStdout(), "##############################################\nBEGIN %s %s%s\n##############################################\n", prePost, task.Name, attempt)
 	st := time.Now()
 
-	err := ExecTaskInPod(task, command, false) //(task.Service, task.Namespace, command, false, task.Container, task.ScaleWaitTime, task.ScaleMaxIterations)
+	if task.Executor == TaskExecutorJob {
+		err = ExecTaskInJob(ctx, task, command)
+	} else {
+		err = ExecTaskInPod(ctx, task, command, false) //(task.Service, task.Namespace, command, false, task.Container, task.ScaleWaitTime, task.ScaleMaxIterations)
+	}
+	if err != nil {
+		// the error from the exec doesn't make it clear that the task was stopped, so return an error that does
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			err = &TaskTimeoutError{ErrorText: fmt.Sprintf("task %s did not complete within the timeout of %s", task.Name, task.Timeout)}
+		case errors.Is(ctx.Err(), context.Canceled):
+			err = fmt.Errorf("task %s was cancelled before it completed: %w", task.Name, ctx.Err())
+		}
+	}
 
 	if err != nil {
-		fmt.Printf("Failed to execute task `%v` due to reason `%v`\n", task.Name, err.Error())
+		fmt.Fprintf(task.GetStdout(), "Failed to execute task `%v` due to reason `%v`\n", task.Name, err.Error())
 	}
 
 	et := time.Now()
 	diff := time.Time{}.Add(et.Sub(st))
 	tz, _ := et.Zone()
-	fmt.Printf("##############################################\nSTEP %s %s: Completed at %s (%s) Duration %s Elapsed %s\n##############################################\n", prePost, task.Name, et.Format("2006-01-02 15:04:05"), tz, diff.Format("15:04:05"), diff.Format("15:04:05"))
+	fmt.Fprintf(task.GetStdout(), "##############################################\nSTEP %s %s%s: Completed at %s (%s) Duration %s Elapsed %s\n##############################################\n", prePost, task.Name, attempt, et.Format("2006-01-02 15:04:05"), tz, diff.Format("15:04:05"), diff.Format("15:04:05"))
 
 	return err
 }
 
-// ExecTaskInPod .
+// ExecTaskInPod runs the command in a pod of the service of the task, scaling the deployment up if there are no ready replicas.
+// the command, and any waiting for the deployment to scale, is stopped when the context is cancelled
 func ExecTaskInPod(
+	ctx context.Context,
 	task Task,
 	command []string,
 	tty bool,
@@ -158,7 +343,7 @@ func ExecTaskInPod(
 
 	lagoonServiceLabel := "lagoon.sh/service=" + task.Service
 
-	deployments, err := depClient.List(context.TODO(), v1.ListOptions{
+	deployments, err := depClient.List(ctx, v1.ListOptions{
 		LabelSelector: lagoonServiceLabel,
 	})
 	if err != nil {
@@ -179,19 +364,21 @@ func ExecTaskInPod(
 			return errors.New("Failed to scale pods for " + deployment.Name)
 		}
 		if deployment.Status.ReadyReplicas == 0 {
-			fmt.Println(fmt.Sprintf("No ready replicas found, scaling up. Attempt %d/%d", numIterations, task.ScaleMaxIterations))
+			fmt.Fprintf(task.GetStdout(), "No ready replicas found, scaling up. Attempt %d/%d\n", numIterations, task.ScaleMaxIterations)
 
-			scale, err := clientset.AppsV1().Deployments(task.Namespace).GetScale(context.TODO(), deployment.Name, v1.GetOptions{})
+			scale, err := clientset.AppsV1().Deployments(task.Namespace).GetScale(ctx, deployment.Name, v1.GetOptions{})
 			if err != nil {
 				return err
 			}
 
 			if scale.Spec.Replicas == 0 {
 				scale.Spec.Replicas = 1
-				depClient.UpdateScale(context.TODO(), deployment.Name, scale, v1.UpdateOptions{})
+				depClient.UpdateScale(ctx, deployment.Name, scale, v1.UpdateOptions{})
+			}
+			if err := sleepWithContext(ctx, time.Second*time.Duration(task.ScaleWaitTime)); err != nil {
+				return err
 			}
-			time.Sleep(time.Second * time.Duration(task.ScaleWaitTime))
-			deployment, err = depClient.Get(context.TODO(), deployment.Name, v1.GetOptions{})
+			deployment, err = depClient.Get(ctx, deployment.Name, v1.GetOptions{})
 			if err != nil {
 				return err
 			}
@@ -203,7 +390,7 @@ func ExecTaskInPod(
 	//grab pod - for now we'll copy precisely what the build script does and use the labels
 
 	podClient := clientset.CoreV1().Pods(task.Namespace)
-	clientList, err := podClient.List(context.TODO(), v1.ListOptions{
+	clientList, err := podClient.List(ctx, v1.ListOptions{
 		LabelSelector: lagoonServiceLabel,
 	})
 
@@ -238,7 +425,7 @@ func ExecTaskInPod(
 		if task.Container != "" {
 			podName = fmt.Sprintf("%v/%v", podName, task.Container)
 		}
-		fmt.Printf("Executing task '%v' in pod %v \n", task.Name, podName)
+		fmt.Fprintf(task.GetStdout(), "Executing task '%v' in pod %v \n", task.Name, podName)
 	}
 
 	req := clientset.CoreV1().RESTClient().Post().
@@ -270,13 +457,13 @@ func ExecTaskInPod(
 		return fmt.Errorf("error while creating Executor: %v", err)
 	}
 
-	err = exec.Stream(remotecommand.StreamOptions{
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: task.GetStdout(),
+		Stderr: task.GetStderr(),
 		Tty:    tty,
 	})
 	if err != nil {
-		return fmt.Errorf("Error returned: %v", err)
+		return fmt.Errorf("Error returned: %w", err)
 	}
 
 	return nil
@@ -350,7 +537,9 @@ func UnidleNamespace(ctx context.Context, namespace string, retries int, waitTim
 	scaled := true
 	scaledDeps := make(map[string]bool)
 	for countdown := retries; len(deploys.Items) > 0 && countdown > 0; countdown-- {
-		time.Sleep(time.Second * time.Duration(waitTime))
+		if err := sleepWithContext(ctx, time.Second*time.Duration(waitTime)); err != nil {
+			return err
+		}
 		for _, deploy := range deploys.Items {
 			s, err := clientset.AppsV1().Deployments(namespace).Get(ctx, deploy.Name, v1.GetOptions{})
 			if err != nil {
@@ -375,6 +564,18 @@ func UnidleNamespace(ctx context.Context, namespace string, retries int, waitTim
 	return nil
 }
 
+// sleepWithContext waits for the duration, or until the context is cancelled
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func init() {
 	//TODO: will potentially be useful to wire this up to the global debug into
 	debug = true
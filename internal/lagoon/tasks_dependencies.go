@@ -0,0 +1,101 @@
+package lagoon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TasksHaveDependencies returns true if any of the tasks depend on another task
+func TasksHaveDependencies(tasks []Task) bool {
+	for _, task := range tasks {
+		if len(task.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskDependencies returns the indexes of the tasks that each task depends on. tasks depend on other tasks by name, so a
+// task that other tasks depend on must have a name that is unique. an error is returned if a task depends on a task that
+// doesn't exist, or if the dependencies have a cycle
+func TaskDependencies(tasks []Task) ([][]int, error) {
+	names := map[string][]int{}
+	for i, task := range tasks {
+		if task.Name != "" {
+			names[task.Name] = append(names[task.Name], i)
+		}
+	}
+	dependencies := make([][]int, len(tasks))
+	for i, task := range tasks {
+		for _, dependsOn := range task.DependsOn {
+			dependency, ok := names[dependsOn]
+			switch {
+			case !ok:
+				return nil, fmt.Errorf("task %s depends on %s, but there is no task with that name", TaskDisplayName(task, i), dependsOn)
+			case len(dependency) > 1:
+				return nil, fmt.Errorf("task %s depends on %s, but there is more than one task with that name", TaskDisplayName(task, i), dependsOn)
+			case dependency[0] == i:
+				return nil, fmt.Errorf("task %s can't depend on itself", TaskDisplayName(task, i))
+			}
+			dependencies[i] = append(dependencies[i], dependency[0])
+		}
+	}
+	if cycle := findTaskCycle(dependencies); cycle != nil {
+		path := []string{}
+		for _, i := range cycle {
+			path = append(path, TaskDisplayName(tasks[i], i))
+		}
+		return nil, fmt.Errorf("the task dependencies have a cycle: %s", strings.Join(path, " -> "))
+	}
+	return dependencies, nil
+}
+
+// findTaskCycle returns the indexes of the tasks in the first cycle found in the dependencies, starting and ending with the same task
+func findTaskCycle(dependencies [][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(dependencies))
+	stack := []int{}
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, dependency := range dependencies[i] {
+			switch state[dependency] {
+			case visiting:
+				// the dependency is already on the stack, so the cycle is the stack from the dependency
+				for j, k := range stack {
+					if k == dependency {
+						return append(append([]int{}, stack[j:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+	for i := range dependencies {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// TaskDisplayName returns the name of the task, or its position in the list of tasks if it has no name
+func TaskDisplayName(task Task, i int) string {
+	if task.Name != "" {
+		return task.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
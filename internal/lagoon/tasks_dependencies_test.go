@@ -0,0 +1,84 @@
+package lagoon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTaskDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		tasks   []Task
+		want    [][]int
+		wantErr string
+	}{
+		{
+			name: "no dependencies",
+			tasks: []Task{
+				{Name: "first"},
+				{},
+			},
+			want: [][]int{nil, nil},
+		},
+		{
+			name: "dependencies",
+			tasks: []Task{
+				{Name: "deploy"},
+				{Name: "warm cache", DependsOn: []string{"deploy"}},
+				{Name: "reindex", DependsOn: []string{"deploy"}},
+				{DependsOn: []string{"warm cache", "reindex"}},
+			},
+			want: [][]int{nil, {0}, {0}, {1, 2}},
+		},
+		{
+			name: "unknown dependency",
+			tasks: []Task{
+				{Name: "warm cache", DependsOn: []string{"deploy"}},
+			},
+			wantErr: "task warm cache depends on deploy, but there is no task with that name",
+		},
+		{
+			name: "ambiguous dependency",
+			tasks: []Task{
+				{Name: "deploy"},
+				{Name: "deploy"},
+				{DependsOn: []string{"deploy"}},
+			},
+			wantErr: "task #3 depends on deploy, but there is more than one task with that name",
+		},
+		{
+			name: "depends on itself",
+			tasks: []Task{
+				{Name: "deploy", DependsOn: []string{"deploy"}},
+			},
+			wantErr: "task deploy can't depend on itself",
+		},
+		{
+			name: "cycle",
+			tasks: []Task{
+				{Name: "first"},
+				{Name: "deploy", DependsOn: []string{"reindex", "first"}},
+				{Name: "warm cache", DependsOn: []string{"deploy"}},
+				{Name: "reindex", DependsOn: []string{"warm cache"}},
+			},
+			wantErr: "the task dependencies have a cycle: deploy -> reindex -> warm cache -> deploy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TaskDependencies(tt.tasks)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("TaskDependencies() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("TaskDependencies() wanted error %v", tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TaskDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package lagoon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	// TaskExecutorExec runs the task by exec into a running pod of the service, this is the default
+	TaskExecutorExec = "exec"
+	// TaskExecutorJob runs the task in a job created from the deployment of the service
+	TaskExecutorJob = "job"
+)
+
+// taskJobPollInterval is how often the pod of a task job is checked while waiting for it to start and complete
+var taskJobPollInterval = 2 * time.Second
+
+// taskJobScheduleTimeout is how long to wait for the pod of a task job to be scheduled when the task has no timeout, without
+// it a pod that can never be scheduled would be waited on forever
+var taskJobScheduleTimeout = 10 * time.Minute
+
+// taskJobTTL is how long a task job is kept after it completes, the job is deleted once the task is done but this
+// makes sure it is cleaned up if the build is killed before it can be deleted
+const taskJobTTL = int32(3600)
+
+// ExecTaskInJob runs the command in a job that is created from the pod spec of the deployment of the service of the task.
+// unlike ExecTaskInPod the deployment doesn't need a ready replica, so it works for idled environments. the job is
+// waited on, its logs are streamed to the output of the task, and it is deleted once it completes
+func ExecTaskInJob(
+	ctx context.Context,
+	task Task,
+	command []string,
+) error {
+	restCfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := GetK8sClient(restCfg)
+	if err != nil {
+		return fmt.Errorf("unable to create client: %v", err)
+	}
+	return execTaskInJob(ctx, clientset, task, command)
+}
+
+func execTaskInJob(ctx context.Context, clientset kubernetes.Interface, task Task, command []string) error {
+	lagoonServiceLabel := "lagoon.sh/service=" + task.Service
+	deployments, err := clientset.AppsV1().Deployments(task.Namespace).List(ctx, v1.ListOptions{
+		LabelSelector: lagoonServiceLabel,
+	})
+	if err != nil {
+		return err
+	}
+	if len(deployments.Items) == 0 {
+		return &DeploymentMissingError{ErrorText: "No deployments found matching label: " + lagoonServiceLabel}
+	}
+	deployment := deployments.Items[0]
+
+	// the job name is limited to 52 characters, the same as a cronjob, so the names of the pods it creates are valid
+	prefix := deployment.Name
+	if len(prefix) > 41 {
+		prefix = prefix[:41]
+	}
+	name := fmt.Sprintf("%s-task-%s", prefix, rand.String(5))
+	job, err := taskJob(deployment.Spec.Template.Spec, task, command, name)
+	if err != nil {
+		return err
+	}
+
+	jobClient := clientset.BatchV1().Jobs(task.Namespace)
+	if _, err := jobClient.Create(ctx, job, v1.CreateOptions{}); err != nil {
+		return fmt.Errorf("unable to create job for task %s: %v", task.Name, err)
+	}
+	fmt.Fprintf(task.GetStdout(), "Executing task '%v' in job %v \n", task.Name, name)
+	defer func() {
+		// the context may have been cancelled, so the job is deleted with a new one
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		propagation := v1.DeletePropagationBackground
+		if err := jobClient.Delete(cleanupCtx, name, v1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+			fmt.Fprintf(task.GetStdout(), "Unable to delete job %v for task '%v': %v\n", name, task.Name, err)
+		}
+	}()
+
+	// a task with a timeout has a deadline on the job, which fails the job if its pod is never scheduled
+	scheduleTimeout := time.Duration(0)
+	if job.Spec.ActiveDeadlineSeconds == nil {
+		scheduleTimeout = taskJobScheduleTimeout
+	}
+	pod, err := waitForTaskJobPod(ctx, clientset, task.Namespace, name, scheduleTimeout, func(pod corev1.Pod) bool {
+		return pod.Status.Phase != corev1.PodPending
+	})
+	if err != nil {
+		return err
+	}
+	logs, err := clientset.CoreV1().Pods(task.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: job.Spec.Template.Spec.Containers[0].Name,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to stream logs of job %s: %v", name, err)
+	}
+	defer logs.Close()
+	if _, err := io.Copy(task.GetStdout(), logs); err != nil {
+		return fmt.Errorf("unable to stream logs of job %s: %v", name, err)
+	}
+
+	// the logs end when the container stops, but the pod may not have been updated yet
+	pod, err = waitForTaskJobPod(ctx, clientset, task.Namespace, name, scheduleTimeout, func(pod corev1.Pod) bool {
+		return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+	})
+	if err != nil {
+		return err
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		// return the exit code the same way as an exec, so the task can be retried on a non-zero exit code
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == job.Spec.Template.Spec.Containers[0].Name && status.State.Terminated != nil {
+				exitCode := int(status.State.Terminated.ExitCode)
+				return fmt.Errorf("Error returned: %w", utilexec.CodeExitError{
+					Err:  fmt.Errorf("command terminated with exit code %d", exitCode),
+					Code: exitCode,
+				})
+			}
+		}
+		return fmt.Errorf("the pod %s of job %s failed: %s", pod.Name, name, pod.Status.Reason)
+	}
+	return nil
+}
+
+// taskJob returns a job that runs the command in a copy of the pod spec of a deployment. only the container the task
+// runs in is kept, sidecars would stop the job from completing, and the pod doesn't use the labels of the deployment so
+// it doesn't receive any traffic from the services of the environment
+func taskJob(podSpec corev1.PodSpec, task Task, command []string, name string) (*batchv1.Job, error) {
+	spec := podSpec.DeepCopy()
+	if len(spec.Containers) == 0 {
+		return nil, fmt.Errorf("the deployment for service %s has no containers", task.Service)
+	}
+	container := spec.Containers[0]
+	if task.Container != "" {
+		found := false
+		for _, c := range spec.Containers {
+			if c.Name == task.Container {
+				container = c
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("the deployment for service %s has no container %s", task.Service, task.Container)
+		}
+	}
+	container.Command = command
+	container.Args = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	spec.Containers = []corev1.Container{container}
+	spec.RestartPolicy = corev1.RestartPolicyNever
+
+	labels := map[string]string{
+		"lagoon.sh/jobType": "task",
+		"lagoon.sh/taskJob": name,
+	}
+	job := &batchv1.Job{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: task.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			// the job is never retried by kubernetes, the retries of the task are used instead
+			BackoffLimit:            helpers.Int32Ptr(0),
+			TTLSecondsAfterFinished: helpers.Int32Ptr(taskJobTTL),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: *spec,
+			},
+		},
+	}
+	timeout, err := task.TimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		deadline := int64(timeout.Seconds())
+		if deadline < 1 {
+			deadline = 1
+		}
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	return job, nil
+}
+
+// waitForTaskJobPod waits for the pod of the job to be in the state that the check is looking for
+// if the pod can't be started because of its image or configuration, the job has failed, or the pod isn't scheduled within the
+// schedule timeout an error is returned instead of waiting forever
+func waitForTaskJobPod(ctx context.Context, clientset kubernetes.Interface, namespace, name string, scheduleTimeout time.Duration, check func(corev1.Pod) bool) (*corev1.Pod, error) {
+	start := time.Now()
+	for {
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, v1.ListOptions{
+			LabelSelector: "lagoon.sh/taskJob=" + name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		scheduled := false
+		for _, pod := range pods.Items {
+			if check(pod) {
+				return &pod, nil
+			}
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.State.Waiting == nil {
+					continue
+				}
+				switch status.State.Waiting.Reason {
+				case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError":
+					return nil, fmt.Errorf("the pod %s of job %s can't start: %s %s", pod.Name, name, status.State.Waiting.Reason, status.State.Waiting.Message)
+				}
+			}
+			if taskJobPodScheduled(pod) {
+				scheduled = true
+			}
+		}
+		// the pod may never reach the state the check is looking for if it was evicted or deleted, or the deadline of the job
+		// was exceeded, but the job controller will mark the job as failed
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get job %s: %v", name, err)
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobFailed:
+				return nil, fmt.Errorf("the job %s failed: %s %s", name, condition.Reason, condition.Message)
+			case batchv1.JobComplete:
+				return nil, fmt.Errorf("the job %s completed, but its pod couldn't be found", name)
+			}
+		}
+		if scheduleTimeout > 0 && !scheduled && time.Since(start) > scheduleTimeout {
+			return nil, fmt.Errorf("the pod of job %s wasn't scheduled within %v", name, scheduleTimeout)
+		}
+		if err := sleepWithContext(ctx, taskJobPollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// taskJobPodScheduled returns true if the pod has been scheduled to a node
+func taskJobPodScheduled(pod corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodPending {
+		return true
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
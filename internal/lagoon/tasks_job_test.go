@@ -0,0 +1,282 @@
+package lagoon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func testTaskDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "cli",
+			Namespace: "example-project-main",
+			Labels: map[string]string{
+				"lagoon.sh/service": "cli",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: map[string]string{
+						"lagoon.sh/service": "cli",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "cli",
+							Image: "harbor.example.com/example-project/main/cli@sha256:abcdef",
+							Args:  []string{"/sbin/tini", "--", "/lagoon/entrypoints.sh"},
+							Env: []corev1.EnvVar{
+								{Name: "LAGOON_ENVIRONMENT", Value: "main"},
+							},
+							ReadinessProbe: &corev1.Probe{InitialDelaySeconds: 1},
+						},
+						{
+							Name:  "sidecar",
+							Image: "sidecar:latest",
+						},
+					},
+					Volumes: []corev1.Volume{
+						{Name: "cli-data"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_taskJob(t *testing.T) {
+	deployment := testTaskDeployment()
+	tests := []struct {
+		name          string
+		task          Task
+		wantContainer string
+		wantDeadline  int64
+		wantErr       bool
+	}{
+		{
+			name:          "first container",
+			task:          Task{Name: "test", Namespace: "example-project-main", Service: "cli"},
+			wantContainer: "cli",
+		},
+		{
+			name:          "named container with a timeout",
+			task:          Task{Name: "test", Namespace: "example-project-main", Service: "cli", Container: "sidecar", Timeout: "10m"},
+			wantContainer: "sidecar",
+			wantDeadline:  600,
+		},
+		{
+			name:    "missing container",
+			task:    Task{Name: "test", Namespace: "example-project-main", Service: "cli", Container: "php"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := []string{"sh", "-c", "drush cr"}
+			job, err := taskJob(deployment.Spec.Template.Spec, tt.task, command, "cli-task-abcde")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("taskJob() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			spec := job.Spec.Template.Spec
+			if len(spec.Containers) != 1 || spec.Containers[0].Name != tt.wantContainer {
+				t.Fatalf("taskJob() containers = %v, want only %v", spec.Containers, tt.wantContainer)
+			}
+			container := spec.Containers[0]
+			if strings.Join(container.Command, " ") != "sh -c drush cr" || container.Args != nil {
+				t.Errorf("taskJob() command = %v args = %v, want %v", container.Command, container.Args, command)
+			}
+			if container.ReadinessProbe != nil {
+				t.Errorf("taskJob() kept the readiness probe")
+			}
+			if spec.RestartPolicy != corev1.RestartPolicyNever || *job.Spec.BackoffLimit != 0 {
+				t.Errorf("taskJob() restartPolicy = %v backoffLimit = %v, want Never and 0", spec.RestartPolicy, *job.Spec.BackoffLimit)
+			}
+			if len(spec.Volumes) != 1 {
+				t.Errorf("taskJob() volumes = %v, want the volumes of the deployment", spec.Volumes)
+			}
+			if _, ok := job.Spec.Template.Labels["lagoon.sh/service"]; ok {
+				t.Errorf("taskJob() used the labels of the deployment")
+			}
+			if job.Spec.Template.Labels["lagoon.sh/taskJob"] != "cli-task-abcde" {
+				t.Errorf("taskJob() labels = %v", job.Spec.Template.Labels)
+			}
+			if tt.wantDeadline == 0 && job.Spec.ActiveDeadlineSeconds != nil {
+				t.Errorf("taskJob() activeDeadlineSeconds = %v, want nil", *job.Spec.ActiveDeadlineSeconds)
+			}
+			if tt.wantDeadline != 0 && (job.Spec.ActiveDeadlineSeconds == nil || *job.Spec.ActiveDeadlineSeconds != tt.wantDeadline) {
+				t.Errorf("taskJob() activeDeadlineSeconds = %v, want %v", job.Spec.ActiveDeadlineSeconds, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func Test_execTaskInJob(t *testing.T) {
+	pollInterval, scheduleTimeout := taskJobPollInterval, taskJobScheduleTimeout
+	t.Cleanup(func() {
+		taskJobPollInterval, taskJobScheduleTimeout = pollInterval, scheduleTimeout
+	})
+	taskJobPollInterval = 10 * time.Millisecond
+	taskJobScheduleTimeout = 100 * time.Millisecond
+	tests := []struct {
+		name         string
+		deployment   *appsv1.Deployment
+		phase        corev1.PodPhase
+		exitCode     int32
+		noPod        bool
+		jobCondition batchv1.JobConditionType
+		wantExitCode int
+		wantErr      bool
+		wantErrText  string
+	}{
+		{
+			name:       "job succeeds",
+			deployment: testTaskDeployment(),
+			phase:      corev1.PodSucceeded,
+		},
+		{
+			name:         "job fails with an exit code",
+			deployment:   testTaskDeployment(),
+			phase:        corev1.PodFailed,
+			exitCode:     2,
+			wantExitCode: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "job fails without a pod",
+			deployment:   testTaskDeployment(),
+			noPod:        true,
+			jobCondition: batchv1.JobFailed,
+			wantErr:      true,
+			wantErrText:  "DeadlineExceeded",
+		},
+		{
+			name:         "job completes without a pod",
+			deployment:   testTaskDeployment(),
+			noPod:        true,
+			jobCondition: batchv1.JobComplete,
+			wantErr:      true,
+			wantErrText:  "its pod couldn't be found",
+		},
+		{
+			name:        "pod is never scheduled",
+			deployment:  testTaskDeployment(),
+			phase:       corev1.PodPending,
+			wantErr:     true,
+			wantErrText: "wasn't scheduled",
+		},
+		{
+			name:    "missing deployment",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			if tt.deployment != nil {
+				clientset = fake.NewSimpleClientset(tt.deployment)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			done := make(chan struct{})
+			// the job controller is stopped before the test returns, so it doesn't outlive the test
+			defer func() {
+				cancel()
+				<-done
+			}()
+			// act as the job controller, and create a completed pod for the job once it exists
+			go func() {
+				defer close(done)
+				for ctx.Err() == nil {
+					jobs, _ := clientset.BatchV1().Jobs("example-project-main").List(ctx, v1.ListOptions{})
+					if len(jobs.Items) > 0 {
+						job := jobs.Items[0]
+						if tt.jobCondition != "" {
+							job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+								Type:   tt.jobCondition,
+								Status: corev1.ConditionTrue,
+								Reason: "DeadlineExceeded",
+							})
+							clientset.BatchV1().Jobs(job.Namespace).UpdateStatus(ctx, &job, v1.UpdateOptions{})
+						}
+						if tt.noPod {
+							return
+						}
+						pod := &corev1.Pod{
+							ObjectMeta: v1.ObjectMeta{
+								Name:      job.Name + "-pod",
+								Namespace: job.Namespace,
+								Labels:    job.Spec.Template.Labels,
+							},
+							Status: corev1.PodStatus{
+								Phase: tt.phase,
+								Conditions: []corev1.PodCondition{
+									{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable"},
+								},
+								ContainerStatuses: []corev1.ContainerStatus{
+									{
+										Name: job.Spec.Template.Spec.Containers[0].Name,
+										State: corev1.ContainerState{
+											Terminated: &corev1.ContainerStateTerminated{ExitCode: tt.exitCode},
+										},
+									},
+								},
+							},
+						}
+						clientset.CoreV1().Pods(job.Namespace).Create(ctx, pod, v1.CreateOptions{})
+						return
+					}
+					time.Sleep(5 * time.Millisecond)
+				}
+			}()
+			var out bytes.Buffer
+			task := Task{Name: "test", Namespace: "example-project-main", Service: "cli", Stdout: &out}
+			err := execTaskInJob(ctx, clientset, task, []string{"sh", "-c", "drush cr"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("execTaskInJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrText != "" {
+				if !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Errorf("execTaskInJob() error = %v, want an error containing %v", err, tt.wantErrText)
+				}
+				return
+			}
+			if tt.deployment == nil {
+				var deploymentMissing *DeploymentMissingError
+				if !errors.As(err, &deploymentMissing) {
+					t.Errorf("execTaskInJob() error = %v, want a DeploymentMissingError", err)
+				}
+				return
+			}
+			if tt.wantExitCode != 0 {
+				var exitErr utilexec.ExitError
+				if !errors.As(err, &exitErr) || exitErr.ExitStatus() != tt.wantExitCode {
+					t.Errorf("execTaskInJob() error = %v, want exit code %v", err, tt.wantExitCode)
+				}
+			}
+			if !strings.Contains(out.String(), "fake logs") {
+				t.Errorf("execTaskInJob() didn't stream the logs of the job: %v", out.String())
+			}
+			jobs, _ := clientset.BatchV1().Jobs("example-project-main").List(context.Background(), v1.ListOptions{})
+			if len(jobs.Items) != 0 {
+				t.Errorf("execTaskInJob() didn't delete the job")
+			}
+		})
+	}
+}
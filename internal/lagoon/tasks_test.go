@@ -1,8 +1,14 @@
 package lagoon
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
+
+	utilexec "k8s.io/client-go/util/exec"
 )
 
 func TestNewTask(t *testing.T) {
@@ -28,3 +34,176 @@ func TestNewTask(t *testing.T) {
 		})
 	}
 }
+
+func TestTask_TimeoutDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "no timeout",
+			want: 0,
+		},
+		{
+			name:    "timeout in minutes",
+			timeout: "30m",
+			want:    30 * time.Minute,
+		},
+		{
+			name:    "timeout in hours and minutes",
+			timeout: "1h30m",
+			want:    90 * time.Minute,
+		},
+		{
+			name:    "timeout without a unit",
+			timeout: "30",
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout",
+			timeout: "-5m",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := Task{Name: "test", Timeout: tt.timeout}
+			got, err := task.TimeoutDuration()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TimeoutDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("TimeoutDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sleepWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepWithContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleepWithContext() error = %v, want %v", err, context.Canceled)
+	}
+	if err := sleepWithContext(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("sleepWithContext() error = %v, want nil", err)
+	}
+}
+
+func TestTask_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    Task
+		wantErr bool
+	}{
+		{
+			name: "no timeout or retries",
+			task: Task{Name: "test"},
+		},
+		{
+			name: "retries with a delay and failures to retry",
+			task: Task{Name: "test", Timeout: "5m", Retries: 2, RetryDelay: "30s", RetryOn: []string{"exitCode", "timeout"}},
+		},
+		{
+			name:    "invalid timeout",
+			task:    Task{Name: "test", Timeout: "5 minutes"},
+			wantErr: true,
+		},
+		{
+			name:    "negative retries",
+			task:    Task{Name: "test", Retries: -1},
+			wantErr: true,
+		},
+		{
+			name:    "invalid retry delay",
+			task:    Task{Name: "test", Retries: 1, RetryDelay: "soon"},
+			wantErr: true,
+		},
+		{
+			name: "job executor",
+			task: Task{Name: "test", Executor: "job"},
+		},
+		{
+			name:    "unsupported executor",
+			task:    Task{Name: "test", Executor: "cronjob"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported failure to retry",
+			task:    Task{Name: "test", Retries: 1, RetryOn: []string{"everything"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.task.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTask_Retryable(t *testing.T) {
+	exitErr := fmt.Errorf("Error returned: %w", utilexec.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1})
+	connectionErr := fmt.Errorf("Error returned: %w", errors.New("error dialing backend: EOF"))
+	timeoutErr := &TaskTimeoutError{ErrorText: "task test did not complete within the timeout of 5m"}
+	tests := []struct {
+		name string
+		task Task
+		err  error
+		want bool
+	}{
+		{
+			name: "default retries exit codes",
+			task: Task{Retries: 1},
+			err:  exitErr,
+			want: true,
+		},
+		{
+			name: "default retries connection errors",
+			task: Task{Retries: 1},
+			err:  connectionErr,
+			want: true,
+		},
+		{
+			name: "default doesn't retry timeouts",
+			task: Task{Retries: 1},
+			err:  timeoutErr,
+			want: false,
+		},
+		{
+			name: "only retry connection errors",
+			task: Task{Retries: 1, RetryOn: []string{"connection"}},
+			err:  exitErr,
+			want: false,
+		},
+		{
+			name: "only retry timeouts",
+			task: Task{Retries: 1, RetryOn: []string{"timeout"}},
+			err:  timeoutErr,
+			want: true,
+		},
+		{
+			name: "never retry a cancelled task",
+			task: Task{Retries: 1, RetryOn: []string{"connection"}},
+			err:  fmt.Errorf("task test was cancelled before it completed: %w", context.Canceled),
+			want: false,
+		},
+		{
+			name: "never retry a missing deployment",
+			task: Task{Retries: 1},
+			err:  &DeploymentMissingError{ErrorText: "No deployments found matching label: lagoon.sh/service=cli"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
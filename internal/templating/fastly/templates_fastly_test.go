@@ -0,0 +1,83 @@
+package fastly
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+func TestGenerateFastlyAPISecretTemplate(t *testing.T) {
+	type args struct {
+		apiSecret generator.FastlyAPISecret
+		values    generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - branch environment",
+			args: args{
+				apiSecret: generator.FastlyAPISecret{
+					Name:                     "fastly-api-examplecom",
+					APIToken:                 "abcdefg123456",
+					PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+				},
+			},
+			want: "test-resources/result-fastly-api-secret-1.yaml",
+		},
+		{
+			name: "test2 - pullrequest environment",
+			args: args{
+				apiSecret: generator.FastlyAPISecret{
+					Name:                     "fastly-api-examplecom",
+					APIToken:                 "abcdefg123456",
+					PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "pr-123",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-pr-123",
+					BuildType:       "pullrequest",
+					PRNumber:        "123",
+					PRHeadBranch:    "feature",
+					PRBaseBranch:    "main",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+				},
+			},
+			want: "test-resources/result-fastly-api-secret-2.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateFastlyAPISecretTemplate(tt.args.apiSecret, tt.args.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateFastlyAPISecretTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateFastlyAPISecretTemplate() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// responseHeader is a header that is added to every response of a route
+type responseHeader struct {
+	Name  string
+	Value string
+}
+
+var (
+	// headerNameRegex matches the characters allowed in a header name (a token in RFC 7230)
+	headerNameRegex = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+	// supportedFrameOptions are the values supported by the X-Frame-Options header
+	supportedFrameOptions = []string{"DENY", "SAMEORIGIN"}
+	// supportedReferrerPolicies are the policies supported by the Referrer-Policy header
+	supportedReferrerPolicies = []string{
+		"no-referrer",
+		"no-referrer-when-downgrade",
+		"origin",
+		"origin-when-cross-origin",
+		"same-origin",
+		"strict-origin",
+		"strict-origin-when-cross-origin",
+		"unsafe-url",
+	}
+)
+
+// routeResponseHeaders returns the response headers of a route in a deterministic order. the hsts header is first, followed by
+// the Content-Security-Policy, X-Frame-Options and Referrer-Policy headers, then any custom headers sorted by name
+func routeResponseHeaders(route lagoon.RouteV2) ([]responseHeader, error) {
+	headers := []responseHeader{}
+	if route.HSTSEnabled != nil && *route.HSTSEnabled {
+		hstsHeader := fmt.Sprintf("max-age=%d", route.HSTSMaxAge)
+		if route.HSTSIncludeSubdomains != nil && *route.HSTSIncludeSubdomains {
+			hstsHeader = fmt.Sprintf("%s%s", hstsHeader, ";includeSubDomains")
+		}
+		if route.HSTSPreload != nil && *route.HSTSPreload {
+			hstsHeader = fmt.Sprintf("%s%s", hstsHeader, ";preload")
+		}
+		headers = append(headers, responseHeader{Name: "Strict-Transport-Security", Value: hstsHeader})
+	}
+	if route.Headers == nil {
+		return headers, nil
+	}
+	if route.Headers.ContentSecurityPolicy != "" {
+		headers = append(headers, responseHeader{Name: "Content-Security-Policy", Value: route.Headers.ContentSecurityPolicy})
+	}
+	if route.Headers.FrameOptions != "" {
+		frameOptions := strings.ToUpper(route.Headers.FrameOptions)
+		if !helpers.Contains(supportedFrameOptions, frameOptions) {
+			return nil, fmt.Errorf("the route %s has a frameOptions header of %s, supported values are DENY and SAMEORIGIN", route.Domain, route.Headers.FrameOptions)
+		}
+		headers = append(headers, responseHeader{Name: "X-Frame-Options", Value: frameOptions})
+	}
+	if route.Headers.ReferrerPolicy != "" {
+		// the referrer policy can be a list of fallback policies
+		for _, policy := range strings.Split(route.Headers.ReferrerPolicy, ",") {
+			if !helpers.Contains(supportedReferrerPolicies, strings.TrimSpace(policy)) {
+				return nil, fmt.Errorf("the route %s has a referrerPolicy header with an unsupported policy %s", route.Domain, strings.TrimSpace(policy))
+			}
+		}
+		headers = append(headers, responseHeader{Name: "Referrer-Policy", Value: route.Headers.ReferrerPolicy})
+	}
+	customNames := []string{}
+	for name := range route.Headers.Custom {
+		customNames = append(customNames, name)
+	}
+	sort.Strings(customNames)
+	for _, name := range customNames {
+		if !headerNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("the route %s has a custom header %s, which is not a valid header name", route.Domain, name)
+		}
+		for _, header := range headers {
+			if strings.EqualFold(header.Name, name) {
+				return nil, fmt.Errorf("the route %s has the header %s defined more than once", route.Domain, header.Name)
+			}
+		}
+		headers = append(headers, responseHeader{Name: name, Value: route.Headers.Custom[name]})
+	}
+	for _, header := range headers {
+		// the values are added to the configuration snippet in quotes, so they can't contain anything that would end the quotes or the line
+		if strings.ContainsAny(header.Value, "\"\\\r\n") {
+			return nil, fmt.Errorf("the route %s has a value for the header %s that contains quotes, backslashes or newlines, which are not supported", route.Domain, header.Name)
+		}
+	}
+	return headers, nil
+}
+
+// mergeConfigurationSnippet merges the response headers into a configuration snippet, each header is added with `more_set_headers`
+// in the order provided, followed by any configuration snippet the user has defined so the user can still override a header
+func mergeConfigurationSnippet(headers []responseHeader, snippet string) string {
+	merged := ""
+	for _, header := range headers {
+		merged = fmt.Sprintf("%smore_set_headers \"%s: %s\";\n", merged, header.Name, header.Value)
+	}
+	return fmt.Sprintf("%s%s", merged, snippet)
+}
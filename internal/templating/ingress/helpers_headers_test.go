@@ -0,0 +1,167 @@
+package routes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_routeResponseHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   lagoon.RouteV2
+		want    []responseHeader
+		wantErr bool
+	}{
+		{
+			name: "test1 - no headers",
+			route: lagoon.RouteV2{
+				Domain: "example.com",
+			},
+			want: []responseHeader{},
+		},
+		{
+			name: "test2 - hsts and all headers in order",
+			route: lagoon.RouteV2{
+				Domain:                "example.com",
+				HSTSEnabled:           helpers.BoolPtr(true),
+				HSTSMaxAge:            31536000,
+				HSTSIncludeSubdomains: helpers.BoolPtr(true),
+				Headers: &lagoon.RouteHeaders{
+					ContentSecurityPolicy: "default-src 'self'",
+					FrameOptions:          "sameorigin",
+					ReferrerPolicy:        "no-referrer, strict-origin-when-cross-origin",
+					Custom: map[string]string{
+						"X-Powered-By":           "Lagoon",
+						"Permissions-Policy":     "geolocation=()",
+						"X-Content-Type-Options": "nosniff",
+					},
+				},
+			},
+			want: []responseHeader{
+				{Name: "Strict-Transport-Security", Value: "max-age=31536000;includeSubDomains"},
+				{Name: "Content-Security-Policy", Value: "default-src 'self'"},
+				{Name: "X-Frame-Options", Value: "SAMEORIGIN"},
+				{Name: "Referrer-Policy", Value: "no-referrer, strict-origin-when-cross-origin"},
+				{Name: "Permissions-Policy", Value: "geolocation=()"},
+				{Name: "X-Content-Type-Options", Value: "nosniff"},
+				{Name: "X-Powered-By", Value: "Lagoon"},
+			},
+		},
+		{
+			name: "test3 - unsupported frame options",
+			route: lagoon.RouteV2{
+				Domain: "example.com",
+				Headers: &lagoon.RouteHeaders{
+					FrameOptions: "ALLOW-FROM https://example.net",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test4 - unsupported referrer policy",
+			route: lagoon.RouteV2{
+				Domain: "example.com",
+				Headers: &lagoon.RouteHeaders{
+					ReferrerPolicy: "same-origin, everything",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test5 - custom header duplicates hsts",
+			route: lagoon.RouteV2{
+				Domain:      "example.com",
+				HSTSEnabled: helpers.BoolPtr(true),
+				HSTSMaxAge:  31536000,
+				Headers: &lagoon.RouteHeaders{
+					Custom: map[string]string{
+						"strict-transport-security": "max-age=0",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test6 - invalid custom header name",
+			route: lagoon.RouteV2{
+				Domain: "example.com",
+				Headers: &lagoon.RouteHeaders{
+					Custom: map[string]string{
+						"X Custom": "value",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test7 - header value that would break the snippet",
+			route: lagoon.RouteV2{
+				Domain: "example.com",
+				Headers: &lagoon.RouteHeaders{
+					ContentSecurityPolicy: "default-src 'self'\";\nreturn 200;",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := routeResponseHeaders(tt.route)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("routeResponseHeaders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("routeResponseHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mergeConfigurationSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []responseHeader
+		snippet string
+		want    string
+	}{
+		{
+			name: "test1 - no headers or snippet",
+			want: "",
+		},
+		{
+			name: "test2 - headers only",
+			headers: []responseHeader{
+				{Name: "Strict-Transport-Security", Value: "max-age=31536000"},
+				{Name: "X-Frame-Options", Value: "DENY"},
+			},
+			want: "more_set_headers \"Strict-Transport-Security: max-age=31536000\";\nmore_set_headers \"X-Frame-Options: DENY\";\n",
+		},
+		{
+			name: "test3 - headers before the user snippet",
+			headers: []responseHeader{
+				{Name: "Strict-Transport-Security", Value: "max-age=31536000"},
+			},
+			snippet: "more_set_headers \"X-Frame-Options: DENY\";\nadd_header Content-type text/html;",
+			want:    "more_set_headers \"Strict-Transport-Security: max-age=31536000\";\nmore_set_headers \"X-Frame-Options: DENY\";\nadd_header Content-type text/html;",
+		},
+		{
+			name:    "test4 - user snippet only",
+			snippet: "add_header Content-type text/html;",
+			want:    "add_header Content-type text/html;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeConfigurationSnippet(tt.headers, tt.snippet); got != tt.want {
+				t.Errorf("mergeConfigurationSnippet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	networkv1 "k8s.io/api/networking/v1"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+// routeMetadata returns the domain, the truncated domain used for labels and secret names, and the default labels and
+// annotations for the resources generated for a route. wildcard routes have the wildcard prefix added to the domain
+func routeMetadata(route lagoon.RouteV2, lValues generator.BuildValues) (string, string, map[string]string, map[string]string) {
+	// truncate the route for use in labels and secretname
+	truncatedRouteDomain := route.Domain
+	if len(truncatedRouteDomain) >= 53 {
+		subdomain := strings.Split(truncatedRouteDomain, ".")[0]
+		if errs := utilvalidation.IsValidLabelValue(subdomain); errs != nil {
+			subdomain = subdomain[:53]
+		}
+		truncatedRouteDomain = fmt.Sprintf("%s-%s", strings.Split(subdomain, ".")[0], helpers.GetMD5HashWithNewLine(route.Domain)[:5])
+	}
+
+	// if this is a wildcard route, handle templating that here
+	domain := route.Domain
+	if route.Wildcard != nil && *route.Wildcard == true {
+		truncatedRouteDomain = fmt.Sprintf("wildcard-%s", truncatedRouteDomain)
+		if len(truncatedRouteDomain) >= 53 {
+			subdomain := strings.Split(truncatedRouteDomain, "-")[0]
+			if errs := utilvalidation.IsValidLabelValue(subdomain); errs != nil {
+				subdomain = subdomain[:53]
+			}
+			truncatedRouteDomain = fmt.Sprintf("%s-%s", strings.Split(subdomain, "-")[0], helpers.GetMD5HashWithNewLine(route.Domain)[:5])
+		}
+		// set the domain to include the wildcard prefix
+		domain = fmt.Sprintf("*.%s", route.Domain)
+	}
+
+	// add the default labels
+	labels := map[string]string{
+		"lagoon.sh/autogenerated":      "false",
+		"helm.sh/chart":                fmt.Sprintf("%s-%s", "custom-ingress", "0.1.0"),
+		"app.kubernetes.io/name":       "custom-ingress",
+		"app.kubernetes.io/instance":   truncatedRouteDomain,
+		"app.kubernetes.io/managed-by": "Helm",
+		"lagoon.sh/service":            truncatedRouteDomain,
+		"lagoon.sh/service-type":       "custom-ingress",
+		"lagoon.sh/project":            lValues.Project,
+		"lagoon.sh/environment":        lValues.Environment,
+		"lagoon.sh/environmentType":    lValues.EnvironmentType,
+		"lagoon.sh/buildType":          lValues.BuildType,
+	}
+
+	// add the default annotations
+	annotations := map[string]string{
+		"kubernetes.io/tls-acme": strconv.FormatBool(*route.TLSAcme),
+		"fastly.amazee.io/watch": strconv.FormatBool(route.Fastly.Watch),
+		"lagoon.sh/version":      lValues.LagoonVersion,
+	}
+	// if a cert-manager certificate is generated for the route, the tls-acme annotation is not added so that the ingress-shim
+	// doesn't also create a certificate, and the build doesn't remove the certificate as it would for `tls-acme: false`
+	if routeCertificateIssuer(route, lValues) != "" {
+		delete(annotations, "kubernetes.io/tls-acme")
+	}
+
+	if lValues.EnvironmentType == "production" && !route.Autogenerated {
+		if route.Migrate != nil {
+			labels["activestandby.lagoon.sh/migrate"] = strconv.FormatBool(*route.Migrate)
+		} else {
+			labels["activestandby.lagoon.sh/migrate"] = "false"
+		}
+	}
+	if lValues.EnvironmentType == "production" {
+		// monitoring is only available in production environments
+		annotations["monitor.stakater.com/enabled"] = "false"
+		primaryIngress, _ := url.Parse(lValues.Route)
+		// check if monitoring enabled, route isn't autogenerated, and the primary ingress from the .lagoon.yml is this processed routedomain
+		// and enable monitoring on the primary ingress only.
+		if lValues.Monitoring.Enabled && !route.Autogenerated && primaryIngress.Host == domain {
+			labels["lagoon.sh/primaryIngress"] = "true"
+
+			// only add the monitring annotations if monitoring is enabled
+			annotations["monitor.stakater.com/enabled"] = "true"
+			annotations["uptimerobot.monitor.stakater.com/alert-contacts"] = "unconfigured"
+			if lValues.Monitoring.AlertContact != "" {
+				annotations["uptimerobot.monitor.stakater.com/alert-contacts"] = lValues.Monitoring.AlertContact
+			}
+			if lValues.Monitoring.StatusPageID != "" {
+				annotations["uptimerobot.monitor.stakater.com/status-pages"] = lValues.Monitoring.StatusPageID
+			}
+			annotations["uptimerobot.monitor.stakater.com/interval"] = "60"
+		}
+		if route.MonitoringPath != "" {
+			annotations["monitor.stakater.com/overridePath"] = route.MonitoringPath
+		}
+	}
+	if route.Fastly.ServiceID != "" {
+		annotations["fastly.amazee.io/service-id"] = route.Fastly.ServiceID
+	}
+	if route.Fastly.APISecretName != "" {
+		annotations["fastly.amazee.io/api-secret-name"] = route.Fastly.APISecretName
+	}
+	if lValues.BuildType == "branch" {
+		annotations["lagoon.sh/branch"] = lValues.Branch
+	} else if lValues.BuildType == "pullrequest" {
+		annotations["lagoon.sh/prNumber"] = lValues.PRNumber
+		annotations["lagoon.sh/prHeadBranch"] = lValues.PRHeadBranch
+		annotations["lagoon.sh/prBaseBranch"] = lValues.PRBaseBranch
+	}
+	return domain, truncatedRouteDomain, labels, annotations
+}
+
+// routeTLS returns the name of the secret that holds the certificate for a route, and the hosts that the certificate is for
+// the route domain should already include any wildcard prefix
+func routeTLS(route lagoon.RouteV2, lValues generator.BuildValues, truncatedRouteDomain string) networkv1.IngressTLS {
+	tls := networkv1.IngressTLS{}
+	if route.Autogenerated {
+		// autogenerated use the service name
+		tls.SecretName = fmt.Sprintf("%s-tls", route.LagoonService)
+	} else {
+		// everything else uses the truncated
+		// use the truncated route domain here as we add `-tls`
+		// if a domain that is 253 chars long is used this will then exceed
+		// the 253 char limit on kubernetes names
+		tls.SecretName = fmt.Sprintf("%s-tls", truncatedRouteDomain)
+	}
+
+	// autogenerated domains that are too long break when creating the acme challenge k8s resource
+	// this injects a shorter domain into the tls spec that is used in the k8s challenge
+	// use the compose service name to check this, as this is how Services are populated from the compose generation
+	for _, service := range lValues.Services {
+		if service.Name == route.ComposeService {
+			if service.ShortAutogeneratedRouteDomain != "" && len(route.Domain) > 63 {
+				tls.Hosts = append(tls.Hosts, service.ShortAutogeneratedRouteDomain)
+			}
+		}
+	}
+	// add the main domain and any alternative names
+	tls.Hosts = append(tls.Hosts, route.Domain)
+	tls.Hosts = append(tls.Hosts, route.AlternativeNames...)
+	return tls
+}
+
+// routeCertificateIssuer returns the cert-manager issuer to use for the certificate of a route, or nothing if the route
+// doesn't get a certificate. routes with tls-acme enabled use the http-01 issuer, wildcard routes use the dns-01 issuer
+func routeCertificateIssuer(route lagoon.RouteV2, lValues generator.BuildValues) string {
+	if !lValues.CertManager.Enabled {
+		return ""
+	}
+	if route.Wildcard != nil && *route.Wildcard {
+		return lValues.CertManager.DNS01Issuer
+	}
+	if *route.TLSAcme {
+		return lValues.CertManager.HTTP01Issuer
+	}
+	return ""
+}
+
+// maxBodySizeRegex matches the sizes that nginx accepts, a number with an optional k, m or g unit
+var maxBodySizeRegex = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+
+// routeLimitAnnotations validates the rate limit and maximum body size of a route, and returns the annotations that apply them
+func routeLimitAnnotations(route lagoon.RouteV2) (map[string]string, error) {
+	annotations := map[string]string{}
+	if route.RateLimit != nil {
+		rateLimit := route.RateLimit
+		if rateLimit.RPS < 0 || rateLimit.RPM < 0 || rateLimit.Connections < 0 || rateLimit.BurstMultiplier < 0 {
+			return nil, fmt.Errorf("the route %s has a rateLimit with a negative value, which is not supported", route.Domain)
+		}
+		if rateLimit.BurstMultiplier > 0 && rateLimit.RPS == 0 && rateLimit.RPM == 0 {
+			return nil, fmt.Errorf("the route %s has a rateLimit burstMultiplier, but no rps or rpm to multiply", route.Domain)
+		}
+		if rateLimit.RPS > 0 {
+			annotations["nginx.ingress.kubernetes.io/limit-rps"] = strconv.Itoa(rateLimit.RPS)
+		}
+		if rateLimit.RPM > 0 {
+			annotations["nginx.ingress.kubernetes.io/limit-rpm"] = strconv.Itoa(rateLimit.RPM)
+		}
+		if rateLimit.Connections > 0 {
+			annotations["nginx.ingress.kubernetes.io/limit-connections"] = strconv.Itoa(rateLimit.Connections)
+		}
+		if rateLimit.BurstMultiplier > 0 {
+			annotations["nginx.ingress.kubernetes.io/limit-burst-multiplier"] = strconv.Itoa(rateLimit.BurstMultiplier)
+		}
+	}
+	if route.MaxBodySize != "" {
+		if !maxBodySizeRegex.MatchString(route.MaxBodySize) {
+			return nil, fmt.Errorf("the route %s has a maxBodySize of %s, which is not a valid size (eg 10m)", route.Domain, route.MaxBodySize)
+		}
+		annotations["nginx.ingress.kubernetes.io/proxy-body-size"] = route.MaxBodySize
+	}
+	return annotations, nil
+}
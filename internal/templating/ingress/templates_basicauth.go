@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// GenerateBasicAuthSecretTemplate generates the basic auth secret template for routes to apply.
+func GenerateBasicAuthSecretTemplate(
+	basicAuthSecret generator.BasicAuthSecret,
+	lValues generator.BuildValues,
+) ([]byte, error) {
+
+	// create the secret object for templating
+	secret := &corev1.Secret{}
+	secret.TypeMeta = metav1.TypeMeta{
+		Kind:       "Secret",
+		APIVersion: "v1",
+	}
+	secret.ObjectMeta.Name = basicAuthSecret.Name
+
+	// add the default labels
+	secret.ObjectMeta.Labels = map[string]string{
+		"helm.sh/chart":                fmt.Sprintf("%s-%s", "basic-auth-secret", "0.1.0"),
+		"app.kubernetes.io/name":       "basic-auth-secret",
+		"app.kubernetes.io/instance":   basicAuthSecret.Name,
+		"app.kubernetes.io/managed-by": "Helm",
+		"lagoon.sh/service":            basicAuthSecret.Name,
+		"lagoon.sh/service-type":       "basic-auth-secret",
+		"lagoon.sh/project":            lValues.Project,
+		"lagoon.sh/environment":        lValues.Environment,
+		"lagoon.sh/environmentType":    lValues.EnvironmentType,
+		"lagoon.sh/buildType":          lValues.BuildType,
+	}
+
+	// add the default annotations
+	secret.ObjectMeta.Annotations = map[string]string{
+		"lagoon.sh/version": lValues.LagoonVersion,
+	}
+	if lValues.BuildType == "branch" {
+		secret.ObjectMeta.Annotations["lagoon.sh/branch"] = lValues.Branch
+	} else if lValues.BuildType == "pullrequest" {
+		secret.ObjectMeta.Annotations["lagoon.sh/prNumber"] = lValues.PRNumber
+		secret.ObjectMeta.Annotations["lagoon.sh/prHeadBranch"] = lValues.PRHeadBranch
+		secret.ObjectMeta.Annotations["lagoon.sh/prBaseBranch"] = lValues.PRBaseBranch
+	}
+
+	// validate any annotations
+	if err := apivalidation.ValidateAnnotations(secret.ObjectMeta.Annotations, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the annotations for %s are not valid: %v", basicAuthSecret.Name, err)
+		}
+	}
+	// validate any labels
+	if err := metavalidation.ValidateLabels(secret.ObjectMeta.Labels, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the labels for %s are not valid: %v", basicAuthSecret.Name, err)
+		}
+	}
+
+	// the ingress controller reads the htpasswd content from the `auth` key
+	secret.StringData = map[string]string{
+		"auth": basicAuthSecret.Auth,
+	}
+
+	// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+	// of the current build process
+	separator := []byte("---\n")
+	secretBytes, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, err
+	}
+	result := append(separator[:], secretBytes[:]...)
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+func TestGenerateBasicAuthSecretTemplate(t *testing.T) {
+	type args struct {
+		basicAuthSecret generator.BasicAuthSecret
+		values          generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - pullrequest environment",
+			args: args{
+				basicAuthSecret: generator.BasicAuthSecret{
+					Name:     "basic-auth-staging-htpasswd",
+					Variable: "STAGING_HTPASSWD",
+					Auth:     "staging:$apr1$lP3S9Uf1$bR4E1yF2tG3hZ4x5C6v7B8\nadmin:$apr1$Ty4Zp2Ey$2Nj5x0JbNAx6n0rAUp7Ep1",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "pr-123",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-pr-123",
+					BuildType:       "pullrequest",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					PRNumber:        "123",
+					PRHeadBranch:    "feature",
+					PRBaseBranch:    "main",
+				},
+			},
+			want: "test-resources/result-basic-auth-secret-1.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateBasicAuthSecretTemplate(tt.args.basicAuthSecret, tt.args.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateBasicAuthSecretTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateBasicAuthSecretTemplate() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
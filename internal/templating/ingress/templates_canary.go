@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	networkv1 "k8s.io/api/networking/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"sigs.k8s.io/yaml"
+)
+
+// CanaryIngressName returns the name of the canary ingress for the ingress of a route
+// names are limited to 253 characters, so a long ingress name is truncated and a hash of it is added to keep the name unique
+func CanaryIngressName(ingressName string) string {
+	name := fmt.Sprintf("%s-canary", ingressName)
+	if len(name) > 253 {
+		// trim any trailing separators so the truncated name stays a valid dns subdomain
+		truncatedName := strings.TrimRight(ingressName[:240], ".-")
+		name = fmt.Sprintf("%s-%s-canary", truncatedName, helpers.GetMD5HashWithNewLine(ingressName)[:5])
+	}
+	return name
+}
+
+// GenerateCanaryIngressTemplate generates the canary ingress template for a route to apply.
+// the canary ingress has the same hosts as the ingress of the route, and ingress-nginx sends the requests selected by the
+// canary annotations to the canary service instead. if the route has no canary, nothing is returned
+func GenerateCanaryIngressTemplate(
+	route lagoon.RouteV2,
+	lValues generator.BuildValues,
+) ([]byte, error) {
+	if route.Canary == nil {
+		return nil, nil
+	}
+
+	// create the ingress object for templating
+	ingress := &networkv1.Ingress{}
+	ingress.TypeMeta = metav1.TypeMeta{
+		Kind:       "Ingress",
+		APIVersion: "networking.k8s.io/v1",
+	}
+	ingress.ObjectMeta.Name = CanaryIngressName(route.IngressName)
+
+	// add the default labels and annotations, only the lagoon annotations are used as the monitoring, fastly and tls
+	// configuration is handled by the ingress of the route
+	var annotations map[string]string
+	route.Domain, _, ingress.ObjectMeta.Labels, annotations = routeMetadata(route, lValues)
+	// the ingress of the route is the primary ingress, not the canary
+	delete(ingress.ObjectMeta.Labels, "lagoon.sh/primaryIngress")
+	ingress.ObjectMeta.Annotations = map[string]string{}
+	for key, value := range annotations {
+		if strings.HasPrefix(key, "lagoon.sh/") {
+			ingress.ObjectMeta.Annotations[key] = value
+		}
+	}
+	ingress.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/canary"] = "true"
+	if route.Canary.Weight > 0 {
+		ingress.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/canary-weight"] = strconv.Itoa(route.Canary.Weight)
+	}
+	if route.Canary.Header != "" {
+		ingress.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/canary-by-header"] = route.Canary.Header
+		if route.Canary.HeaderValue != "" {
+			ingress.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/canary-by-header-value"] = route.Canary.HeaderValue
+		}
+	}
+	if route.Canary.Cookie != "" {
+		ingress.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/canary-by-cookie"] = route.Canary.Cookie
+	}
+	// add any labels that the route had to overwrite any previous labels
+	for key, value := range route.Labels {
+		ingress.ObjectMeta.Labels[key] = value
+	}
+	// validate any annotations
+	if err := apivalidation.ValidateAnnotations(ingress.ObjectMeta.Annotations, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the annotations for the canary of %s are not valid: %v", route.Domain, err)
+		}
+	}
+	// validate any labels
+	if err := metavalidation.ValidateLabels(ingress.ObjectMeta.Labels, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the labels for the canary of %s are not valid: %v", route.Domain, err)
+		}
+	}
+
+	// add ingressclass support to ingress template generation, the canary must use the same class as the ingress of the route
+	if route.IngressClass != "" {
+		ingress.Spec.IngressClassName = &route.IngressClass
+	}
+
+	// the canary uses the default http port unless one is provided
+	servicePort := networkv1.ServiceBackendPort{
+		Name: "http",
+	}
+	if route.Canary.Port != nil {
+		if route.Canary.Port.Type == intstr.Int {
+			servicePort = networkv1.ServiceBackendPort{
+				Number: route.Canary.Port.IntVal,
+			}
+		} else {
+			servicePort = networkv1.ServiceBackendPort{
+				Name: route.Canary.Port.StrVal,
+			}
+		}
+	}
+	pt := networkv1.PathTypePrefix
+	paths := []networkv1.HTTPIngressPath{
+		{
+			Path:     "/",
+			PathType: &pt,
+			Backend: networkv1.IngressBackend{
+				Service: &networkv1.IngressServiceBackend{
+					Name: route.Canary.Service,
+					Port: servicePort,
+				},
+			},
+		},
+	}
+	// add the main domain and any alternative names as rules in the spec
+	for _, host := range append([]string{route.Domain}, route.AlternativeNames...) {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, networkv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkv1.IngressRuleValue{
+				HTTP: &networkv1.HTTPIngressRuleValue{
+					Paths: paths,
+				},
+			},
+		})
+	}
+
+	// marshal the resulting ingress
+	ingressBytes, err := yaml.Marshal(ingress)
+	if err != nil {
+		return nil, err
+	}
+	// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+	// of the current build process
+	separator := []byte("---\n")
+	result := append(separator[:], ingressBytes[:]...)
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestGenerateCanaryIngressTemplate(t *testing.T) {
+	type args struct {
+		route  lagoon.RouteV2
+		values generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - canary by weight and cookie",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					Canary: &lagoon.RouteCanary{
+						Service: "nginx-next",
+						Port:    &intstr.IntOrString{Type: intstr.String, StrVal: "http-alt"},
+						Weight:  25,
+						Cookie:  "lagoon_canary",
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName:  "example.com",
+					IngressClass: "nginx",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+			},
+			want: "test-resources/result-canary-ingress-1.yaml",
+		},
+		{
+			name: "test2 - no canary",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					IngressName:    "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateCanaryIngressTemplate(tt.args.route, tt.args.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateCanaryIngressTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("GenerateCanaryIngressTemplate() = %v, want nil", string(got))
+				}
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateCanaryIngressTemplate() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
+
+func TestCanaryIngressName(t *testing.T) {
+	tests := []struct {
+		name        string
+		ingressName string
+		want        string
+	}{
+		{
+			name:        "test1 - canary ingress name",
+			ingressName: "www.example.com",
+			want:        "www.example.com-canary",
+		},
+		{
+			name:        "test2 - long ingress name is truncated",
+			ingressName: "this-is-a-very-long-subdomain-name-for-a-canary-route-testing.this-is-a-very-long-subdomain-name-for-a-canary-route-testing.this-is-a-very-long-subdomain-name-for-a-canary-route-testing.environment-name-example-project-with-a-longer-name.example.com",
+			want:        "this-is-a-very-long-subdomain-name-for-a-canary-route-testing.this-is-a-very-long-subdomain-name-for-a-canary-route-testing.this-is-a-very-long-subdomain-name-for-a-canary-route-testing.environment-name-example-project-with-a-longer-name.ex-e1f96-canary",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CanaryIngressName(tt.ingressName)
+			if got != tt.want {
+				t.Errorf("CanaryIngressName() = %v, want %v", got, tt.want)
+			}
+			if errs := apivalidation.NameIsDNSSubdomain(got, false); errs != nil {
+				t.Errorf("CanaryIngressName() = %v is not a valid name: %v", got, errs)
+			}
+		})
+	}
+}
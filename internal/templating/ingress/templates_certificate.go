@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"fmt"
+	"strings"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+
+	"sigs.k8s.io/yaml"
+)
+
+// GenerateCertificateTemplate generates the cert-manager certificate template for a route to apply.
+// if the route does not require a certificate, nothing is returned
+func GenerateCertificateTemplate(
+	route lagoon.RouteV2,
+	lValues generator.BuildValues,
+) ([]byte, error) {
+	issuer := routeCertificateIssuer(route, lValues)
+	if issuer == "" {
+		return nil, nil
+	}
+
+	// create the certificate object for templating
+	certificate := &certmanagerv1.Certificate{}
+	certificate.TypeMeta = metav1.TypeMeta{
+		Kind:       "Certificate",
+		APIVersion: "cert-manager.io/v1",
+	}
+
+	// add the default labels and annotations, only the lagoon annotations are used as the rest are for the ingress controller
+	var truncatedRouteDomain string
+	var annotations map[string]string
+	route.Domain, truncatedRouteDomain, certificate.ObjectMeta.Labels, annotations = routeMetadata(route, lValues)
+	certificate.ObjectMeta.Annotations = map[string]string{}
+	for key, value := range annotations {
+		if strings.HasPrefix(key, "lagoon.sh/") {
+			certificate.ObjectMeta.Annotations[key] = value
+		}
+	}
+	// add any labels that the route had to overwrite any previous labels
+	for key, value := range route.Labels {
+		certificate.ObjectMeta.Labels[key] = value
+	}
+	// validate any annotations
+	if err := apivalidation.ValidateAnnotations(certificate.ObjectMeta.Annotations, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the annotations for %s are not valid: %v", route.Domain, err)
+		}
+	}
+	// validate any labels
+	if err := metavalidation.ValidateLabels(certificate.ObjectMeta.Labels, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the labels for %s are not valid: %v", route.Domain, err)
+		}
+	}
+
+	// the certificate is named after the secret it creates, which is the same secret the ingress uses for tls
+	tls := routeTLS(route, lValues, truncatedRouteDomain)
+	certificate.ObjectMeta.Name = tls.SecretName
+	certificate.Spec = certmanagerv1.CertificateSpec{
+		SecretName: tls.SecretName,
+		DNSNames:   tls.Hosts,
+		IssuerRef: cmmetav1.ObjectReference{
+			Name:  issuer,
+			Kind:  lValues.CertManager.IssuerKind,
+			Group: "cert-manager.io",
+		},
+	}
+
+	// marshal the resulting certificate
+	certificateBytes, err := yaml.Marshal(certificate)
+	if err != nil {
+		return nil, err
+	}
+	// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+	// of the current build process
+	separator := []byte("---\n")
+	result := append(separator[:], certificateBytes[:]...)
+	return result, nil
+}
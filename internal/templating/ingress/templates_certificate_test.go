@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func TestGenerateCertificateTemplate(t *testing.T) {
+	type args struct {
+		route  lagoon.RouteV2
+		values generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - certificate with alternative names",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:           "extra-long-name.a-really-long-name-that-should-truncate.www.example.com",
+					LagoonService:    "nginx",
+					MonitoringPath:   "/",
+					Insecure:         helpers.StrPtr("Redirect"),
+					TLSAcme:          helpers.BoolPtr(true),
+					Migrate:          helpers.BoolPtr(false),
+					AlternativeNames: []string{"www.example.com", "en.example.com"},
+					Annotations: map[string]string{
+						"custom-annotation": "custom annotation value",
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "extra-long-name.a-really-long-name-that-should-truncate.www.example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					CertManager: generator.CertManagerConfiguration{
+						Enabled:      true,
+						HTTP01Issuer: "lagoon-letsencrypt",
+						DNS01Issuer:  "lagoon-letsencrypt-dns",
+						IssuerKind:   "ClusterIssuer",
+					},
+				},
+			},
+			want: "test-resources/result-certificate-1.yaml",
+		},
+		{
+			name: "test2 - wildcard certificate",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "www.example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(false),
+					Wildcard:       helpers.BoolPtr(true),
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "wildcard-www.example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					CertManager: generator.CertManagerConfiguration{
+						Enabled:      true,
+						HTTP01Issuer: "lagoon-letsencrypt",
+						DNS01Issuer:  "lagoon-letsencrypt-dns",
+						IssuerKind:   "Issuer",
+					},
+				},
+			},
+			want: "test-resources/result-certificate-2.yaml",
+		},
+		{
+			name: "test3 - wildcard route without a dns-01 issuer",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:        "www.example.com",
+					LagoonService: "nginx",
+					Insecure:      helpers.StrPtr("Redirect"),
+					TLSAcme:       helpers.BoolPtr(false),
+					Wildcard:      helpers.BoolPtr(true),
+					IngressName:   "wildcard-www.example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					BuildType:       "branch",
+					CertManager: generator.CertManagerConfiguration{
+						Enabled:      true,
+						HTTP01Issuer: "lagoon-letsencrypt",
+						IssuerKind:   "ClusterIssuer",
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateCertificateTemplate(tt.args.route, tt.args.values)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("couldn't generate template %v: %v", tt.want, err)
+				}
+			}
+			if got != nil && tt.wantErr {
+				t.Errorf("wanted an error, but didn't get one")
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("GenerateCertificateTemplate() = %v, want nil", string(got))
+				}
+				return
+			}
+			if !tt.wantErr {
+				r1, err := os.ReadFile(tt.want)
+				if err != nil {
+					t.Errorf("couldn't read file %v: %v", tt.want, err)
+				}
+				if !reflect.DeepEqual(string(got), string(r1)) {
+					t.Errorf("GenerateCertificateTemplate() = %v, want %v", string(got), string(r1))
+				}
+			}
+		})
+	}
+}
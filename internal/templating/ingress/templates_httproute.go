@@ -0,0 +1,353 @@
+package routes
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"sigs.k8s.io/yaml"
+)
+
+// GenerateHTTPRouteTemplate generates the gateway api httproute templates for a route to apply.
+// routes that redirect insecure traffic generate a second httproute attached to the http listener of the gateway
+// that redirects all requests to https
+func GenerateHTTPRouteTemplate(
+	route lagoon.RouteV2,
+	lValues generator.BuildValues,
+) ([]byte, error) {
+	// httproutes have no standard way to restrict access to a route, or limit the requests to it
+	if len(route.IPAllowlist) > 0 || route.BasicAuth != nil {
+		return nil, fmt.Errorf("the route %s has an ipAllowlist or basicAuth defined, which is not supported by httproutes", route.Domain)
+	}
+	// a maxBodySize of "0" has no limit, so it is allowed
+	if route.RateLimit != nil || (route.MaxBodySize != "" && route.MaxBodySize != "0") {
+		return nil, fmt.Errorf("the route %s has a rateLimit or maxBodySize defined, which is not supported by httproutes", route.Domain)
+	}
+	if route.Canary != nil {
+		return nil, fmt.Errorf("the route %s has a canary defined, which is not supported by httproutes", route.Domain)
+	}
+
+	// create the httproute object for templating
+	httpRoute := &gwapiv1.HTTPRoute{}
+	httpRoute.TypeMeta = metav1.TypeMeta{
+		Kind:       "HTTPRoute",
+		APIVersion: "gateway.networking.k8s.io/v1",
+	}
+	httpRoute.ObjectMeta.Name = route.IngressName
+
+	// add the default labels and annotations
+	route.Domain, _, httpRoute.ObjectMeta.Labels, httpRoute.ObjectMeta.Annotations = routeMetadata(route, lValues)
+
+	// add any annotations that the route had to overwrite any previous annotations
+	for key, value := range route.Annotations {
+		httpRoute.ObjectMeta.Annotations[key] = value
+	}
+	// add any labels that the route had to overwrite any previous labels
+	for key, value := range route.Labels {
+		httpRoute.ObjectMeta.Labels[key] = value
+	}
+	// validate any annotations
+	if err := apivalidation.ValidateAnnotations(httpRoute.ObjectMeta.Annotations, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the annotations for %s are not valid: %v", route.Domain, err)
+		}
+	}
+	// validate any labels
+	if err := metavalidation.ValidateLabels(httpRoute.ObjectMeta.Labels, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the labels for %s are not valid: %v", route.Domain, err)
+		}
+	}
+
+	// the main domain and any alternative names are all hostnames of the httproute
+	httpRoute.Spec.Hostnames = []gwapiv1.Hostname{gwapiv1.Hostname(route.Domain)}
+	for _, alternativeName := range route.AlternativeNames {
+		httpRoute.Spec.Hostnames = append(httpRoute.Spec.Hostnames, gwapiv1.Hostname(alternativeName))
+	}
+
+	// httproute backends only support port numbers, so any port names need to be looked up from the services
+	// default service port is http in all lagoon deployments
+	servicePortName := "http"
+	if route.ServicePortName != nil {
+		servicePortName = *route.ServicePortName
+	}
+	var servicePort int32
+	if route.ServicePortNumber != nil && route.ServicePortName == nil {
+		servicePort = *route.ServicePortNumber
+	} else {
+		portNumber, err := servicestemplates.ServicePortNumber(lValues, route.LagoonService, servicePortName)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't generate httproute for %s: %v", route.Domain, err)
+		}
+		servicePort = portNumber
+	}
+
+	// any response headers that would be added by the ingress controller are added with a filter
+	responseHeaders := []gwapiv1.HTTPHeader{}
+	if lValues.EnvironmentType == "development" || route.Autogenerated {
+		responseHeaders = append(responseHeaders, gwapiv1.HTTPHeader{
+			Name:  "X-Robots-Tag",
+			Value: "noindex, nofollow",
+		})
+	}
+	// add the hsts and any other response headers of the route
+	routeHeaders, err := routeResponseHeaders(route)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range routeHeaders {
+		responseHeaders = append(responseHeaders, gwapiv1.HTTPHeader{
+			Name:  gwapiv1.HTTPHeaderName(header.Name),
+			Value: header.Value,
+		})
+	}
+	filters := []gwapiv1.HTTPRouteFilter{}
+	if len(responseHeaders) > 0 {
+		filters = append(filters, gwapiv1.HTTPRouteFilter{
+			Type: gwapiv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: &gwapiv1.HTTPHeaderFilter{
+				Set: responseHeaders,
+			},
+		})
+	}
+
+	// set up the rules, the route service is always the default path
+	// unless a path based routing rule replaces it
+	rules := []gwapiv1.HTTPRouteRule{
+		httpRouteRule(gwapiv1.PathMatchPathPrefix, "/", route.LagoonService, servicePort, filters),
+	}
+	for _, routePath := range route.Paths {
+		var pathType gwapiv1.PathMatchType
+		switch routePath.PathType {
+		case "Prefix":
+			pathType = gwapiv1.PathMatchPathPrefix
+		case "Exact":
+			pathType = gwapiv1.PathMatchExact
+		default:
+			return nil, fmt.Errorf("the path %s for %s uses the pathType %s, which is not supported by httproutes", routePath.Path, route.Domain, routePath.PathType)
+		}
+		// paths use the default http port unless one is provided
+		pathPort := intstr.FromString("http")
+		if routePath.Port != nil {
+			pathPort = *routePath.Port
+		}
+		pathPortNumber := pathPort.IntVal
+		if pathPort.Type == intstr.String {
+			portNumber, err := servicestemplates.ServicePortNumber(lValues, routePath.Service, pathPort.StrVal)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't generate httproute for %s: %v", route.Domain, err)
+			}
+			pathPortNumber = portNumber
+		}
+		rule := httpRouteRule(pathType, routePath.Path, routePath.Service, pathPortNumber, filters)
+		if routePath.Path == "/" && pathType == gwapiv1.PathMatchPathPrefix {
+			rules[0] = rule
+		} else {
+			rules = append(rules, rule)
+		}
+	}
+	httpRoute.Spec.Rules = rules
+
+	// redirecting routes replace the rules with a single rule that redirects every request to the target
+	if route.Redirect != nil {
+		filter, err := httpRouteRedirectFilter(route)
+		if err != nil {
+			return nil, err
+		}
+		rule := gwapiv1.HTTPRouteRule{
+			Filters: []gwapiv1.HTTPRouteFilter{filter},
+		}
+		// replacing the prefix of the path requires the rule to match on a path prefix
+		if filter.RequestRedirect.Path != nil && filter.RequestRedirect.Path.Type == gwapiv1.PrefixMatchHTTPPathModifier {
+			pathType := gwapiv1.PathMatchPathPrefix
+			path := "/"
+			rule.Matches = []gwapiv1.HTTPRouteMatch{
+				{
+					Path: &gwapiv1.HTTPPathMatch{
+						Type:  &pathType,
+						Value: &path,
+					},
+				},
+			}
+		}
+		httpRoute.Spec.Rules = []gwapiv1.HTTPRouteRule{rule}
+	}
+
+	parentRef := gwapiv1.ParentReference{
+		Name: gwapiv1.ObjectName(lValues.GatewayAPI.ParentName),
+	}
+	if lValues.GatewayAPI.ParentNamespace != "" {
+		namespace := gwapiv1.Namespace(lValues.GatewayAPI.ParentNamespace)
+		parentRef.Namespace = &namespace
+	}
+
+	// routes that allow insecure traffic attach to all listeners of the gateway
+	// otherwise the route only attaches to the https listener, and insecure traffic is redirected
+	var redirectRoute *gwapiv1.HTTPRoute
+	switch httpRouteInsecure(route) {
+	case "Allow":
+		httpRoute.Spec.ParentRefs = []gwapiv1.ParentReference{parentRef}
+	case "Redirect", "None":
+		httpsParentRef := parentRef
+		httpsListener := gwapiv1.SectionName(lValues.GatewayAPI.HTTPSListener)
+		httpsParentRef.SectionName = &httpsListener
+		httpRoute.Spec.ParentRefs = []gwapiv1.ParentReference{httpsParentRef}
+
+		httpParentRef := parentRef
+		httpListener := gwapiv1.SectionName(lValues.GatewayAPI.HTTPListener)
+		httpParentRef.SectionName = &httpListener
+		redirectRoute = httpRoute.DeepCopy()
+		redirectRoute.ObjectMeta.Name = httpRouteRedirectName(route.IngressName)
+		redirectRoute.Spec.ParentRefs = []gwapiv1.ParentReference{httpParentRef}
+		scheme := "https"
+		statusCode := 301
+		redirectRoute.Spec.Rules = []gwapiv1.HTTPRouteRule{
+			{
+				Filters: []gwapiv1.HTTPRouteFilter{
+					{
+						Type: gwapiv1.HTTPRouteFilterRequestRedirect,
+						RequestRedirect: &gwapiv1.HTTPRequestRedirectFilter{
+							Scheme:     &scheme,
+							StatusCode: &statusCode,
+						},
+					},
+				},
+			},
+		}
+	default:
+		return nil, fmt.Errorf("the insecure value %s for %s is not valid", *route.Insecure, route.Domain)
+	}
+
+	// marshal the resulting httproutes
+	// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+	// of the current build process
+	separator := []byte("---\n")
+	httpRouteBytes, err := yaml.Marshal(httpRoute)
+	if err != nil {
+		return nil, err
+	}
+	result := append(separator[:], httpRouteBytes[:]...)
+	if redirectRoute != nil {
+		redirectRouteBytes, err := yaml.Marshal(redirectRoute)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, separator[:]...)
+		result = append(result, redirectRouteBytes[:]...)
+	}
+	return result, nil
+}
+
+// HTTPRouteNames returns the names of the httproutes that are generated for a route
+func HTTPRouteNames(route lagoon.RouteV2) []string {
+	names := []string{route.IngressName}
+	switch httpRouteInsecure(route) {
+	case "Redirect", "None":
+		names = append(names, httpRouteRedirectName(route.IngressName))
+	}
+	return names
+}
+
+// httpRouteInsecure returns how insecure traffic is handled by the httproutes of a route
+// redirecting routes that redirect insecure traffic redirect it straight to the target instead of to https first, so they
+// allow insecure traffic. redirecting routes with `insecure: None` only attach to the https listener, the same as other routes
+func httpRouteInsecure(route lagoon.RouteV2) string {
+	if route.Insecure == nil {
+		return ""
+	}
+	insecure := *route.Insecure
+	if route.Redirect != nil && insecure == "Redirect" {
+		insecure = "Allow"
+	}
+	return insecure
+}
+
+// httpRouteRedirectName returns the name of the httproute that redirects insecure traffic to https
+func httpRouteRedirectName(ingressName string) string {
+	return fmt.Sprintf("%s-redirect", ingressName)
+}
+
+// httpRouteRule returns a httproute rule that matches a path and sends it to the port of a service
+func httpRouteRule(pathType gwapiv1.PathMatchType, path, service string, port int32, filters []gwapiv1.HTTPRouteFilter) gwapiv1.HTTPRouteRule {
+	pathValue := path
+	portNumber := gwapiv1.PortNumber(port)
+	return gwapiv1.HTTPRouteRule{
+		Matches: []gwapiv1.HTTPRouteMatch{
+			{
+				Path: &gwapiv1.HTTPPathMatch{
+					Type:  &pathType,
+					Value: &pathValue,
+				},
+			},
+		},
+		Filters: filters,
+		BackendRefs: []gwapiv1.HTTPBackendRef{
+			{
+				BackendRef: gwapiv1.BackendRef{
+					BackendObjectReference: gwapiv1.BackendObjectReference{
+						Name: gwapiv1.ObjectName(service),
+						Port: &portNumber,
+					},
+				},
+			},
+		},
+	}
+}
+
+// httpRouteRedirectFilter returns the request redirect filter for a redirecting route
+// httproutes only support the 301 and 302 status codes for redirects
+func httpRouteRedirectFilter(route lagoon.RouteV2) (gwapiv1.HTTPRouteFilter, error) {
+	if route.Redirect.StatusCode != 301 && route.Redirect.StatusCode != 302 {
+		return gwapiv1.HTTPRouteFilter{}, fmt.Errorf("the redirect statusCode %d for %s is not supported by httproutes", route.Redirect.StatusCode, route.Domain)
+	}
+	target, err := url.Parse(route.Redirect.Target)
+	if err != nil {
+		return gwapiv1.HTTPRouteFilter{}, fmt.Errorf("the redirect target %s for %s is not valid: %v", route.Redirect.Target, route.Domain, err)
+	}
+	scheme := target.Scheme
+	hostname := gwapiv1.PreciseHostname(target.Hostname())
+	statusCode := route.Redirect.StatusCode
+	redirect := &gwapiv1.HTTPRequestRedirectFilter{
+		Scheme:     &scheme,
+		Hostname:   &hostname,
+		StatusCode: &statusCode,
+	}
+	if target.Port() != "" {
+		port, _ := strconv.Atoi(target.Port())
+		portNumber := gwapiv1.PortNumber(port)
+		redirect.Port = &portNumber
+	}
+	// unless the path is preserved, every request is redirected to the path of the target
+	// if the path is preserved, it is added to the end of the path of the target the same way as the ingress redirect
+	if route.Redirect.PreservePath {
+		if path := strings.TrimSuffix(target.Path, "/"); path != "" {
+			redirect.Path = &gwapiv1.HTTPPathModifier{
+				Type:               gwapiv1.PrefixMatchHTTPPathModifier,
+				ReplacePrefixMatch: &path,
+			}
+		}
+	} else {
+		path := target.Path
+		if path == "" {
+			path = "/"
+		}
+		redirect.Path = &gwapiv1.HTTPPathModifier{
+			Type:            gwapiv1.FullPathHTTPPathModifier,
+			ReplaceFullPath: &path,
+		}
+	}
+	return gwapiv1.HTTPRouteFilter{
+		Type:            gwapiv1.HTTPRouteFilterRequestRedirect,
+		RequestRedirect: redirect,
+	}, nil
+}
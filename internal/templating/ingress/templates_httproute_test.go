@@ -0,0 +1,442 @@
+package routes
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestGenerateHTTPRouteTemplate(t *testing.T) {
+	type args struct {
+		route  lagoon.RouteV2
+		values generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - httproute with insecure redirect and hsts",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:                "www.example.com",
+					LagoonService:         "nginx",
+					MonitoringPath:        "/",
+					Insecure:              helpers.StrPtr("Redirect"),
+					TLSAcme:               helpers.BoolPtr(true),
+					Migrate:               helpers.BoolPtr(false),
+					HSTSEnabled:           helpers.BoolPtr(true),
+					HSTSMaxAge:            31536000,
+					HSTSIncludeSubdomains: helpers.BoolPtr(true),
+					HSTSPreload:           helpers.BoolPtr(true),
+					AlternativeNames:      []string{"example.com"},
+					Annotations: map[string]string{
+						"custom-annotation": "custom annotation value",
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "www.example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:         true,
+						ParentName:      "lagoon-gateway",
+						ParentNamespace: "gateway-system",
+						HTTPListener:    "http",
+						HTTPSListener:   "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-httproute-1.yaml",
+		},
+		{
+			name: "test2 - httproute allowing insecure traffic with path based routing",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "www.example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Allow"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Paths: []lagoon.RoutePath{
+						{
+							Path:     "/api",
+							PathType: "Prefix",
+							Service:  "node",
+						},
+						{
+							Path:     "/healthz",
+							PathType: "Exact",
+							Service:  "node",
+							Port:     &intstr.IntOrString{Type: intstr.Int, IntVal: 3001},
+						},
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "www.example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+						{
+							Name:         "node",
+							OverrideName: "node",
+							Type:         "node",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-httproute-2.yaml",
+		},
+		{
+			name: "test5 - httproute with a redirect",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Redirect: &lagoon.RouteRedirect{
+						Target:     "https://www.example.com/welcome",
+						StatusCode: 301,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-httproute-3.yaml",
+		},
+		{
+			name: "test7 - httproute with a redirect that preserves the path",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Redirect: &lagoon.RouteRedirect{
+						Target:       "https://www.example.com/welcome/",
+						StatusCode:   302,
+						PreservePath: true,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-httproute-4.yaml",
+		},
+		{
+			name: "test8 - httproute with a redirect and no body size limit",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Redirect: &lagoon.RouteRedirect{
+						Target:     "https://www.example.com/welcome",
+						StatusCode: 301,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					MaxBodySize: "0",
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-httproute-3.yaml",
+		},
+		{
+			name: "test9 - httproute with a redirect that does not allow insecure traffic",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("None"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Redirect: &lagoon.RouteRedirect{
+						Target:     "https://www.example.com/welcome",
+						StatusCode: 301,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-httproute-5.yaml",
+		},
+		{
+			name: "test6 - httproute with an unsupported redirect status code",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:        "example.com",
+					LagoonService: "nginx",
+					Insecure:      helpers.StrPtr("Redirect"),
+					TLSAcme:       helpers.BoolPtr(true),
+					Redirect: &lagoon.RouteRedirect{
+						Target:     "https://www.example.com",
+						StatusCode: 308,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					BuildType:       "branch",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test3 - implementation specific path type",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:        "www.example.com",
+					LagoonService: "nginx",
+					Insecure:      helpers.StrPtr("Redirect"),
+					TLSAcme:       helpers.BoolPtr(true),
+					Paths: []lagoon.RoutePath{
+						{
+							Path:     "/api",
+							PathType: "ImplementationSpecific",
+							Service:  "nginx",
+						},
+					},
+					IngressName: "www.example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					BuildType:       "branch",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test4 - service port name not found",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:          "www.example.com",
+					LagoonService:   "nginx",
+					Insecure:        helpers.StrPtr("Redirect"),
+					TLSAcme:         helpers.BoolPtr(true),
+					ServicePortName: helpers.StrPtr("metrics"),
+					IngressName:     "www.example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					BuildType:       "branch",
+					GatewayAPI: generator.GatewayAPIConfiguration{
+						Enabled:       true,
+						ParentName:    "lagoon-gateway",
+						HTTPListener:  "http",
+						HTTPSListener: "https",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateHTTPRouteTemplate(tt.args.route, tt.args.values)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("couldn't generate template %v: %v", tt.want, err)
+				}
+			}
+			if got != nil && tt.wantErr {
+				t.Errorf("wanted an error, but didn't get one")
+			}
+			if !tt.wantErr {
+				r1, err := os.ReadFile(tt.want)
+				if err != nil {
+					t.Errorf("couldn't read file %v: %v", tt.want, err)
+				}
+				if !reflect.DeepEqual(string(got), string(r1)) {
+					t.Errorf("GenerateHTTPRouteTemplate() = %v, want %v", string(got), string(r1))
+				}
+			}
+		})
+	}
+}
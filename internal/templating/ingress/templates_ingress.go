@@ -2,18 +2,16 @@ package routes
 
 import (
 	"fmt"
-	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
-	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 	networkv1 "k8s.io/api/networking/v1"
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
-	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"sigs.k8s.io/yaml"
 )
@@ -24,16 +22,6 @@ func GenerateIngressTemplate(
 	lValues generator.BuildValues,
 ) ([]byte, error) {
 
-	// truncate the route for use in labels and secretname
-	truncatedRouteDomain := route.Domain
-	if len(truncatedRouteDomain) >= 53 {
-		subdomain := strings.Split(truncatedRouteDomain, ".")[0]
-		if errs := utilvalidation.IsValidLabelValue(subdomain); errs != nil {
-			subdomain = subdomain[:53]
-		}
-		truncatedRouteDomain = fmt.Sprintf("%s-%s", strings.Split(subdomain, ".")[0], helpers.GetMD5HashWithNewLine(route.Domain)[:5])
-	}
-
 	// create the ingress object for templating
 	ingress := &networkv1.Ingress{}
 	ingress.TypeMeta = metav1.TypeMeta{
@@ -42,89 +30,12 @@ func GenerateIngressTemplate(
 	}
 	ingress.ObjectMeta.Name = route.IngressName
 
-	// if this is a wildcard ingress, handle templating that here
-	if route.Wildcard != nil && *route.Wildcard == true {
-		truncatedRouteDomain = fmt.Sprintf("wildcard-%s", truncatedRouteDomain)
-		if len(truncatedRouteDomain) >= 53 {
-			subdomain := strings.Split(truncatedRouteDomain, "-")[0]
-			if errs := utilvalidation.IsValidLabelValue(subdomain); errs != nil {
-				subdomain = subdomain[:53]
-			}
-			truncatedRouteDomain = fmt.Sprintf("%s-%s", strings.Split(subdomain, "-")[0], helpers.GetMD5HashWithNewLine(route.Domain)[:5])
-		}
-		// set the domain to include the wildcard prefix
-		route.Domain = fmt.Sprintf("*.%s", route.Domain)
-	}
-
-	// add the default labels
-	ingress.ObjectMeta.Labels = map[string]string{
-		"lagoon.sh/autogenerated":      "false",
-		"helm.sh/chart":                fmt.Sprintf("%s-%s", "custom-ingress", "0.1.0"),
-		"app.kubernetes.io/name":       "custom-ingress",
-		"app.kubernetes.io/instance":   truncatedRouteDomain,
-		"app.kubernetes.io/managed-by": "Helm",
-		"lagoon.sh/service":            truncatedRouteDomain,
-		"lagoon.sh/service-type":       "custom-ingress",
-		"lagoon.sh/project":            lValues.Project,
-		"lagoon.sh/environment":        lValues.Environment,
-		"lagoon.sh/environmentType":    lValues.EnvironmentType,
-		"lagoon.sh/buildType":          lValues.BuildType,
-	}
+	// add the default labels and annotations
+	var truncatedRouteDomain string
+	route.Domain, truncatedRouteDomain, ingress.ObjectMeta.Labels, ingress.ObjectMeta.Annotations = routeMetadata(route, lValues)
 	additionalLabels := map[string]string{}
-
-	// add the default annotations
-	ingress.ObjectMeta.Annotations = map[string]string{
-		"kubernetes.io/tls-acme": strconv.FormatBool(*route.TLSAcme),
-		"fastly.amazee.io/watch": strconv.FormatBool(route.Fastly.Watch),
-		"lagoon.sh/version":      lValues.LagoonVersion,
-	}
 	additionalAnnotations := map[string]string{}
 
-	if lValues.EnvironmentType == "production" && !route.Autogenerated {
-		if route.Migrate != nil {
-			additionalLabels["activestandby.lagoon.sh/migrate"] = strconv.FormatBool(*route.Migrate)
-		} else {
-			additionalLabels["activestandby.lagoon.sh/migrate"] = "false"
-		}
-	}
-	if lValues.EnvironmentType == "production" {
-		// monitoring is only available in production environments
-		additionalAnnotations["monitor.stakater.com/enabled"] = "false"
-		primaryIngress, _ := url.Parse(lValues.Route)
-		// check if monitoring enabled, route isn't autogenerated, and the primary ingress from the .lagoon.yml is this processed routedomain
-		// and enable monitoring on the primary ingress only.
-		if lValues.Monitoring.Enabled && !route.Autogenerated && primaryIngress.Host == route.Domain {
-			additionalLabels["lagoon.sh/primaryIngress"] = "true"
-
-			// only add the monitring annotations if monitoring is enabled
-			additionalAnnotations["monitor.stakater.com/enabled"] = "true"
-			additionalAnnotations["uptimerobot.monitor.stakater.com/alert-contacts"] = "unconfigured"
-			if lValues.Monitoring.AlertContact != "" {
-				additionalAnnotations["uptimerobot.monitor.stakater.com/alert-contacts"] = lValues.Monitoring.AlertContact
-			}
-			if lValues.Monitoring.StatusPageID != "" {
-				additionalAnnotations["uptimerobot.monitor.stakater.com/status-pages"] = lValues.Monitoring.StatusPageID
-			}
-			additionalAnnotations["uptimerobot.monitor.stakater.com/interval"] = "60"
-		}
-		if route.MonitoringPath != "" {
-			additionalAnnotations["monitor.stakater.com/overridePath"] = route.MonitoringPath
-		}
-	}
-	if route.Fastly.ServiceID != "" {
-		additionalAnnotations["fastly.amazee.io/service-id"] = route.Fastly.ServiceID
-	}
-	if route.Fastly.APISecretName != "" {
-		additionalAnnotations["fastly.amazee.io/api-secret-name"] = route.Fastly.APISecretName
-	}
-	if lValues.BuildType == "branch" {
-		additionalAnnotations["lagoon.sh/branch"] = lValues.Branch
-	} else if lValues.BuildType == "pullrequest" {
-		additionalAnnotations["lagoon.sh/prNumber"] = lValues.PRNumber
-		additionalAnnotations["lagoon.sh/prHeadBranch"] = lValues.PRHeadBranch
-		additionalAnnotations["lagoon.sh/prBaseBranch"] = lValues.PRBaseBranch
-
-	}
 	if *route.Insecure == "Allow" {
 		additionalAnnotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "false"
 		additionalAnnotations["ingress.kubernetes.io/ssl-redirect"] = "false"
@@ -136,33 +47,58 @@ func GenerateIngressTemplate(
 		additionalAnnotations["nginx.ingress.kubernetes.io/server-snippet"] = "add_header X-Robots-Tag \"noindex, nofollow\";\n"
 	}
 
-	// check if a user has defined hsts configuration
-	if route.HSTSEnabled != nil && *route.HSTSEnabled {
-		hstsHeader := fmt.Sprintf("more_set_headers \"Strict-Transport-Security: max-age=%d", route.HSTSMaxAge)
-		if route.HSTSIncludeSubdomains != nil && *route.HSTSIncludeSubdomains {
-			hstsHeader = fmt.Sprintf("%s%s", hstsHeader, ";includeSubDomains")
-		}
-		if route.HSTSPreload != nil && *route.HSTSPreload {
-			hstsHeader = fmt.Sprintf("%s%s", hstsHeader, ";preload")
-		}
-		hstsHeader = fmt.Sprintf("%s\"", hstsHeader)
-		// if someone has already set a configuration-snippet annotation, then add the hsts header
-		// to the top of the existing annotation before it is added to the ingress object
+	// add the hsts and any other response headers of the route to the configuration snippet
+	responseHeaders, err := routeResponseHeaders(route)
+	if err != nil {
+		return nil, err
+	}
+	if len(responseHeaders) > 0 {
+		// if someone has already set a configuration-snippet annotation, then the headers are merged
+		// into the top of the existing annotation before it is added to the ingress object
 		if value, ok := route.Annotations["nginx.ingress.kubernetes.io/configuration-snippet"]; ok {
-			route.Annotations["nginx.ingress.kubernetes.io/configuration-snippet"] = fmt.Sprintf(
-				"%s;\n%s",
-				hstsHeader,
-				value,
-			)
+			route.Annotations["nginx.ingress.kubernetes.io/configuration-snippet"] = mergeConfigurationSnippet(responseHeaders, value)
 		} else {
 			// otherwise create a new one in the additional annotations
-			additionalAnnotations["nginx.ingress.kubernetes.io/configuration-snippet"] = fmt.Sprintf(
-				"%s;\n",
-				hstsHeader,
-			)
+			additionalAnnotations["nginx.ingress.kubernetes.io/configuration-snippet"] = mergeConfigurationSnippet(responseHeaders, "")
+		}
+	}
+
+	// redirecting routes return the redirect before the request is sent to the service
+	if route.Redirect != nil {
+		target := route.Redirect.Target
+		if route.Redirect.PreservePath {
+			target = fmt.Sprintf("%s$request_uri", strings.TrimSuffix(target, "/"))
+		}
+		if route.Redirect.StatusCode == 302 {
+			additionalAnnotations["nginx.ingress.kubernetes.io/temporal-redirect"] = target
+		} else {
+			additionalAnnotations["nginx.ingress.kubernetes.io/permanent-redirect"] = target
+			additionalAnnotations["nginx.ingress.kubernetes.io/permanent-redirect-code"] = strconv.Itoa(route.Redirect.StatusCode)
+		}
+	}
+
+	// restrict access to the route
+	if len(route.IPAllowlist) > 0 {
+		additionalAnnotations["nginx.ingress.kubernetes.io/whitelist-source-range"] = strings.Join(route.IPAllowlist, ",")
+	}
+	if route.BasicAuth != nil {
+		additionalAnnotations["nginx.ingress.kubernetes.io/auth-type"] = "basic"
+		additionalAnnotations["nginx.ingress.kubernetes.io/auth-secret"] = route.BasicAuth.SecretName
+		additionalAnnotations["nginx.ingress.kubernetes.io/auth-realm"] = "Authentication Required"
+		if route.BasicAuth.Realm != "" {
+			additionalAnnotations["nginx.ingress.kubernetes.io/auth-realm"] = route.BasicAuth.Realm
 		}
 	}
 
+	// limit the requests to the route
+	limitAnnotations, err := routeLimitAnnotations(route)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range limitAnnotations {
+		additionalAnnotations[key] = value
+	}
+
 	// add ingressclass support to ingress template generation
 	if route.IngressClass != "" {
 		ingress.Spec.IngressClassName = &route.IngressClass
@@ -199,38 +135,8 @@ func GenerateIngressTemplate(
 		}
 	}
 
-	// set up the secretname for tls
-	if route.Autogenerated {
-		// autogenerated use the service name
-		ingress.Spec.TLS = []networkv1.IngressTLS{
-			{
-				SecretName: fmt.Sprintf("%s-tls", route.LagoonService),
-			},
-		}
-	} else {
-		// everything else uses the truncated
-		ingress.Spec.TLS = []networkv1.IngressTLS{
-			{
-				// use the truncated route domain here as we add `-tls`
-				// if a domain that is 253 chars long is used this will then exceed
-				// the 253 char limit on kubernetes names
-				SecretName: fmt.Sprintf("%s-tls", truncatedRouteDomain),
-			},
-		}
-	}
-
-	// autogenerated domains that are too long break when creating the acme challenge k8s resource
-	// this injects a shorter domain into the tls spec that is used in the k8s challenge
-	// use the compose service name to check this, as this is how Services are populated from the compose generation
-	for _, service := range lValues.Services {
-		if service.Name == route.ComposeService {
-			if service.ShortAutogeneratedRouteDomain != "" && len(route.Domain) > 63 {
-				ingress.Spec.TLS[0].Hosts = append(ingress.Spec.TLS[0].Hosts, service.ShortAutogeneratedRouteDomain)
-			}
-		}
-	}
-	// add the main domain to the tls spec now
-	ingress.Spec.TLS[0].Hosts = append(ingress.Spec.TLS[0].Hosts, route.Domain)
+	// set up the secretname and hosts for tls
+	ingress.Spec.TLS = []networkv1.IngressTLS{routeTLS(route, lValues, truncatedRouteDomain)}
 
 	// default service port is http in all lagoon deployments
 	servicePort := networkv1.ServiceBackendPort{
@@ -250,49 +156,72 @@ func GenerateIngressTemplate(
 		}
 	}
 
-	// set up the pathtype prefix for the host rule
+	// set up the paths for the host rules, the route service is always the default path
+	// unless a path based routing rule replaces it
 	pt := networkv1.PathTypePrefix
+	paths := []networkv1.HTTPIngressPath{
+		{
+			Path:     "/",
+			PathType: &pt,
+			Backend: networkv1.IngressBackend{
+				Service: &networkv1.IngressServiceBackend{
+					Name: route.LagoonService,
+					Port: servicePort,
+				},
+			},
+		},
+	}
+	for _, routePath := range route.Paths {
+		pathType := networkv1.PathType(routePath.PathType)
+		// paths use the default http port unless one is provided
+		pathPort := networkv1.ServiceBackendPort{
+			Name: "http",
+		}
+		if routePath.Port != nil {
+			if routePath.Port.Type == intstr.Int {
+				pathPort = networkv1.ServiceBackendPort{
+					Number: routePath.Port.IntVal,
+				}
+			} else {
+				pathPort = networkv1.ServiceBackendPort{
+					Name: routePath.Port.StrVal,
+				}
+			}
+		}
+		ingressPath := networkv1.HTTPIngressPath{
+			Path:     routePath.Path,
+			PathType: &pathType,
+			Backend: networkv1.IngressBackend{
+				Service: &networkv1.IngressServiceBackend{
+					Name: routePath.Service,
+					Port: pathPort,
+				},
+			},
+		}
+		if routePath.Path == "/" && pathType == pt {
+			paths[0] = ingressPath
+		} else {
+			paths = append(paths, ingressPath)
+		}
+	}
 	// add the main domain as the first rule in the spec
 	ingress.Spec.Rules = []networkv1.IngressRule{
 		{
 			Host: route.Domain,
 			IngressRuleValue: networkv1.IngressRuleValue{
 				HTTP: &networkv1.HTTPIngressRuleValue{
-					Paths: []networkv1.HTTPIngressPath{
-						{
-							Path:     "/",
-							PathType: &pt,
-							Backend: networkv1.IngressBackend{
-								Service: &networkv1.IngressServiceBackend{
-									Name: route.LagoonService,
-									Port: servicePort,
-								},
-							},
-						},
-					},
+					Paths: paths,
 				},
 			},
 		},
 	}
 	// check if any alternative names were provided and add them to the spec
 	for _, alternativeName := range route.AlternativeNames {
-		ingress.Spec.TLS[0].Hosts = append(ingress.Spec.TLS[0].Hosts, alternativeName)
 		altName := networkv1.IngressRule{
 			Host: alternativeName,
 			IngressRuleValue: networkv1.IngressRuleValue{
 				HTTP: &networkv1.HTTPIngressRuleValue{
-					Paths: []networkv1.HTTPIngressPath{
-						{
-							Path:     "/",
-							PathType: &pt,
-							Backend: networkv1.IngressBackend{
-								Service: &networkv1.IngressServiceBackend{
-									Name: route.LagoonService,
-									Port: servicePort,
-								},
-							},
-						},
-					},
+					Paths: paths,
 				},
 			},
 		}
@@ -10,6 +10,7 @@ import (
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestGenerateKubeTemplate(t *testing.T) {
@@ -458,6 +459,364 @@ func TestGenerateKubeTemplate(t *testing.T) {
 			},
 			want: "test-resources/result-wildcard-ingress2.yaml",
 		},
+		{
+			name: "test11 - custom ingress with path based routing",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "www.example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					Annotations:    map[string]string{},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					AlternativeNames: []string{"example.com"},
+					IngressName:      "www.example.com",
+					Paths: []lagoon.RoutePath{
+						{
+							Path:     "/api",
+							PathType: "Prefix",
+							Service:  "node",
+							Port:     &intstr.IntOrString{Type: intstr.Int, IntVal: 3000},
+						},
+						{
+							Path:     "/static/logo.png",
+							PathType: "Exact",
+							Service:  "nginx",
+							Port:     &intstr.IntOrString{Type: intstr.String, StrVal: "static"},
+						},
+					},
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			want: "test-resources/result-custom-ingress7.yaml",
+		},
+		{
+			name: "test12 - custom ingress with path based routing replacing the default path",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "www.example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					Annotations:    map[string]string{},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "www.example.com",
+					Paths: []lagoon.RoutePath{
+						{
+							Path:     "/",
+							PathType: "Prefix",
+							Service:  "varnish",
+						},
+						{
+							Path:     "/api",
+							PathType: "Prefix",
+							Service:  "node",
+						},
+					},
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			want: "test-resources/result-custom-ingress8.yaml",
+		},
+		{
+			name: "test13 - custom ingress with a permanent redirect preserving the path",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					Redirect: &lagoon.RouteRedirect{
+						Target:       "https://www.example.com/",
+						StatusCode:   308,
+						PreservePath: true,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			want: "test-resources/result-custom-ingress9.yaml",
+		},
+		{
+			name: "test14 - custom ingress with a temporary redirect",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					Redirect: &lagoon.RouteRedirect{
+						Target:     "https://www.example.com/welcome",
+						StatusCode: 302,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			want: "test-resources/result-custom-ingress10.yaml",
+		},
+		{
+			name: "test15 - custom ingress with a rate limit and maximum body size",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					RateLimit: &lagoon.RouteRateLimit{
+						RPS:             10,
+						Connections:     20,
+						BurstMultiplier: 3,
+					},
+					MaxBodySize: "64m",
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			want: "test-resources/result-custom-ingress11.yaml",
+		},
+		{
+			name: "test16 - custom ingress with an invalid maximum body size",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					MaxBodySize:    "64mb",
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			wantErr: true,
+		},
+		{
+			name: "test17 - custom ingress with a burst multiplier and no rate",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					RateLimit: &lagoon.RouteRateLimit{
+						Connections:     20,
+						BurstMultiplier: 3,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			wantErr: true,
+		},
+		{
+			name: "test18 - custom ingress with a negative rate limit",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					RateLimit: &lagoon.RouteRateLimit{
+						RPM: -60,
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			wantErr: true,
+		},
+		{
+			name: "test19 - custom ingress with security headers, hsts and a configuration snippet",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					HSTSEnabled:    helpers.BoolPtr(true),
+					HSTSMaxAge:     31536000,
+					Headers: &lagoon.RouteHeaders{
+						ContentSecurityPolicy: "default-src 'self'",
+						FrameOptions:          "DENY",
+						ReferrerPolicy:        "strict-origin-when-cross-origin",
+						Custom: map[string]string{
+							"X-Content-Type-Options": "nosniff",
+						},
+					},
+					Annotations: map[string]string{
+						"nginx.ingress.kubernetes.io/configuration-snippet": "add_header Content-type text/html;",
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			want: "test-resources/result-custom-ingress12.yaml",
+		},
+		{
+			name: "test20 - custom ingress with an unsupported frame options header",
+			args: args{
+				route: lagoon.RouteV2{
+					Domain:         "example.com",
+					LagoonService:  "nginx",
+					MonitoringPath: "/",
+					Insecure:       helpers.StrPtr("Redirect"),
+					TLSAcme:        helpers.BoolPtr(true),
+					Migrate:        helpers.BoolPtr(false),
+					Headers: &lagoon.RouteHeaders{
+						FrameOptions: "ALLOWALL",
+					},
+					Fastly: lagoon.Fastly{
+						Watch: false,
+					},
+					IngressName: "example.com",
+				},
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "lagoon.local",
+					Branch:          "environment-name",
+				},
+				activeStandby: false,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
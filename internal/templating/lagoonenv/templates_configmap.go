@@ -0,0 +1,79 @@
+package lagoonenv
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const lagoonEnvConfigMapName = "lagoon-env"
+
+// GenerateLagoonEnvConfigMap generates the lagoon template to apply.
+// the configmap contains all the `runtime` and `global` scoped variables for the environment
+func GenerateLagoonEnvConfigMap(
+	lValues generator.BuildValues,
+	lagoonEnvVars []lagoon.EnvironmentVariable,
+) ([]byte, error) {
+	// create the configmap object for templating
+	configMap := &corev1.ConfigMap{}
+	configMap.TypeMeta = metav1.TypeMeta{
+		Kind:       "ConfigMap",
+		APIVersion: "v1",
+	}
+	configMap.ObjectMeta.Name = lagoonEnvConfigMapName
+
+	// add the default labels
+	configMap.ObjectMeta.Labels = map[string]string{
+		"app.kubernetes.io/name":       lagoonEnvConfigMapName,
+		"app.kubernetes.io/instance":   lagoonEnvConfigMapName,
+		"app.kubernetes.io/managed-by": "build-deploy-tool",
+		"lagoon.sh/project":            lValues.Project,
+		"lagoon.sh/environment":        lValues.Environment,
+		"lagoon.sh/environmentType":    lValues.EnvironmentType,
+		"lagoon.sh/buildType":          lValues.BuildType,
+	}
+
+	// add the default annotations
+	configMap.ObjectMeta.Annotations = map[string]string{
+		"lagoon.sh/version": lValues.LagoonVersion,
+	}
+	if lValues.BuildType == "branch" {
+		configMap.ObjectMeta.Annotations["lagoon.sh/branch"] = lValues.Branch
+	} else if lValues.BuildType == "pullrequest" {
+		configMap.ObjectMeta.Annotations["lagoon.sh/prNumber"] = lValues.PRNumber
+		configMap.ObjectMeta.Annotations["lagoon.sh/prHeadBranch"] = lValues.PRHeadBranch
+		configMap.ObjectMeta.Annotations["lagoon.sh/prBaseBranch"] = lValues.PRBaseBranch
+	}
+
+	// validate any annotations
+	if err := apivalidation.ValidateAnnotations(configMap.ObjectMeta.Annotations, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the annotations for %s are not valid: %v", lagoonEnvConfigMapName, err)
+		}
+	}
+	// validate any labels
+	if err := metavalidation.ValidateLabels(configMap.ObjectMeta.Labels, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the labels for %s are not valid: %v", lagoonEnvConfigMapName, err)
+		}
+	}
+
+	configMap.Data = generator.LagoonEnvConfigMapData(lagoonEnvVars)
+
+	// @TODO: we should review this in the future when we stop doing `kubectl apply` in the builds :)
+	// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+	// of the current build process
+	separator := []byte("---\n")
+	configMapBytes, err := yaml.Marshal(configMap)
+	if err != nil {
+		return nil, err
+	}
+	result := append(separator[:], configMapBytes[:]...)
+	return result, nil
+}
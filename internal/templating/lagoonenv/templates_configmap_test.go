@@ -0,0 +1,86 @@
+package lagoonenv
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func TestGenerateLagoonEnvConfigMap(t *testing.T) {
+	type args struct {
+		values        generator.BuildValues
+		lagoonEnvVars []lagoon.EnvironmentVariable
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - branch environment",
+			args: args{
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+					{Name: "LAGOON_ENVIRONMENT", Value: "environment-name", Scope: "runtime"},
+					{Name: "LAGOON_ROUTE", Value: "https://example.com", Scope: "runtime"},
+					{Name: "MY_GLOBAL_VAR", Value: "global value", Scope: "global"},
+					{Name: "MY_BUILD_VAR", Value: "build value", Scope: "build"},
+				},
+			},
+			want: "test-resources/result-lagoonenv-1.yaml",
+		},
+		{
+			name: "test2 - pullrequest environment",
+			args: args{
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "pr-123",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-pr-123",
+					BuildType:       "pullrequest",
+					PRNumber:        "123",
+					PRHeadBranch:    "feature",
+					PRBaseBranch:    "main",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+				},
+				lagoonEnvVars: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+					{Name: "LAGOON_PR_TITLE", Value: "My PR: a \"quoted\" title", Scope: "runtime"},
+					{Name: "LAGOON_PR_NUMBER", Value: "123", Scope: "runtime"},
+				},
+			},
+			want: "test-resources/result-lagoonenv-2.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateLagoonEnvConfigMap(tt.args.values, tt.args.lagoonEnvVars)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateLagoonEnvConfigMap() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateLagoonEnvConfigMap() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
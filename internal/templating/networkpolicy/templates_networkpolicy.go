@@ -0,0 +1,109 @@
+package networkpolicy
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	networkv1 "k8s.io/api/networking/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const isolationNetworkPolicyName = "isolation-network-policy"
+
+// GenerateNetworkPolicy generates the lagoon template to apply.
+// if the isolation network policy feature is not enabled for the environment, no template is generated
+func GenerateNetworkPolicy(
+	lValues generator.BuildValues,
+) ([]byte, error) {
+	if !lValues.IsolationNetworkPolicy {
+		return nil, nil
+	}
+
+	// create the networkpolicy object for templating
+	networkPolicy := &networkv1.NetworkPolicy{}
+	networkPolicy.TypeMeta = metav1.TypeMeta{
+		Kind:       "NetworkPolicy",
+		APIVersion: "networking.k8s.io/v1",
+	}
+	networkPolicy.ObjectMeta.Name = isolationNetworkPolicyName
+
+	// add the default labels
+	networkPolicy.ObjectMeta.Labels = map[string]string{
+		"helm.sh/chart":                fmt.Sprintf("%s-%s", isolationNetworkPolicyName, "0.1.0"),
+		"app.kubernetes.io/name":       isolationNetworkPolicyName,
+		"app.kubernetes.io/instance":   isolationNetworkPolicyName,
+		"app.kubernetes.io/managed-by": "Helm",
+		"lagoon.sh/service":            isolationNetworkPolicyName,
+		"lagoon.sh/service-type":       isolationNetworkPolicyName,
+		"lagoon.sh/project":            lValues.Project,
+		"lagoon.sh/environment":        lValues.Environment,
+		"lagoon.sh/environmentType":    lValues.EnvironmentType,
+		"lagoon.sh/buildType":          lValues.BuildType,
+	}
+
+	// add the default annotations
+	networkPolicy.ObjectMeta.Annotations = map[string]string{
+		"lagoon.sh/version": lValues.LagoonVersion,
+	}
+	if lValues.BuildType == "branch" {
+		networkPolicy.ObjectMeta.Annotations["lagoon.sh/branch"] = lValues.Branch
+	} else if lValues.BuildType == "pullrequest" {
+		networkPolicy.ObjectMeta.Annotations["lagoon.sh/prNumber"] = lValues.PRNumber
+		networkPolicy.ObjectMeta.Annotations["lagoon.sh/prHeadBranch"] = lValues.PRHeadBranch
+		networkPolicy.ObjectMeta.Annotations["lagoon.sh/prBaseBranch"] = lValues.PRBaseBranch
+	}
+
+	// validate any annotations
+	if err := apivalidation.ValidateAnnotations(networkPolicy.ObjectMeta.Annotations, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the annotations for %s are not valid: %v", isolationNetworkPolicyName, err)
+		}
+	}
+	// validate any labels
+	if err := metavalidation.ValidateLabels(networkPolicy.ObjectMeta.Labels, nil); err != nil {
+		if len(err) != 0 {
+			return nil, fmt.Errorf("the labels for %s are not valid: %v", isolationNetworkPolicyName, err)
+		}
+	}
+
+	networkPolicy.Spec = networkv1.NetworkPolicySpec{
+		// empty podSelector applies this policy to _all_ pods in the current namespace
+		PodSelector: metav1.LabelSelector{},
+		Ingress: []networkv1.NetworkPolicyIngressRule{
+			{
+				From: []networkv1.NetworkPolicyPeer{
+					{
+						// empty ingress podSelector means traffic from _all_ pods in the current
+						// namespace are allowed ingress
+						PodSelector: &metav1.LabelSelector{},
+					},
+					{
+						// allow network traffic from cluster services
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{
+									Key:      "lagoon.sh/environment",
+									Operator: metav1.LabelSelectorOpDoesNotExist,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// @TODO: we should review this in the future when we stop doing `kubectl apply` in the builds :)
+	// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+	// of the current build process
+	separator := []byte("---\n")
+	networkPolicyBytes, err := yaml.Marshal(networkPolicy)
+	if err != nil {
+		return nil, err
+	}
+	result := append(separator[:], networkPolicyBytes[:]...)
+	return result, nil
+}
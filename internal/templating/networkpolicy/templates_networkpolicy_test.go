@@ -0,0 +1,95 @@
+package networkpolicy
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+func TestGenerateNetworkPolicy(t *testing.T) {
+	type args struct {
+		values generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - branch environment",
+			args: args{
+				values: generator.BuildValues{
+					Project:                "example-project",
+					Environment:            "environment-name",
+					EnvironmentType:        "production",
+					Namespace:              "myexample-project-environment-name",
+					BuildType:              "branch",
+					LagoonVersion:          "v2.x.x",
+					Kubernetes:             "generator.local",
+					Branch:                 "environment-name",
+					IsolationNetworkPolicy: true,
+				},
+			},
+			want: "test-resources/result-networkpolicy-1.yaml",
+		},
+		{
+			name: "test2 - pullrequest environment",
+			args: args{
+				values: generator.BuildValues{
+					Project:                "example-project",
+					Environment:            "pr-123",
+					EnvironmentType:        "development",
+					Namespace:              "myexample-project-pr-123",
+					BuildType:              "pullrequest",
+					PRNumber:               "123",
+					PRHeadBranch:           "feature",
+					PRBaseBranch:           "main",
+					LagoonVersion:          "v2.x.x",
+					Kubernetes:             "generator.local",
+					IsolationNetworkPolicy: true,
+				},
+			},
+			want: "test-resources/result-networkpolicy-2.yaml",
+		},
+		{
+			name: "test3 - isolation network policy disabled",
+			args: args{
+				values: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateNetworkPolicy(tt.args.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateNetworkPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Errorf("GenerateNetworkPolicy() = %v, want no template", string(got))
+				}
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateNetworkPolicy() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+)
+
+// deployableServices returns the services that will be deployed, services that share the same `lagoon.name`
+// are deployed as a single service using the values of the first service defined in the docker-compose file
+func deployableServices(buildValues generator.BuildValues) []generator.ServiceValues {
+	services := []generator.ServiceValues{}
+	seen := map[string]bool{}
+	for _, serviceValues := range buildValues.Services {
+		if _, ok := ServiceTypes[serviceValues.Type]; !ok {
+			continue
+		}
+		if seen[serviceValues.OverrideName] {
+			continue
+		}
+		seen[serviceValues.OverrideName] = true
+		services = append(services, serviceValues)
+	}
+	return services
+}
+
+// serviceImage returns the image for a container, if the container requires the image from another service that shares
+// the same `lagoon.name`, then the image for the service with the matching deployment service type is used
+func serviceImage(buildValues generator.BuildValues, serviceValues generator.ServiceValues, container ServiceContainer) string {
+	if container.ImageDeploymentServiceType == "" {
+		return serviceValues.ImageName
+	}
+	for _, sibling := range buildValues.Services {
+		if sibling.OverrideName == serviceValues.OverrideName && sibling.DeploymentServiceType == container.ImageDeploymentServiceType {
+			return sibling.ImageName
+		}
+	}
+	return ""
+}
+
+// selectorLabels are the labels used to select the pods of a service, these must not change
+// as they are immutable on existing deployments
+func selectorLabels(serviceValues generator.ServiceValues, serviceType ServiceType) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     serviceType.Name,
+		"app.kubernetes.io/instance": serviceValues.OverrideName,
+	}
+}
+
+// generateLabels returns the default labels for the resources of a service
+// these are the same labels that the helm chart of the service type adds, so that existing resources aren't changed
+func generateLabels(buildValues generator.BuildValues, serviceValues generator.ServiceValues, serviceType ServiceType) map[string]string {
+	labels := map[string]string{
+		"helm.sh/chart":                fmt.Sprintf("%s-%s", serviceType.Name, "0.1.0"),
+		"app.kubernetes.io/managed-by": "Helm",
+		"lagoon.sh/project":            buildValues.Project,
+		"lagoon.sh/environment":        buildValues.Environment,
+		"lagoon.sh/environmentType":    buildValues.EnvironmentType,
+		"lagoon.sh/buildType":          buildValues.BuildType,
+		"lagoon.sh/service":            serviceValues.OverrideName,
+		"lagoon.sh/service-type":       serviceType.Name,
+	}
+	for key, value := range selectorLabels(serviceValues, serviceType) {
+		labels[key] = value
+	}
+	return labels
+}
+
+// generateAnnotations returns the default annotations for the resources of a service
+func generateAnnotations(buildValues generator.BuildValues) map[string]string {
+	annotations := map[string]string{
+		"lagoon.sh/version": buildValues.LagoonVersion,
+	}
+	if buildValues.BuildType == "branch" {
+		annotations["lagoon.sh/branch"] = buildValues.Branch
+	} else if buildValues.BuildType == "pullrequest" {
+		annotations["lagoon.sh/prNumber"] = buildValues.PRNumber
+		annotations["lagoon.sh/prHeadBranch"] = buildValues.PRHeadBranch
+		annotations["lagoon.sh/prBaseBranch"] = buildValues.PRBaseBranch
+	}
+	return annotations
+}
+
+// validateMetadata validates the labels and annotations of a resource
+func validateMetadata(name string, labels, annotations map[string]string) error {
+	// validate any annotations
+	if err := apivalidation.ValidateAnnotations(annotations, nil); err != nil {
+		if len(err) != 0 {
+			return fmt.Errorf("the annotations for %s are not valid: %v", name, err)
+		}
+	}
+	// validate any labels
+	if err := metavalidation.ValidateLabels(labels, nil); err != nil {
+		if len(err) != 0 {
+			return fmt.Errorf("the labels for %s are not valid: %v", name, err)
+		}
+	}
+	// check length of labels
+	return helpers.CheckLabelLength(labels)
+}
+
+// persistentVolumeName returns the name of the persistent volume claim a service uses
+func persistentVolumeName(serviceValues generator.ServiceValues, serviceType ServiceType) string {
+	if serviceType.PersistentVolumeNameFromName || serviceValues.PersistentVolumeName == "" {
+		return serviceValues.OverrideName
+	}
+	return serviceValues.PersistentVolumeName
+}
+
+// serviceResources merges the resources calculated for a service over the default resources of a container
+func serviceResources(containerResources corev1.ResourceRequirements, serviceValues generator.ServiceValues) corev1.ResourceRequirements {
+	resources := *containerResources.DeepCopy()
+	for name, quantity := range serviceValues.Resources.Limits {
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[name] = quantity
+	}
+	for name, quantity := range serviceValues.Resources.Requests {
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		resources.Requests[name] = quantity
+	}
+	return resources
+}
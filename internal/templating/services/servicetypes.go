@@ -0,0 +1,593 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ServiceType defines how a lagoon service type is templated into kubernetes resources.
+// these were ported from the helm charts that were previously used by the legacy build process
+type ServiceType struct {
+	Name                         string
+	Ports                        []corev1.ServicePort
+	AllowServicePortOverride     bool
+	Strategy                     appsv1.DeploymentStrategyType
+	EnablePriorityClass          bool
+	EnableDatadogAdmission       bool
+	ProvidesPersistentVolume     bool
+	PersistentVolumeNameFromName bool
+	PersistentVolumeAccessMode   corev1.PersistentVolumeAccessMode
+	PersistentVolumeStorageClass string
+	PersistentVolumeBackup       bool
+	RootlessStoragePermissions   bool
+	BackupCommand                string
+	BackupFileExtension          string
+	InitContainers               []corev1.Container
+	Containers                   []ServiceContainer
+	// the name of the container that native cronjobs are based on, the first container is used if this is not set
+	CronjobContainer             string
+	CronjobMountSSHKey           bool
+	CronjobMountPersistentVolume bool
+}
+
+// ServiceContainer defines a container within the deployment of a lagoon service type
+type ServiceContainer struct {
+	// the name of the container, if this is not set, the name of the service is used
+	Name string
+	// the deployment service type to source the image from when a service type has multiple containers
+	ImageDeploymentServiceType string
+	// the lagoon provided environment variables to inject into the container, in order
+	LagoonEnv             []string
+	MountPersistentVolume bool
+	MountTwigStorage      bool
+	MountSSHKey           bool
+	Container             corev1.Container
+}
+
+var defaultResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("10m"),
+		corev1.ResourceMemory: resource.MustParse("10Mi"),
+	},
+}
+
+var defaultCLIResources = corev1.ResourceRequirements{
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("8Gi"),
+	},
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("10m"),
+		corev1.ResourceMemory: resource.MustParse("10Mi"),
+	},
+}
+
+var defaultNodeResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("10m"),
+		corev1.ResourceMemory: resource.MustParse("100Mi"),
+	},
+}
+
+// tcpProbe returns a probe that checks a tcp socket
+func tcpProbe(port intstr.IntOrString, initialDelay, timeout, period, failure int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: port,
+			},
+		},
+		InitialDelaySeconds: initialDelay,
+		TimeoutSeconds:      timeout,
+		PeriodSeconds:       period,
+		FailureThreshold:    failure,
+	}
+}
+
+// httpProbe returns a probe that checks a http path
+func httpProbe(path string, port intstr.IntOrString, initialDelay, timeout, period, failure int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: port,
+			},
+		},
+		InitialDelaySeconds: initialDelay,
+		TimeoutSeconds:      timeout,
+		PeriodSeconds:       period,
+		FailureThreshold:    failure,
+	}
+}
+
+// the readiness probe used by cli and worker type services
+var entrypointReadinessProbe = &corev1.Probe{
+	ProbeHandler: corev1.ProbeHandler{
+		Exec: &corev1.ExecAction{
+			Command: []string{
+				"/bin/sh",
+				"-c",
+				"if [ -x /bin/entrypoint-readiness ]; then\n  /bin/entrypoint-readiness;\nfi\n",
+			},
+		},
+	},
+	InitialDelaySeconds: 5,
+	PeriodSeconds:       2,
+	FailureThreshold:    3,
+}
+
+// the init container used by elasticsearch and opensearch to set the max map count on the node
+var maxMapCountInitContainer = corev1.Container{
+	Name:            "set-max-map-count",
+	Image:           "library/busybox:latest",
+	ImagePullPolicy: corev1.PullAlways,
+	SecurityContext: &corev1.SecurityContext{
+		Privileged: helpers.BoolPtr(true),
+		RunAsUser:  helpers.Int64Ptr(0),
+	},
+	Command: []string{
+		"sh",
+		"-c",
+		"set -xe\nDESIRED=\"262144\"\nCURRENT=$(sysctl -n vm.max_map_count)\nif [ \"$DESIRED\" -gt \"$CURRENT\" ]; then\n  sysctl -w vm.max_map_count=$DESIRED\nfi\n",
+	},
+}
+
+func httpServicePort(port int32) []corev1.ServicePort {
+	return []corev1.ServicePort{
+		{
+			Name:       "http",
+			Port:       port,
+			TargetPort: intstr.FromString("http"),
+			Protocol:   corev1.ProtocolTCP,
+		},
+	}
+}
+
+func tcpServicePort(name string, port int32) corev1.ServicePort {
+	return corev1.ServicePort{
+		Name:       name,
+		Port:       port,
+		TargetPort: intstr.FromInt(int(port)),
+		Protocol:   corev1.ProtocolTCP,
+	}
+}
+
+func httpContainerPort(port int32) []corev1.ContainerPort {
+	return []corev1.ContainerPort{
+		{
+			Name:          "http",
+			ContainerPort: port,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+}
+
+// basicLikeType returns the service type definition used by the basic, node and python services
+func basicLikeType(name string, port int32, persistent bool, resources corev1.ResourceRequirements, readinessDelay, livenessPeriod int32) ServiceType {
+	st := ServiceType{
+		Name:  name,
+		Ports: httpServicePort(port),
+		// only the basic types support changing the service port
+		AllowServicePortOverride: strings.HasPrefix(name, "basic"),
+		EnablePriorityClass:      true,
+		EnableDatadogAdmission:   true,
+		Containers: []ServiceContainer{
+			{
+				Name:                  name,
+				LagoonEnv:             []string{"LAGOON_GIT_SHA", "CRONJOBS"},
+				MountPersistentVolume: persistent,
+				Container: corev1.Container{
+					Ports:          httpContainerPort(port),
+					ReadinessProbe: tcpProbe(intstr.FromInt(int(port)), readinessDelay, 1, 0, 0),
+					LivenessProbe:  tcpProbe(intstr.FromInt(int(port)), 60, 0, livenessPeriod, 0),
+					Resources:      resources,
+				},
+			},
+		},
+	}
+	if persistent {
+		st.ProvidesPersistentVolume = true
+		st.PersistentVolumeAccessMode = corev1.ReadWriteMany
+		st.PersistentVolumeStorageClass = "bulk"
+		st.PersistentVolumeBackup = true
+	}
+	return st
+}
+
+// nginxPHPType returns the service type definition used by the nginx-php services
+func nginxPHPType(name string, persistent bool) ServiceType {
+	st := ServiceType{
+		Name:                   name,
+		Ports:                  httpServicePort(8080),
+		EnablePriorityClass:    true,
+		EnableDatadogAdmission: true,
+		CronjobContainer:       "php",
+		Containers: []ServiceContainer{
+			{
+				Name:                       "nginx",
+				ImageDeploymentServiceType: "nginx",
+				LagoonEnv:                  []string{"LAGOON_GIT_SHA", "CRONJOBS"},
+				MountPersistentVolume:      persistent,
+				Container: corev1.Container{
+					Ports:          httpContainerPort(8080),
+					ReadinessProbe: httpProbe("/nginx_status", intstr.FromInt(50000), 1, 3, 0, 0),
+					LivenessProbe:  httpProbe("/nginx_status", intstr.FromInt(50000), 90, 3, 0, 5),
+					Env: []corev1.EnvVar{
+						{
+							Name:  "NGINX_FASTCGI_PASS",
+							Value: "127.0.0.1",
+						},
+					},
+					Resources: defaultResources,
+				},
+			},
+			{
+				Name:                       "php",
+				ImageDeploymentServiceType: "php",
+				LagoonEnv:                  []string{"LAGOON_GIT_SHA"},
+				MountPersistentVolume:      persistent,
+				MountTwigStorage:           persistent,
+				Container: corev1.Container{
+					Ports: []corev1.ContainerPort{
+						{
+							ContainerPort: 9000,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					ReadinessProbe: tcpProbe(intstr.FromInt(9000), 2, 0, 10, 0),
+					LivenessProbe:  tcpProbe(intstr.FromInt(9000), 60, 0, 10, 0),
+					Env: []corev1.EnvVar{
+						{
+							Name:  "NGINX_FASTCGI_PASS",
+							Value: "127.0.0.1",
+						},
+					},
+					Resources: defaultNodeResources,
+				},
+			},
+		},
+	}
+	if persistent {
+		st.ProvidesPersistentVolume = true
+		st.PersistentVolumeAccessMode = corev1.ReadWriteMany
+		st.PersistentVolumeStorageClass = "bulk"
+		st.PersistentVolumeBackup = true
+		st.RootlessStoragePermissions = true
+	}
+	return st
+}
+
+// varnishType returns the service type definition used by the varnish services
+func varnishType(name string, persistent bool) ServiceType {
+	st := ServiceType{
+		Name: name,
+		Ports: []corev1.ServicePort{
+			{
+				Name:       "http",
+				Port:       8080,
+				TargetPort: intstr.FromString("http"),
+				Protocol:   corev1.ProtocolTCP,
+			},
+			{
+				Name:       "controlport",
+				Port:       6082,
+				TargetPort: intstr.FromString("controlport"),
+				Protocol:   corev1.ProtocolTCP,
+			},
+		},
+		EnablePriorityClass:    true,
+		EnableDatadogAdmission: true,
+		Containers: []ServiceContainer{
+			{
+				Name:                  name,
+				LagoonEnv:             []string{"LAGOON_GIT_SHA"},
+				MountPersistentVolume: persistent,
+				Container: corev1.Container{
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "http",
+							ContainerPort: 8080,
+							Protocol:      corev1.ProtocolTCP,
+						},
+						{
+							Name:          "controlport",
+							ContainerPort: 6082,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					ReadinessProbe: tcpProbe(intstr.FromString("http"), 0, 0, 0, 0),
+					LivenessProbe:  tcpProbe(intstr.FromString("http"), 0, 0, 0, 0),
+					Resources:      defaultResources,
+				},
+			},
+		},
+	}
+	if persistent {
+		st.Strategy = appsv1.RecreateDeploymentStrategyType
+		st.ProvidesPersistentVolume = true
+		st.PersistentVolumeAccessMode = corev1.ReadWriteOnce
+		st.BackupCommand = `/bin/sh -c "/bin/busybox tar -cf - -C %s ."`
+		st.BackupFileExtension = ".%s.tar"
+		st.Containers[0].LagoonEnv = []string{"LAGOON_GIT_SHA", "SERVICE_NAME"}
+	}
+	return st
+}
+
+// redisType returns the service type definition used by the redis services
+func redisType(name string, persistent bool) ServiceType {
+	st := ServiceType{
+		Name: name,
+		Ports: []corev1.ServicePort{
+			tcpServicePort("6379-tcp", 6379),
+		},
+		Containers: []ServiceContainer{
+			{
+				Name:                  name,
+				LagoonEnv:             []string{"CRONJOBS", "LAGOON_GIT_SHA"},
+				MountPersistentVolume: persistent,
+				Container: corev1.Container{
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "6379-tcp",
+							ContainerPort: 6379,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					ReadinessProbe: tcpProbe(intstr.FromInt(6379), 1, 1, 0, 0),
+					LivenessProbe:  tcpProbe(intstr.FromInt(6379), 120, 0, 10, 0),
+					Resources:      defaultResources,
+				},
+			},
+		},
+	}
+	if persistent {
+		st.Strategy = appsv1.RecreateDeploymentStrategyType
+		st.EnablePriorityClass = true
+		st.EnableDatadogAdmission = true
+		st.ProvidesPersistentVolume = true
+		st.PersistentVolumeAccessMode = corev1.ReadWriteOnce
+		st.BackupCommand = `/bin/sh -c "/bin/busybox tar -cf - -C %s ."`
+		st.BackupFileExtension = ".%s.tar"
+	} else {
+		st.Strategy = appsv1.RollingUpdateDeploymentStrategyType
+		st.EnableDatadogAdmission = true
+	}
+	return st
+}
+
+// searchType returns the service type definition used by the elasticsearch and opensearch services
+func searchType(name string) ServiceType {
+	return ServiceType{
+		Name: name,
+		Ports: []corev1.ServicePort{
+			tcpServicePort("9200-tcp", 9200),
+		},
+		Strategy:                   appsv1.RecreateDeploymentStrategyType,
+		EnablePriorityClass:        true,
+		EnableDatadogAdmission:     true,
+		ProvidesPersistentVolume:   true,
+		PersistentVolumeAccessMode: corev1.ReadWriteOnce,
+		BackupCommand:              `/bin/sh -c "tar -cf - -C %s ."`,
+		BackupFileExtension:        ".%s.tar",
+		InitContainers: []corev1.Container{
+			maxMapCountInitContainer,
+		},
+		Containers: []ServiceContainer{
+			{
+				Name:                  name,
+				LagoonEnv:             []string{"CRONJOBS"},
+				MountPersistentVolume: true,
+				Container: corev1.Container{
+					Ports: []corev1.ContainerPort{
+						{
+							ContainerPort: 9200,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					ReadinessProbe: httpProbe("/_cluster/health?local=true", intstr.FromInt(9200), 20, 0, 0, 0),
+					LivenessProbe:  httpProbe("/_cluster/health?local=true", intstr.FromInt(9200), 120, 0, 0, 0),
+					Resources:      defaultResources,
+				},
+			},
+		},
+	}
+}
+
+// singleDatabaseType returns the service type definition used by the mariadb, mongodb and postgres single services
+func singleDatabaseType(name string, port int32, backupCommand, backupFileExtension string, liveness bool) ServiceType {
+	st := ServiceType{
+		Name: name,
+		Ports: []corev1.ServicePort{
+			tcpServicePort(fmt.Sprintf("%d-tcp", port), port),
+		},
+		Strategy:                     appsv1.RecreateDeploymentStrategyType,
+		ProvidesPersistentVolume:     true,
+		PersistentVolumeNameFromName: true,
+		PersistentVolumeAccessMode:   corev1.ReadWriteOnce,
+		BackupCommand:                backupCommand,
+		BackupFileExtension:          backupFileExtension,
+		Containers: []ServiceContainer{
+			{
+				Name:                  name,
+				LagoonEnv:             []string{"CRONJOBS", "LAGOON_GIT_SHA"},
+				MountPersistentVolume: true,
+				Container: corev1.Container{
+					Ports: []corev1.ContainerPort{
+						{
+							ContainerPort: port,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					ReadinessProbe: tcpProbe(intstr.FromInt(int(port)), 1, 1, 0, 0),
+					Resources:      defaultResources,
+				},
+			},
+		},
+	}
+	if liveness {
+		st.Containers[0].Container.LivenessProbe = tcpProbe(intstr.FromInt(int(port)), 120, 0, 5, 0)
+	}
+	return st
+}
+
+// commandType returns the service type definition used by the cli and worker services
+func commandType(name string, persistent, sshKey bool) ServiceType {
+	return ServiceType{
+		Name:                   name,
+		EnablePriorityClass:    true,
+		EnableDatadogAdmission: true,
+		Containers: []ServiceContainer{
+			{
+				LagoonEnv:             []string{"CRONJOBS", "LAGOON_GIT_SHA", "SERVICE_NAME"},
+				MountSSHKey:           sshKey,
+				MountPersistentVolume: persistent,
+				MountTwigStorage:      persistent,
+				Container: corev1.Container{
+					ReadinessProbe: entrypointReadinessProbe,
+					Resources:      defaultCLIResources,
+				},
+			},
+		},
+	}
+}
+
+// tarBackupCommand is the backup command used by services that store files that can be backed up with tar
+var tarBackupCommand = `/bin/sh -c 'tar -cf - -C "%s" --exclude="lost\+found" . || [ $? -eq 1 ]'`
+
+// ServiceTypes is the list of lagoon service types that can be templated
+var ServiceTypes = map[string]ServiceType{
+	"basic":                basicLikeType("basic", 3000, false, defaultResources, 1, 10),
+	"basic-persistent":     withCronjobVolumes(basicLikeType("basic-persistent", 3000, true, defaultResources, 1, 10), false, true),
+	"node":                 basicLikeType("node", 3000, false, defaultNodeResources, 1, 10),
+	"node-persistent":      withCronjobVolumes(basicLikeType("node-persistent", 3000, true, defaultNodeResources, 1, 10), true, true),
+	"python":               basicLikeType("python", 8800, false, defaultResources, 15, 5),
+	"python-persistent":    withCronjobVolumes(basicLikeType("python-persistent", 8800, true, defaultResources, 15, 5), true, true),
+	"nginx":                withCronjobVolumes(nginxType, true, false),
+	"nginx-php":            withCronjobVolumes(nginxPHPType("nginx-php", false), true, false),
+	"nginx-php-persistent": withCronjobVolumes(nginxPHPType("nginx-php-persistent", true), true, true),
+	"varnish":              varnishType("varnish", false),
+	"varnish-persistent":   withCronjobVolumes(varnishType("varnish-persistent", true), false, true),
+	"redis":                redisType("redis", false),
+	"redis-persistent":     withCronjobVolumes(redisType("redis-persistent", true), false, true),
+	"elasticsearch":        searchType("elasticsearch"),
+	"opensearch":           searchType("opensearch"),
+	"solr":                 solrType,
+	"rabbitmq":             rabbitmqType,
+	"mariadb-single": withCronjobVolumes(singleDatabaseType("mariadb-single", 3306,
+		`/bin/sh -c 'mysqldump --max-allowed-packet=500M --events --routines --quick --add-locks --no-autocommit --single-transaction --all-databases'`,
+		".%s.sql", true), false, true),
+	"mongodb-single": withCronjobVolumes(singleDatabaseType("mongodb-single", 27017, tarBackupCommand, ".%s.tar", false), false, true),
+	"postgres-single": withCronjobVolumes(singleDatabaseType("postgres-single", 5432,
+		`/bin/sh -c "PGPASSWORD=$POSTGRES_PASSWORD pg_dump --host=localhost --port=$%s_SERVICE_PORT --dbname=$POSTGRES_DB --username=$POSTGRES_USER --format=t -w"`,
+		".%s.tar", true), false, true),
+	"cli":               withCronjobVolumes(commandType("cli", false, true), true, false),
+	"cli-persistent":    withCronjobVolumes(commandType("cli-persistent", true, true), true, true),
+	"worker":            withCronjobVolumes(commandType("worker", false, false), true, false),
+	"worker-persistent": withCronjobVolumes(commandType("worker-persistent", true, false), true, true),
+}
+
+// withCronjobVolumes sets which volumes are mounted into the native cronjobs of a service type
+func withCronjobVolumes(serviceType ServiceType, sshKey, persistentVolume bool) ServiceType {
+	serviceType.CronjobMountSSHKey = sshKey
+	serviceType.CronjobMountPersistentVolume = persistentVolume
+	return serviceType
+}
+
+var nginxType = ServiceType{
+	Name:                   "nginx",
+	Ports:                  httpServicePort(8080),
+	EnableDatadogAdmission: true,
+	Containers: []ServiceContainer{
+		{
+			Name:      "nginx",
+			LagoonEnv: []string{"LAGOON_GIT_SHA", "CRONJOBS"},
+			Container: corev1.Container{
+				Ports:          httpContainerPort(8080),
+				ReadinessProbe: httpProbe("/nginx_status", intstr.FromInt(50000), 1, 3, 0, 0),
+				LivenessProbe:  httpProbe("/nginx_status", intstr.FromInt(50000), 90, 3, 0, 5),
+				Resources:      defaultResources,
+			},
+		},
+	},
+}
+
+var solrType = ServiceType{
+	Name: "solr",
+	Ports: []corev1.ServicePort{
+		tcpServicePort("tcp-8983", 8983),
+	},
+	Strategy:                   appsv1.RecreateDeploymentStrategyType,
+	EnablePriorityClass:        true,
+	EnableDatadogAdmission:     true,
+	ProvidesPersistentVolume:   true,
+	PersistentVolumeAccessMode: corev1.ReadWriteOnce,
+	BackupCommand:              tarBackupCommand,
+	BackupFileExtension:        ".%s.tar",
+	Containers: []ServiceContainer{
+		{
+			Name:                  "solr",
+			LagoonEnv:             []string{"CRONJOBS"},
+			MountPersistentVolume: true,
+			Container: corev1.Container{
+				Ports: []corev1.ContainerPort{
+					{
+						ContainerPort: 8983,
+						Protocol:      corev1.ProtocolTCP,
+					},
+				},
+				ReadinessProbe: tcpProbe(intstr.FromInt(8983), 1, 0, 3, 0),
+				LivenessProbe:  tcpProbe(intstr.FromInt(8983), 90, 3, 0, 5),
+				Resources:      defaultResources,
+			},
+		},
+	},
+}
+
+var rabbitmqType = ServiceType{
+	Name: "rabbitmq",
+	Ports: []corev1.ServicePort{
+		tcpServicePort("tcp-5672", 5672),
+		tcpServicePort("tcp-15672", 15672),
+	},
+	Strategy:                   appsv1.RecreateDeploymentStrategyType,
+	EnablePriorityClass:        true,
+	EnableDatadogAdmission:     true,
+	ProvidesPersistentVolume:   true,
+	PersistentVolumeAccessMode: corev1.ReadWriteOnce,
+	BackupCommand:              tarBackupCommand,
+	BackupFileExtension:        ".%s.tar",
+	Containers: []ServiceContainer{
+		{
+			Name:                  "rabbitmq",
+			LagoonEnv:             []string{"CRONJOBS"},
+			MountPersistentVolume: true,
+			Container: corev1.Container{
+				Ports: []corev1.ContainerPort{
+					{
+						ContainerPort: 5672,
+						Protocol:      corev1.ProtocolTCP,
+					},
+					{
+						ContainerPort: 15672,
+						Protocol:      corev1.ProtocolTCP,
+					},
+				},
+				ReadinessProbe: tcpProbe(intstr.FromInt(5672), 1, 0, 3, 0),
+				LivenessProbe:  tcpProbe(intstr.FromInt(5672), 90, 3, 0, 5),
+				Env: []corev1.EnvVar{
+					{
+						Name:  "RABBITMQ_NODENAME",
+						Value: "rabbitmq@localhost",
+					},
+				},
+				Resources: defaultResources,
+			},
+		},
+	},
+}
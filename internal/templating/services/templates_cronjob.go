@@ -0,0 +1,224 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// GenerateCronjobTemplate generates the lagoon template to apply.
+func GenerateCronjobTemplate(
+	buildValues generator.BuildValues,
+) ([]byte, error) {
+	separator := []byte("---\n")
+	var result []byte
+
+	for _, serviceValues := range deployableServices(buildValues) {
+		serviceType := ServiceTypes[serviceValues.Type]
+		// sort the cronjobs so the templates are generated in a consistent order
+		cronjobNames := []string{}
+		for cronjobName := range serviceValues.NativeCronjobs {
+			cronjobNames = append(cronjobNames, cronjobName)
+		}
+		sort.Strings(cronjobNames)
+		for _, cronjobName := range cronjobNames {
+			cronjob, err := generateCronjob(buildValues, serviceValues, serviceType, cronjobName, serviceValues.NativeCronjobs[cronjobName])
+			if err != nil {
+				return nil, err
+			}
+			cronjobBytes, err := yaml.Marshal(cronjob)
+			if err != nil {
+				return nil, err
+			}
+			// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+			// of the current build process
+			cronjobResult := append(separator[:], cronjobBytes[:]...)
+			result = append(result, cronjobResult[:]...)
+		}
+	}
+	return result, nil
+}
+
+// CronjobName returns the name of the kubernetes cronjob for a native cronjob of a service
+func CronjobName(serviceName, cronjobName string) string {
+	return fmt.Sprintf("cronjob-%s-%s", serviceName, cronjobName)
+}
+
+func generateCronjob(
+	buildValues generator.BuildValues,
+	serviceValues generator.ServiceValues,
+	serviceType ServiceType,
+	cronjobName string,
+	cronjobValues generator.CronjobValues,
+) (*batchv1.CronJob, error) {
+	name := CronjobName(serviceValues.OverrideName, cronjobName)
+	labels := generateLabels(buildValues, serviceValues, serviceType)
+	annotations := generateAnnotations(buildValues)
+
+	// the pod labels have some additional values
+	podLabels := map[string]string{}
+	for key, value := range labels {
+		podLabels[key] = value
+	}
+	if serviceValues.CronjobUseSpotInstances {
+		podLabels["lagoon.sh/spot"] = "true"
+	}
+
+	// validate the labels and annotations
+	if err := validateMetadata(name, labels, annotations); err != nil {
+		return nil, err
+	}
+	if err := validateMetadata(name, podLabels, annotations); err != nil {
+		return nil, err
+	}
+
+	// the cronjob is based on one of the containers of the service
+	var serviceContainer ServiceContainer
+	for idx, sc := range serviceType.Containers {
+		if idx == 0 || sc.Name == serviceType.CronjobContainer {
+			serviceContainer = sc
+		}
+	}
+
+	podSpec := corev1.PodSpec{
+		SecurityContext:    buildValues.PodSecurityContext,
+		PriorityClassName:  fmt.Sprintf("lagoon-priority-%s", buildValues.EnvironmentType),
+		EnableServiceLinks: helpers.BoolPtr(false),
+		RestartPolicy:      corev1.RestartPolicyNever,
+		DNSConfig: &corev1.PodDNSConfig{
+			Options: []corev1.PodDNSConfigOption{
+				{
+					Name:  "timeout",
+					Value: helpers.StrPtr("60"),
+				},
+				{
+					Name:  "attempts",
+					Value: helpers.StrPtr("10"),
+				},
+			},
+		},
+	}
+	for _, pullSecret := range buildValues.ImagePullSecrets {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{
+			Name: pullSecret,
+		})
+	}
+	if serviceValues.CronjobNodeSelectors != nil {
+		podSpec.NodeSelector = *serviceValues.CronjobNodeSelectors
+	}
+	if serviceValues.CronjobTolerations != nil {
+		podSpec.Tolerations = *serviceValues.CronjobTolerations
+	}
+	if serviceValues.CronjobAffinity != nil {
+		podSpec.Affinity = serviceValues.CronjobAffinity
+	}
+
+	container := corev1.Container{
+		Name:            name,
+		Image:           serviceImage(buildValues, serviceValues, serviceContainer),
+		ImagePullPolicy: corev1.PullAlways,
+		Command: []string{
+			"/lagoon/cronjob.sh",
+			cronjobValues.Command,
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "LAGOON_GIT_SHA",
+				Value: buildValues.GitSha,
+			},
+			{
+				Name:  "SERVICE_NAME",
+				Value: serviceValues.OverrideName,
+			},
+		},
+		EnvFrom: []corev1.EnvFromSource{
+			{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "lagoon-env",
+					},
+				},
+			},
+		},
+		Resources: serviceResources(serviceContainer.Container.Resources, serviceValues),
+	}
+
+	// add any volumes required by the cronjob
+	if serviceType.CronjobMountSSHKey {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "lagoon-sshkey",
+			MountPath: "/var/run/secrets/lagoon/sshkey/",
+			ReadOnly:  true,
+		})
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "lagoon-sshkey",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  "lagoon-sshkey",
+					DefaultMode: helpers.Int32Ptr(420),
+				},
+			},
+		})
+	}
+	if serviceType.CronjobMountPersistentVolume {
+		pvcName := persistentVolumeName(serviceValues, serviceType)
+		if serviceValues.PersistentVolumePath == "" {
+			return nil, fmt.Errorf("the cronjob %s requires a persistent volume, but no persistent volume path has been defined", name)
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      pvcName,
+			MountPath: serviceValues.PersistentVolumePath,
+		})
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: pvcName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvcName,
+				},
+			},
+		})
+	}
+	podSpec.Containers = []corev1.Container{container}
+
+	cronjob := &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CronJob",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   cronjobValues.Schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: helpers.Int32Ptr(0),
+			FailedJobsHistoryLimit:     helpers.Int32Ptr(1),
+			StartingDeadlineSeconds:    helpers.Int64Ptr(240),
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: annotations,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: helpers.Int32Ptr(0),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels:      podLabels,
+							Annotations: annotations,
+						},
+						Spec: podSpec,
+					},
+				},
+			},
+		},
+	}
+	return cronjob, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+func TestGenerateCronjobTemplate(t *testing.T) {
+	type args struct {
+		buildValues generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - cli-persistent and nginx-php-persistent",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					GitSha:          "0",
+					ImagePullSecrets: []string{
+						"lagoon-internal-registry-secret",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "cli",
+							OverrideName:          "cli",
+							Type:                  "cli-persistent",
+							DeploymentServiceType: "cli",
+							ImageName:             "harbor.example.com/example-project/environment-name/cli@latest",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							NativeCronjobs: map[string]generator.CronjobValues{
+								"drush-cron": {
+									Schedule: "18,48 * * * *",
+									Command:  "drush cron",
+								},
+								"cleanup": {
+									Schedule: "48 4 * * *",
+									Command:  "drush cleanup",
+								},
+							},
+							CronjobUseSpotInstances: true,
+						},
+						{
+							Name:                  "nginx",
+							OverrideName:          "nginx-php",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "nginx",
+							ImageName:             "harbor.example.com/example-project/environment-name/nginx@latest",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							PersistentVolumeSize:  "10Gi",
+							NativeCronjobs: map[string]generator.CronjobValues{
+								"nightly": {
+									Schedule: "48 4 * * *",
+									Command:  "php cleanup.php",
+								},
+							},
+						},
+						{
+							Name:                  "php",
+							OverrideName:          "nginx-php",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "php",
+							ImageName:             "harbor.example.com/example-project/environment-name/php@latest",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							PersistentVolumeSize:  "10Gi",
+						},
+						{
+							Name:                  "redis",
+							OverrideName:          "redis",
+							Type:                  "redis",
+							DeploymentServiceType: "redis",
+							ImageName:             "harbor.example.com/example-project/environment-name/redis@latest",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-cronjob-1.yaml",
+		},
+		{
+			name: "test2 - no native cronjobs",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "cli",
+							OverrideName:          "cli",
+							Type:                  "cli",
+							DeploymentServiceType: "cli",
+							ImageName:             "harbor.example.com/example-project/environment-name/cli@latest",
+							InPodCronjobs:         "3,18,33,48 * * * * drush cron",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-cronjob-2.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateCronjobTemplate(tt.args.buildValues)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateCronjobTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateCronjobTemplate() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
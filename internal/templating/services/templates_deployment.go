@@ -0,0 +1,310 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// GenerateDeploymentTemplate generates the lagoon template to apply.
+func GenerateDeploymentTemplate(
+	buildValues generator.BuildValues,
+) ([]byte, error) {
+	separator := []byte("---\n")
+	var result []byte
+
+	for _, serviceValues := range deployableServices(buildValues) {
+		serviceType := ServiceTypes[serviceValues.Type]
+		deployment, err := generateDeployment(buildValues, serviceValues, serviceType)
+		if err != nil {
+			return nil, err
+		}
+		deploymentBytes, err := yaml.Marshal(deployment)
+		if err != nil {
+			return nil, err
+		}
+		// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+		// of the current build process
+		deploymentResult := append(separator[:], deploymentBytes[:]...)
+		result = append(result, deploymentResult[:]...)
+	}
+	return result, nil
+}
+
+func generateDeployment(
+	buildValues generator.BuildValues,
+	serviceValues generator.ServiceValues,
+	serviceType ServiceType,
+) (*appsv1.Deployment, error) {
+	labels := generateLabels(buildValues, serviceValues, serviceType)
+	// the spot label is added to the deployment and its pods, but not the selector as it can change between builds
+	if serviceValues.UseSpotInstances {
+		labels["lagoon.sh/spot"] = "true"
+	}
+	annotations := generateAnnotations(buildValues)
+
+	// the pod labels and annotations have some additional values
+	podLabels := map[string]string{}
+	for key, value := range labels {
+		podLabels[key] = value
+	}
+	if serviceType.EnableDatadogAdmission && buildValues.EnvironmentType == "production" {
+		podLabels["admission.datadoghq.com/enabled"] = "true"
+	}
+	podAnnotations := map[string]string{}
+	for key, value := range annotations {
+		podAnnotations[key] = value
+	}
+	podAnnotations["lagoon.sh/configMapSha"] = buildValues.ConfigMapSha
+
+	// services will only run a single replica unless the generator has determined otherwise
+	replicas := int32(1)
+	if serviceValues.Replicas > 0 {
+		replicas = serviceValues.Replicas
+	}
+
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        serviceValues.OverrideName,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: helpers.Int32Ptr(replicas),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels(serviceValues, serviceType),
+			},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: serviceType.Strategy,
+			},
+		},
+	}
+
+	// handle the persistent storage for the service
+	pvcName := ""
+	if serviceType.ProvidesPersistentVolume || serviceValues.PersistentVolumeName != "" {
+		pvcName = persistentVolumeName(serviceValues, serviceType)
+	}
+	if serviceType.BackupCommand != "" {
+		backupCommand := serviceType.BackupCommand
+		if strings.Contains(backupCommand, "%s") {
+			if serviceValues.Type == "postgres-single" {
+				// the postgres service port variable is derived from the name of the service
+				backupCommand = fmt.Sprintf(backupCommand, strings.ToUpper(regexp.MustCompile(`\W+`).ReplaceAllString(serviceValues.OverrideName, "_")))
+			} else {
+				backupCommand = fmt.Sprintf(backupCommand, serviceValues.PersistentVolumePath)
+			}
+		}
+		podAnnotations["k8up.syn.tools/backupcommand"] = backupCommand
+		podAnnotations["k8up.syn.tools/file-extension"] = fmt.Sprintf(serviceType.BackupFileExtension, serviceValues.OverrideName)
+	}
+
+	// validate the labels and annotations
+	if err := validateMetadata(serviceValues.OverrideName, labels, annotations); err != nil {
+		return nil, err
+	}
+	if err := validateMetadata(serviceValues.OverrideName, podLabels, podAnnotations); err != nil {
+		return nil, err
+	}
+	deployment.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+		Labels:      podLabels,
+		Annotations: podAnnotations,
+	}
+
+	podSpec := corev1.PodSpec{
+		SecurityContext: buildValues.PodSecurityContext,
+	}
+	for _, pullSecret := range buildValues.ImagePullSecrets {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{
+			Name: pullSecret,
+		})
+	}
+	if serviceType.EnablePriorityClass {
+		podSpec.PriorityClassName = fmt.Sprintf("lagoon-priority-%s", buildValues.EnvironmentType)
+		podSpec.EnableServiceLinks = helpers.BoolPtr(false)
+	}
+	if serviceValues.NodeSelectors != nil {
+		podSpec.NodeSelector = *serviceValues.NodeSelectors
+	}
+	if serviceValues.Tolerations != nil {
+		podSpec.Tolerations = *serviceValues.Tolerations
+	}
+	if serviceValues.Affinity != nil {
+		podSpec.Affinity = serviceValues.Affinity
+	}
+
+	// add any init containers
+	for _, initContainer := range serviceType.InitContainers {
+		ic := initContainer.DeepCopy()
+		ic.Image = fmt.Sprintf("%s%s", buildValues.ImageCache, ic.Image)
+		podSpec.InitContainers = append(podSpec.InitContainers, *ic)
+	}
+	// rootless workloads require the permissions on the storage to be fixed up before the pod starts
+	if serviceType.RootlessStoragePermissions && buildValues.PodSecurityContext != nil &&
+		buildValues.PodSecurityContext.FSGroup != nil && *buildValues.PodSecurityContext.FSGroup == 10001 {
+		runAsUser := int64(0)
+		if buildValues.PodSecurityContext.RunAsUser != nil {
+			runAsUser = *buildValues.PodSecurityContext.RunAsUser
+		}
+		podSpec.InitContainers = append(podSpec.InitContainers, corev1.Container{
+			Name:            "fix-storage-permissions",
+			Image:           fmt.Sprintf("%slibrary/busybox:musl", buildValues.ImageCache),
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command: []string{
+				"sh",
+				"-c",
+				fmt.Sprintf(`set -e
+SENTINEL="/storage/.lagoon-rootless-migration-complete"
+if ! [ -f "$SENTINEL" ]; then
+  find /storage -exec chown %d:0 {} +
+  find /storage -exec chmod a+r,u+w {} +
+  find /storage -type d -exec chmod a+x {} +
+  touch "$SENTINEL"
+fi
+`, runAsUser),
+			},
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: helpers.Int64Ptr(0),
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      pvcName,
+					MountPath: "/storage",
+				},
+			},
+		})
+	}
+
+	twigStorageName := fmt.Sprintf("%s-twig", pvcName)
+	addSSHKeyVolume, addPersistentVolume, addTwigVolume := false, false, false
+	for _, serviceContainer := range serviceType.Containers {
+		container := serviceContainer.Container.DeepCopy()
+		container.Name = serviceContainer.Name
+		if container.Name == "" {
+			container.Name = serviceValues.OverrideName
+		}
+		container.Image = serviceImage(buildValues, serviceValues, serviceContainer)
+		container.Resources = serviceResources(serviceContainer.Container.Resources, serviceValues)
+		container.ImagePullPolicy = corev1.PullAlways
+
+		// only some service types support changing the port the service listens on
+		if serviceType.AllowServicePortOverride && serviceValues.ServicePort != 0 {
+			port := intstr.FromInt(int(serviceValues.ServicePort))
+			for idx := range container.Ports {
+				container.Ports[idx].ContainerPort = serviceValues.ServicePort
+			}
+			if container.ReadinessProbe != nil && container.ReadinessProbe.TCPSocket != nil {
+				container.ReadinessProbe.TCPSocket.Port = port
+			}
+			if container.LivenessProbe != nil && container.LivenessProbe.TCPSocket != nil {
+				container.LivenessProbe.TCPSocket.Port = port
+			}
+		}
+
+		// add the lagoon provided environment variables
+		env := []corev1.EnvVar{}
+		for _, lagoonEnv := range serviceContainer.LagoonEnv {
+			switch lagoonEnv {
+			case "LAGOON_GIT_SHA":
+				// LAGOON_GIT_SHA is injected directly and not loaded via `lagoon-env` config
+				// this will cause the pod to redeploy on every deployment, even the files have not changed
+				env = append(env, corev1.EnvVar{Name: lagoonEnv, Value: buildValues.GitSha})
+			case "CRONJOBS":
+				env = append(env, corev1.EnvVar{Name: lagoonEnv, Value: serviceValues.InPodCronjobs})
+			case "SERVICE_NAME":
+				env = append(env, corev1.EnvVar{Name: lagoonEnv, Value: serviceValues.OverrideName})
+			}
+		}
+		container.Env = append(env, container.Env...)
+		container.EnvFrom = []corev1.EnvFromSource{
+			{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "lagoon-env",
+					},
+				},
+			},
+		}
+
+		// add any volume mounts
+		if serviceContainer.MountSSHKey {
+			addSSHKeyVolume = true
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      "lagoon-sshkey",
+				MountPath: "/var/run/secrets/lagoon/sshkey/",
+				ReadOnly:  true,
+			})
+		}
+		if serviceContainer.MountPersistentVolume {
+			if pvcName == "" {
+				return nil, fmt.Errorf("the service %s requires a persistent volume, but no persistent volume name has been defined", serviceValues.OverrideName)
+			}
+			if serviceValues.PersistentVolumePath == "" {
+				return nil, fmt.Errorf("the service %s requires a persistent volume, but no persistent volume path has been defined", serviceValues.OverrideName)
+			}
+			addPersistentVolume = true
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      pvcName,
+				MountPath: serviceValues.PersistentVolumePath,
+			})
+		}
+		if serviceContainer.MountTwigStorage {
+			addTwigVolume = true
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      twigStorageName,
+				MountPath: fmt.Sprintf("%s/php", serviceValues.PersistentVolumePath),
+			})
+		}
+		// dynamic secrets are mounted into all containers of the service
+		container.VolumeMounts = append(container.VolumeMounts, serviceValues.DynamicSecretMounts...)
+		podSpec.Containers = append(podSpec.Containers, *container)
+	}
+
+	// add any volumes required by the containers
+	if addSSHKeyVolume {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "lagoon-sshkey",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  "lagoon-sshkey",
+					DefaultMode: helpers.Int32Ptr(420),
+				},
+			},
+		})
+	}
+	if addPersistentVolume {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: pvcName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvcName,
+				},
+			},
+		})
+	}
+	if addTwigVolume {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: twigStorageName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, serviceValues.DynamicSecretVolumes...)
+
+	deployment.Spec.Template.Spec = podSpec
+	return deployment, nil
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGenerateDeploymentTemplate(t *testing.T) {
+	type args struct {
+		buildValues generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - basic with service port",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					GitSha:          "0",
+					ConfigMapSha:    "32bf1359ac92178c8909f0ef938257b477708aa0d78a5a15ad7c2d7919adf273",
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "myservice",
+							OverrideName:          "myservice",
+							Type:                  "basic",
+							DeploymentServiceType: "myservice",
+							ImageName:             "harbor.example.com/example-project/environment-name/myservice@latest",
+							ServicePort:           8080,
+						},
+					},
+				},
+			},
+			want: "test-resources/result-deployment-basic-1.yaml",
+		},
+		{
+			name: "test2 - nginx-php-persistent and cli-persistent",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					GitSha:          "0",
+					ConfigMapSha:    "32bf1359ac92178c8909f0ef938257b477708aa0d78a5a15ad7c2d7919adf273",
+					ImagePullSecrets: []string{
+						"lagoon-internal-registry-secret",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "cli",
+							OverrideName:          "cli",
+							Type:                  "cli-persistent",
+							DeploymentServiceType: "cli",
+							ImageName:             "harbor.example.com/example-project/environment-name/cli@latest",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							InPodCronjobs:         "M/5 * * * * drush cron",
+						},
+						{
+							Name:                  "nginx",
+							OverrideName:          "nginx-php",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "nginx",
+							ImageName:             "harbor.example.com/example-project/environment-name/nginx@latest",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							PersistentVolumeSize:  "10Gi",
+						},
+						{
+							Name:                  "php",
+							OverrideName:          "nginx-php",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "php",
+							ImageName:             "harbor.example.com/example-project/environment-name/php@latest",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							PersistentVolumeSize:  "10Gi",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-deployment-nginx-php-1.yaml",
+		},
+		{
+			name: "test3 - rootless nginx-php-persistent",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "pullrequest",
+					PRNumber:        "123",
+					PRHeadBranch:    "feature",
+					PRBaseBranch:    "main",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					GitSha:          "0",
+					ImageCache:      "imagecache.example.com/",
+					PodSecurityContext: &corev1.PodSecurityContext{
+						FSGroup:    helpers.Int64Ptr(10001),
+						RunAsGroup: helpers.Int64Ptr(0),
+						RunAsUser:  helpers.Int64Ptr(10000),
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "nginx",
+							OverrideName:          "nginx",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "nginx",
+							ImageName:             "harbor.example.com/example-project/environment-name/nginx@latest",
+							PersistentVolumePath:  "/app/web/sites/default/files/",
+							PersistentVolumeName:  "nginx",
+							PersistentVolumeSize:  "5Gi",
+						},
+						{
+							Name:                  "php",
+							OverrideName:          "nginx",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "php",
+							ImageName:             "harbor.example.com/example-project/environment-name/php@latest",
+							PersistentVolumePath:  "/app/web/sites/default/files/",
+							PersistentVolumeName:  "nginx",
+							PersistentVolumeSize:  "5Gi",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-deployment-nginx-php-2.yaml",
+		},
+		{
+			name: "test4 - databases and search",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					GitSha:          "0",
+					ImageCache:      "imagecache.example.com/",
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "mariadb",
+							OverrideName:          "mariadb",
+							Type:                  "mariadb-single",
+							DeploymentServiceType: "mariadb",
+							ImageName:             "harbor.example.com/example-project/environment-name/mariadb@latest",
+							PersistentVolumePath:  "/var/lib/mysql",
+							PersistentVolumeName:  "mariadb",
+							PersistentVolumeSize:  "5Gi",
+						},
+						{
+							Name:                  "postgres-db",
+							OverrideName:          "postgres-db",
+							Type:                  "postgres-single",
+							DeploymentServiceType: "postgres-db",
+							ImageName:             "harbor.example.com/example-project/environment-name/postgres-db@latest",
+							PersistentVolumePath:  "/var/lib/postgresql/data",
+							PersistentVolumeName:  "postgres-db",
+							PersistentVolumeSize:  "5Gi",
+						},
+						{
+							Name:                  "opensearch",
+							OverrideName:          "opensearch",
+							Type:                  "opensearch",
+							DeploymentServiceType: "opensearch",
+							ImageName:             "harbor.example.com/example-project/environment-name/opensearch@latest",
+							PersistentVolumePath:  "/usr/share/opensearch/data",
+							PersistentVolumeName:  "opensearch",
+							PersistentVolumeSize:  "5Gi",
+						},
+						{
+							Name:                  "mariadb-dbaas",
+							OverrideName:          "mariadb-dbaas",
+							Type:                  "mariadb-dbaas",
+							DeploymentServiceType: "mariadb-dbaas",
+						},
+					},
+				},
+			},
+			want: "test-resources/result-deployment-databases-1.yaml",
+		},
+		{
+			name: "test5 - spot instances with replicas",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					GitSha:          "0",
+					ConfigMapSha:    "32bf1359ac92178c8909f0ef938257b477708aa0d78a5a15ad7c2d7919adf273",
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "nginx",
+							OverrideName:          "nginx",
+							Type:                  "nginx",
+							DeploymentServiceType: "nginx",
+							ImageName:             "harbor.example.com/example-project/environment-name/nginx@latest",
+							UseSpotInstances:      true,
+							Replicas:              2,
+							NodeSelectors:         &map[string]string{"lagoon.sh/spot": "true"},
+							Tolerations: &[]corev1.Toleration{
+								{
+									Key:      "lagoon.sh/spot",
+									Operator: corev1.TolerationOpExists,
+									Effect:   corev1.TaintEffectNoSchedule,
+								},
+								{
+									Key:      "lagoon.sh/spot",
+									Operator: corev1.TolerationOpExists,
+									Effect:   corev1.TaintEffectPreferNoSchedule,
+								},
+							},
+							Affinity: &corev1.Affinity{
+								NodeAffinity: &corev1.NodeAffinity{
+									PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+										{
+											Weight: 1,
+											Preference: corev1.NodeSelectorTerm{
+												MatchExpressions: []corev1.NodeSelectorRequirement{
+													{
+														Key:      "lagoon.sh/spot",
+														Operator: corev1.NodeSelectorOpExists,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: "test-resources/result-deployment-spot-1.yaml",
+		},
+		{
+			name: "test6 - resource overrides",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "development",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					GitSha:          "0",
+					ConfigMapSha:    "32bf1359ac92178c8909f0ef938257b477708aa0d78a5a15ad7c2d7919adf273",
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "node",
+							OverrideName:          "node",
+							Type:                  "node",
+							DeploymentServiceType: "node",
+							ImageName:             "harbor.example.com/example-project/environment-name/node@latest",
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory:           resource.MustParse("8Gi"),
+									corev1.ResourceEphemeralStorage: resource.MustParse("16Gi"),
+								},
+								Requests: corev1.ResourceList{
+									corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: "test-resources/result-deployment-resources-1.yaml",
+		},
+		{
+			name: "test7 - persistent service without a path",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "node",
+							OverrideName:          "node",
+							Type:                  "node-persistent",
+							DeploymentServiceType: "node",
+							PersistentVolumeSize:  "5Gi",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateDeploymentTemplate(tt.args.buildValues)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateDeploymentTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateDeploymentTemplate() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// GeneratePVCTemplate generates the lagoon template to apply.
+func GeneratePVCTemplate(
+	buildValues generator.BuildValues,
+) ([]byte, error) {
+	separator := []byte("---\n")
+	var result []byte
+
+	for _, serviceValues := range deployableServices(buildValues) {
+		serviceType := ServiceTypes[serviceValues.Type]
+		if !serviceType.ProvidesPersistentVolume {
+			continue
+		}
+		pvcName := persistentVolumeName(serviceValues, serviceType)
+		labels := generateLabels(buildValues, serviceValues, serviceType)
+		annotations := generateAnnotations(buildValues)
+		annotations["k8up.syn.tools/backup"] = fmt.Sprintf("%t", serviceType.PersistentVolumeBackup)
+		if err := validateMetadata(pvcName, labels, annotations); err != nil {
+			return nil, err
+		}
+
+		size, err := resource.ParseQuantity(serviceValues.PersistentVolumeSize)
+		if err != nil {
+			return nil, fmt.Errorf("the persistent volume size %s for service %s is not valid: %v", serviceValues.PersistentVolumeSize, serviceValues.OverrideName, err)
+		}
+		// the access mode and storage class calculated by the generator take precedence over the service type defaults
+		accessMode := serviceType.PersistentVolumeAccessMode
+		if serviceValues.PersistentVolumeAccessMode != "" {
+			accessMode = serviceValues.PersistentVolumeAccessMode
+		}
+		storageClass := serviceType.PersistentVolumeStorageClass
+		if serviceValues.PersistentVolumeStorageClass != "" {
+			storageClass = serviceValues.PersistentVolumeStorageClass
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "PersistentVolumeClaim",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        pvcName,
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{
+					accessMode,
+				},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: size,
+					},
+				},
+			},
+		}
+		if storageClass != "" {
+			pvc.Spec.StorageClassName = helpers.StrPtr(storageClass)
+		}
+		pvcBytes, err := yaml.Marshal(pvc)
+		if err != nil {
+			return nil, err
+		}
+		// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+		// of the current build process
+		pvcResult := append(separator[:], pvcBytes[:]...)
+		result = append(result, pvcResult[:]...)
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// GenerateServiceTemplate generates the lagoon template to apply.
+func GenerateServiceTemplate(
+	buildValues generator.BuildValues,
+) ([]byte, error) {
+	separator := []byte("---\n")
+	var result []byte
+
+	for _, serviceValues := range deployableServices(buildValues) {
+		serviceType := ServiceTypes[serviceValues.Type]
+		// not all service types have a service, cli and worker types for example
+		if len(serviceType.Ports) == 0 {
+			continue
+		}
+		labels := generateLabels(buildValues, serviceValues, serviceType)
+		annotations := generateAnnotations(buildValues)
+		if err := validateMetadata(serviceValues.OverrideName, labels, annotations); err != nil {
+			return nil, err
+		}
+
+		ports := servicePorts(serviceValues, serviceType)
+		service := &corev1.Service{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Service",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        serviceValues.OverrideName,
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceTypeClusterIP,
+				Ports:    ports,
+				Selector: selectorLabels(serviceValues, serviceType),
+			},
+		}
+		serviceBytes, err := yaml.Marshal(service)
+		if err != nil {
+			return nil, err
+		}
+		// add the seperator to the template so that it can be `kubectl apply` in bulk as part
+		// of the current build process
+		serviceResult := append(separator[:], serviceBytes[:]...)
+		result = append(result, serviceResult[:]...)
+	}
+	return result, nil
+}
+
+// servicePorts returns the ports of the kubernetes service for a service
+func servicePorts(serviceValues generator.ServiceValues, serviceType ServiceType) []corev1.ServicePort {
+	ports := []corev1.ServicePort{}
+	for idx, port := range serviceType.Ports {
+		// only some service types support changing the port the service listens on
+		if idx == 0 && serviceType.AllowServicePortOverride && serviceValues.ServicePort != 0 {
+			port.Port = serviceValues.ServicePort
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// ServicePortNumber returns the port number of a named port on the kubernetes service for a service
+// this is used by resources that can only reference a service port by its number
+func ServicePortNumber(buildValues generator.BuildValues, serviceName, portName string) (int32, error) {
+	for _, serviceValues := range deployableServices(buildValues) {
+		if serviceValues.OverrideName != serviceName {
+			continue
+		}
+		for _, port := range servicePorts(serviceValues, ServiceTypes[serviceValues.Type]) {
+			if port.Name == portName {
+				return port.Port, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("the service %s does not have a port named %s", serviceName, portName)
+}
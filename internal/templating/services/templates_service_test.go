@@ -0,0 +1,110 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+func TestGenerateServiceTemplate(t *testing.T) {
+	type args struct {
+		buildValues generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - multiple services",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					Services: []generator.ServiceValues{
+						{
+							Name:                  "cli",
+							OverrideName:          "cli",
+							Type:                  "cli-persistent",
+							DeploymentServiceType: "cli",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+						},
+						{
+							Name:                  "nginx",
+							OverrideName:          "nginx-php",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "nginx",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							PersistentVolumeSize:  "10Gi",
+						},
+						{
+							Name:                  "php",
+							OverrideName:          "nginx-php",
+							Type:                  "nginx-php-persistent",
+							DeploymentServiceType: "php",
+							PersistentVolumePath:  "/app/docroot/sites/default/files/",
+							PersistentVolumeName:  "nginx-php",
+							PersistentVolumeSize:  "10Gi",
+						},
+						{
+							Name:                  "mariadb",
+							OverrideName:          "mariadb",
+							Type:                  "mariadb-single",
+							DeploymentServiceType: "mariadb",
+							PersistentVolumePath:  "/var/lib/mysql",
+							PersistentVolumeName:  "mariadb",
+							PersistentVolumeSize:  "5Gi",
+						},
+						{
+							Name:                  "varnish",
+							OverrideName:          "varnish",
+							Type:                  "varnish-persistent",
+							DeploymentServiceType: "varnish",
+							PersistentVolumePath:  "/var/cache/varnish",
+							PersistentVolumeName:  "varnish",
+							PersistentVolumeSize:  "5Gi",
+						},
+						{
+							Name:                  "basic",
+							OverrideName:          "basic",
+							Type:                  "basic",
+							DeploymentServiceType: "basic",
+							ServicePort:           8080,
+						},
+					},
+				},
+			},
+			want: "test-resources/result-service-1.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateServiceTemplate(tt.args.buildValues)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateServiceTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(string(got), string(r1)) {
+				t.Errorf("GenerateServiceTemplate() = %v, want %v", string(got), string(r1))
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ type TestData struct {
 	EnvironmentName       string
 	Branch                string
 	PRNumber              string
+	PRTitle               string
 	PRHeadBranch          string
 	PRBaseBranch          string
 	EnvironmentType       string
@@ -42,6 +43,8 @@ type TestData struct {
 	ControllerDevSchedule string
 	ControllerPRSchedule  string
 	Namespace             string
+	DynamicSecretsFile    string
+	ImageReferencesFile   string
 }
 
 // helper function to set up all the environment variables from provided testdata
@@ -70,6 +73,18 @@ func SetupEnvironment(rootCmd cobra.Command, templatePath string, t TestData) (g
 	if err != nil {
 		return generator.GeneratorInput{}, err
 	}
+	err = os.Setenv("DYNAMIC_SECRETS_FILE", t.DynamicSecretsFile)
+	if err != nil {
+		return generator.GeneratorInput{}, err
+	}
+	err = os.Setenv("IMAGE_REFERENCES_FILE", t.ImageReferencesFile)
+	if err != nil {
+		return generator.GeneratorInput{}, err
+	}
+	err = os.Setenv("PR_TITLE", t.PRTitle)
+	if err != nil {
+		return generator.GeneratorInput{}, err
+	}
 	err = os.Setenv("PR_HEAD_BRANCH", t.PRHeadBranch)
 	if err != nil {
 		return generator.GeneratorInput{}, err
@@ -185,6 +200,15 @@ func GetSeedData(t TestData, defaultProjectVariables bool) TestData {
 	if t.PRNumber != "" {
 		rt.PRNumber = t.PRNumber
 	}
+	if t.DynamicSecretsFile != "" {
+		rt.DynamicSecretsFile = t.DynamicSecretsFile
+	}
+	if t.ImageReferencesFile != "" {
+		rt.ImageReferencesFile = t.ImageReferencesFile
+	}
+	if t.PRTitle != "" {
+		rt.PRTitle = t.PRTitle
+	}
 	if t.PRHeadBranch != "" {
 		rt.PRHeadBranch = t.PRHeadBranch
 	}
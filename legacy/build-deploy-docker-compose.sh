@@ -312,6 +312,29 @@ https://docs.lagoon.sh/using-lagoon-the-basics/lagoon-yml/
 ##############################################"
   exit 1
 fi
+
+##############################################
+### RUN route validation to check that no host is claimed by more than one route
+##############################################
+rvOutput=$(bash -c 'build-deploy-tool validate routes; exit $?' 2>&1)
+rvExit=$?
+
+if [ "${rvExit}" != "0" ]; then
+  currentStepEnd="$(date +"%Y-%m-%d %H:%M:%S")"
+  patchBuildStep "${buildStartTime}" "${previousStepEnd}" "${currentStepEnd}" "${NAMESPACE}" "lagoonYmlValidationError" ".lagoon.yml Validation" "false"
+  previousStepEnd=${currentStepEnd}
+  echo "
+##############################################
+Warning!
+There are conflicts between the routes of this environment that must be fixed.
+A host can only be used once, either as a route or as an alternative name
+##############################################
+"
+  echo "${rvOutput}"
+  echo "
+##############################################"
+  exit 1
+fi
 set -ex
 
 set +x
@@ -1020,91 +1043,25 @@ set -x
 #
 # support for multiple api-secrets is possible in the instance that a customer uses 2 separate services in different accounts in the one project
 
-## any fastly api secrets will be prefixed with this, so that we always add this to whatever the customer provides
-FASTLY_API_SECRET_PREFIX="fastly-api-"
-
-FASTLY_API_SECRETS_COUNTER=0
-FASTLY_API_SECRETS=()
-set +x # reduce noise in build logs
-if [ -n "$(cat .lagoon.yml | shyaml keys fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER 2> /dev/null)" ]; then
-  while [ -n "$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER 2> /dev/null)" ]; do
-    FASTLY_API_SECRET_NAME=$FASTLY_API_SECRET_PREFIX$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER.name 2> /dev/null)
-    if [ -z "$FASTLY_API_SECRET_NAME" ]; then
-        echo -e "A fastly api secret was defined in the .lagoon.yml file, but no name could be found the .lagoon.yml\n\nPlease check if the name has been set correctly."
-        exit 1
-    fi
-    FASTLY_API_TOKEN_VALUE=$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER.apiTokenVariableName false)
-    if [[ $FASTLY_API_TOKEN_VALUE == "false" ]]; then
-      echo "No 'apiTokenVariableName' defined for fastly secret $FASTLY_API_SECRET_NAME"; exit 1;
-    fi
-    # if we have everything we need, we can proceed to logging in
-    if [ $FASTLY_API_TOKEN_VALUE != "false" ]; then
-      FASTLY_API_TOKEN=""
-      # check if we have a password defined anywhere in the api first
-      if [ ! -z "$LAGOON_PROJECT_VARIABLES" ]; then
-        FASTLY_API_TOKEN=($(echo $LAGOON_PROJECT_VARIABLES | jq -r '.[] | select(.scope == "build" and .name == "'$FASTLY_API_TOKEN_VALUE'") | "\(.value)"'))
-      fi
-      if [ ! -z "$LAGOON_ENVIRONMENT_VARIABLES" ]; then
-        TEMP_FASTLY_API_TOKEN=($(echo $LAGOON_ENVIRONMENT_VARIABLES | jq -r '.[] | select(.scope == "build" and .name == "'$FASTLY_API_TOKEN_VALUE'") | "\(.value)"'))
-        if [ ! -z "$TEMP_FASTLY_API_TOKEN" ]; then
-          FASTLY_API_TOKEN=$TEMP_FASTLY_API_TOKEN
-        fi
-      fi
-      if [ -z "$FASTLY_API_TOKEN" ]; then
-        echo -e "A fastly api secret was defined in the .lagoon.yml file, but no token could be found in the Lagoon API matching the variable name provided\n\nPlease check if the token has been set correctly."
-        exit 1
-      fi
-    fi
-    FASTLY_API_PLATFORMTLS_CONFIGURATION=$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER.platformTLSConfiguration "")
-    if [ -z "$FASTLY_API_PLATFORMTLS_CONFIGURATION" ]; then
-      echo -e "A fastly api secret was defined in the .lagoon.yml file, but no platform tls configuration id could be found in the .lagoon.yml\n\nPlease check if the platform tls configuration id has been set correctly."
-      exit 1
-    fi
-
-    # run the script to create the secrets
-    . /kubectl-build-deploy/scripts/exec-fastly-api-secrets.sh
-
-    let FASTLY_API_SECRETS_COUNTER=FASTLY_API_SECRETS_COUNTER+1
-  done
-fi
-set -x
-
-set +x # reduce noise in build logs
 # FASTLY API SECRETS FROM LAGOON API VARIABLE
 # Allow for defining fastly api secrets using lagoon api variables
 # This accepts colon separated values like so `SECRET_NAME:FASTLY_API_TOKEN:FASTLY_PLATFORMTLS_CONFIGURATION_ID`, and multiple overrides
 # separated by commas
 # Example 1: examplecom:x1s8asfafasf7ssf:fa23rsdgsdgas
-# ^^^ will create a kubernetes secret called `$FASTLY_API_SECRET_PREFIX-examplecom` with 2 data fields (one for api token, the other for platform tls id)
+# ^^^ will create a kubernetes secret called `fastly-api-examplecom` with 2 data fields (one for api token, the other for platform tls id)
 # populated with `x1s8asfafasf7ssf` and `fa23rsdgsdgas` for whichever field it should be
-# and the name will get created with the prefix defined in `FASTLY_API_SECRET_PREFIX`
 # Example 2: examplecom:x1s8asfafasf7ssf:fa23rsdgsdgas,example2com:fa23rsdgsdgas:x1s8asfafasf7ssf,example3com:fa23rsdgsdgas:x1s8asfafasf7ssf:example3com
-if [ ! -z "$LAGOON_PROJECT_VARIABLES" ]; then
-  LAGOON_FASTLY_API_SECRETS=($(echo $LAGOON_PROJECT_VARIABLES | jq -r '.[] | select(.name == "LAGOON_FASTLY_API_SECRETS") | "\(.value)"'))
-fi
-if [ ! -z "$LAGOON_ENVIRONMENT_VARIABLES" ]; then
-  TEMP_LAGOON_FASTLY_API_SECRETS=($(echo $LAGOON_ENVIRONMENT_VARIABLES | jq -r '.[] | select(.name == "LAGOON_FASTLY_API_SECRETS") | "\(.value)"'))
-  if [ ! -z $TEMP_LAGOON_FASTLY_API_SECRETS ]; then
-    LAGOON_FASTLY_API_SECRETS=$TEMP_LAGOON_FASTLY_API_SECRETS
-  fi
-fi
-if [ ! -z "$LAGOON_FASTLY_API_SECRETS" ]; then
-  IFS=',' read -ra LAGOON_FASTLY_API_SECRETS_SPLIT <<< "$LAGOON_FASTLY_API_SECRETS"
-  for LAGOON_FASTLY_API_SECRETS_DATA in "${LAGOON_FASTLY_API_SECRETS_SPLIT[@]}"
-  do
-    IFS=':' read -ra LAGOON_FASTLY_API_SECRET_SPLIT <<< "$LAGOON_FASTLY_API_SECRETS_DATA"
-    if [ -z "${LAGOON_FASTLY_API_SECRET_SPLIT[0]}" ] || [ -z "${LAGOON_FASTLY_API_SECRET_SPLIT[1]}" ] || [ -z "${LAGOON_FASTLY_API_SECRET_SPLIT[2]}" ]; then
-      echo -e "An override was defined in the lagoon API with LAGOON_FASTLY_API_SECRETS but was not structured correctly, the format should be NAME:FASTLY_API_TOKEN:FASTLY_PLATFORMTLS_CONFIGURATION_ID and comma separated for multiples"
-      exit 1
-    fi
-    # the fastly api secret name will be created with the prefix that is defined above
-    FASTLY_API_SECRET_NAME=$FASTLY_API_SECRET_PREFIX${LAGOON_FASTLY_API_SECRET_SPLIT[0]}
-    FASTLY_API_TOKEN=${LAGOON_FASTLY_API_SECRET_SPLIT[1]}
-    FASTLY_API_PLATFORMTLS_CONFIGURATION=${LAGOON_FASTLY_API_SECRET_SPLIT[2]}
-    # run the script to create the secrets
-    . /kubectl-build-deploy/scripts/exec-fastly-api-secrets.sh
-  done
-fi
+#
+# any fastly api secrets will be prefixed with `fastly-api-`, and the build will fail if a route references a secret that is not defined
+# this api secret needs to exist before the ingress is created, so the templates are prefixed numerically ahead of any ingresses
+set +x # reduce noise in build logs
+build-deploy-tool template fastly-api-secrets
+set -x
+
+# any routes that use basic auth read the htpasswd content from a lagoon variable, the secrets that hold this content
+# need to exist before the ingress is created, so the templates are prefixed numerically ahead of any ingresses
+set +x # reduce noise in build logs
+build-deploy-tool template basic-auth-secrets
 set -x
 
 set +x # reduce noise in build logs
@@ -1152,28 +1109,13 @@ else
 # end custom route
 fi
 
-# identify any autognerated resources based on their resource name
-AUTOGEN_INGRESS=$(build-deploy-tool identify created-ingress | jq -r '.autogenerated[]')
-AUTOGEN_ROUTES=$(kubectl -n ${NAMESPACE} get ingress --no-headers -l "lagoon.sh/autogenerated=true" | cut -d " " -f 1 | xargs)
-MATCHED_AUTOGEN=false
-DELETE_AUTOGEN=()
-for AR in $AUTOGEN_ROUTES; do
-  for AI in $AUTOGEN_INGRESS; do
-    if [ "${AR}" == "${AI}" ]; then
-      MATCHED_AUTOGEN=true
-      continue
-    fi
-  done
-  if [ "${MATCHED_AUTOGEN}" != "true" ]; then
-    DELETE_AUTOGEN+=($AR)
-  fi
-  MATCHED_AUTOGEN=false
-done
-for DA in ${!DELETE_AUTOGEN[@]}; do
+# identify any autogenerated ingress that are no longer created by this build
+DELETE_AUTOGEN=$(build-deploy-tool identify ingress-cleanup | jq -r '.autogenerated[]')
+for DA in ${DELETE_AUTOGEN}; do
   # delete any autogenerated ingress in the namespace as they are disabled
-  if kubectl -n ${NAMESPACE} get ingress ${DELETE_AUTOGEN[$DA]} &> /dev/null; then
-    echo ">> Removing autogenerated ingress for ${DELETE_AUTOGEN[$DA]} because it was disabled"
-    kubectl -n ${NAMESPACE} delete ingress ${DELETE_AUTOGEN[$DA]}
+  if kubectl -n ${NAMESPACE} get ingress ${DA} &> /dev/null; then
+    echo ">> Removing autogenerated ingress for ${DA} because it was disabled"
+    kubectl -n ${NAMESPACE} delete ingress ${DA}
   fi
 done
 
@@ -1269,45 +1211,15 @@ beginBuildStep "Route/Ingress Cleanup" "cleanupRoutes"
 ##############################################s
 
 set +x
-# collect the current routes excluding any certmanager requests.
-# its also possible to exclude ingress by adding a label 'route.lagoon.sh/remove=false', this is then used to skip this from the removal checks
-CURRENT_ROUTES=$(kubectl -n ${NAMESPACE} get ingress  -l "lagoon.sh/autogenerated!=true"  --no-headers  2> /dev/null | cut -d " " -f 1 | xargs)
-# since label selectors can't be combined properly, this is done so that the build can get all the routes
-# and then remove any that match our conditions to be ignored by the removal checker
-IGNORE_ROUTES=$(kubectl -n ${NAMESPACE} get ingress --no-headers -l "acme.cert-manager.io/http01-solver=true"  2> /dev/null | cut -d " " -f 1 | xargs)
-for SINGLE_ROUTE in ${IGNORE_ROUTES}; do
-  # remove ignored routes from the current routes
-  CURRENT_ROUTES=( "${CURRENT_ROUTES[@]/$SINGLE_ROUTE}" )
-done
-IGNORE_ROUTES=$(kubectl -n ${NAMESPACE} get ingress --no-headers -l "lagoon.sh/remove=false"  2> /dev/null | cut -d " " -f 1 | xargs)
-for SINGLE_ROUTE in ${IGNORE_ROUTES}; do
-  # remove ignored routes from the current routes
-  CURRENT_ROUTES=( "${CURRENT_ROUTES[@]/$SINGLE_ROUTE}" )
-done
-
-# collect the routes that Lagoon thinks it should have based on the .lagoon.yml and any routes that have come from the api
-# using the build-deploy-tool generator
-YAML_ROUTES_TO_JSON=$(build-deploy-tool identify created-ingress | jq -r '.secondary[]')
-
-MATCHED_INGRESS=false
-DELETE_INGRESS=()
-# loop over the routes from kubernetes
-for SINGLE_ROUTE in ${CURRENT_ROUTES}; do
-  # loop over the routes that Lagoon thinks it should have
-  for YAML_ROUTE in ${YAML_ROUTES_TO_JSON}; do
-    if [ "${SINGLE_ROUTE}" == "${YAML_ROUTE}" ]; then
-      MATCHED_INGRESS=true
-      continue
-    fi
-  done
-  if [ "${MATCHED_INGRESS}" != "true" ]; then
-    DELETE_INGRESS+=($SINGLE_ROUTE)
-  fi
-  MATCHED_INGRESS=false
-done
+# collect the custom and active/standby ingress that Lagoon no longer creates based on the .lagoon.yml and any routes that have come from the api
+# ingress used by certmanager requests are excluded, its also possible to exclude ingress by adding a label 'lagoon.sh/remove=false'
+# if the gateway api is enabled all of the ingress are collected, and if it is disabled all of the httproutes are collected
+INGRESS_CLEANUP=$(build-deploy-tool identify ingress-cleanup)
+DELETE_INGRESS=($(echo "${INGRESS_CLEANUP}" | jq -r '.custom[], .activeStandby[]'))
+DELETE_HTTPROUTE=($(echo "${INGRESS_CLEANUP}" | jq -r '.httpRoutes[]'))
 
 CLEANUP_WARNINGS="false"
-if [ ${#DELETE_INGRESS[@]} -ne 0 ]; then
+if [ ${#DELETE_INGRESS[@]} -ne 0 ] || [ ${#DELETE_HTTPROUTE[@]} -ne 0 ]; then
   CLEANUP_WARNINGS="true"
   ((++BUILD_WARNING_COUNT))
   echo ">> Lagoon detected routes that have been removed from the .lagoon.yml or Lagoon API"
@@ -1333,6 +1245,17 @@ if [ ${#DELETE_INGRESS[@]} -ne 0 ]; then
       echo "> The route '${DI}' would be removed"
     fi
   done
+  for DH in ${DELETE_HTTPROUTE[@]}
+  do
+    if [ "$(featureFlag CLEANUP_REMOVED_LAGOON_ROUTES)" = enabled ]; then
+      if kubectl -n ${NAMESPACE} get httproute ${DH} &> /dev/null; then
+        echo ">> Removing httproute ${DH}"
+        kubectl -n ${NAMESPACE} delete httproute ${DH}
+      fi
+    else
+      echo "> The route '${DH}' would be removed"
+    fi
+  done
 else
   echo "No route cleanup required"
 fi
@@ -1433,37 +1356,12 @@ LAGOON_AUTOGENERATED_ROUTES=${AUTOGENERATED_ROUTES}\n\
 " >> /kubectl-build-deploy/values.env
 
 # Generate a Config Map with project wide env variables
-kubectl -n ${NAMESPACE} create configmap lagoon-env -o yaml --dry-run=client --from-env-file=/kubectl-build-deploy/values.env | kubectl apply -n ${NAMESPACE} -f -
-
+# the `lagoon-env` configmap contains the LAGOON_X build variables and any `runtime` or `global` scoped variables from the lagoon API
 set +x # reduce noise in build logs
-# Add environment variables from lagoon API
-if [ ! -z "$LAGOON_PROJECT_VARIABLES" ]; then
-  HAS_PROJECT_RUNTIME_VARS=$(echo $LAGOON_PROJECT_VARIABLES | jq -r 'map( select(.scope == "runtime" or .scope == "global") )')
-
-  if [ ! "$HAS_PROJECT_RUNTIME_VARS" = "[]" ]; then
-    kubectl patch \
-      -n ${NAMESPACE} \
-      configmap lagoon-env \
-      -p "{\"data\":$(echo $LAGOON_PROJECT_VARIABLES | jq -r 'map( select(.scope == "runtime" or .scope == "global") ) | map( { (.name) : .value } ) | add | tostring')}"
-  fi
-fi
-if [ ! -z "$LAGOON_ENVIRONMENT_VARIABLES" ]; then
-  HAS_ENVIRONMENT_RUNTIME_VARS=$(echo $LAGOON_ENVIRONMENT_VARIABLES | jq -r 'map( select(.scope == "runtime" or .scope == "global") )')
-
-  if [ ! "$HAS_ENVIRONMENT_RUNTIME_VARS" = "[]" ]; then
-    kubectl patch \
-      -n ${NAMESPACE} \
-      configmap lagoon-env \
-      -p "{\"data\":$(echo $LAGOON_ENVIRONMENT_VARIABLES | jq -r 'map( select(.scope == "runtime" or .scope == "global") ) | map( { (.name) : .value } ) | add | tostring')}"
-  fi
-fi
-
-if [ "$BUILD_TYPE" == "pullrequest" ]; then
-  kubectl patch \
-    -n ${NAMESPACE} \
-    configmap lagoon-env \
-    -p "{\"data\":{\"LAGOON_PR_HEAD_BRANCH\":\"${PR_HEAD_BRANCH}\", \"LAGOON_PR_BASE_BRANCH\":\"${PR_BASE_BRANCH}\", \"LAGOON_PR_TITLE\":$(echo $PR_TITLE | jq -R)}}"
-fi
+LAGOON_ENV_YAML_FOLDER="/kubectl-build-deploy/lagoon/lagoon-env"
+mkdir -p $LAGOON_ENV_YAML_FOLDER
+build-deploy-tool template lagoon-env --saved-templates-path ${LAGOON_ENV_YAML_FOLDER}
+kubectl apply -n ${NAMESPACE} -f ${LAGOON_ENV_YAML_FOLDER}/
 
 # loop through created DBAAS
 for DBAAS_ENTRY in "${DBAAS[@]}"
@@ -1592,6 +1490,16 @@ elif [ "$BUILD_TYPE" == "promote" ]; then
 
 fi
 
+# write the image hashes to a file so that the build-deploy-tool can resolve the image of each service
+IMAGE_REFERENCES_FILE=/kubectl-build-deploy/image-references.json
+echo "{}" > ${IMAGE_REFERENCES_FILE}
+for IMAGE_NAME in "${!IMAGE_HASHES[@]}"
+do
+  jq --arg name "${IMAGE_NAME}" --arg ref "${IMAGE_HASHES[${IMAGE_NAME}]}" '. + {($name): $ref}' ${IMAGE_REFERENCES_FILE} > ${IMAGE_REFERENCES_FILE}.tmp
+  mv ${IMAGE_REFERENCES_FILE}.tmp ${IMAGE_REFERENCES_FILE}
+done
+export IMAGE_REFERENCES_FILE
+
 set +x
 currentStepEnd="$(date +"%Y-%m-%d %H:%M:%S")"
 patchBuildStep "${buildStartTime}" "${previousStepEnd}" "${currentStepEnd}" "${NAMESPACE}" "imagePushComplete" "Image Push to Registry" "false"
@@ -1649,13 +1557,9 @@ else
   echo ">> Backup configurations disabled for this build"
 fi
 
-# check for ISOLATION_NETWORK_POLICY feature flag, disabled by default
-if [ "$(featureFlag ISOLATION_NETWORK_POLICY)" = enabled ]; then
-	# add namespace isolation network policy to deployment
-	helm template isolation-network-policy /kubectl-build-deploy/helmcharts/isolation-network-policy \
-		-f /kubectl-build-deploy/values.yaml \
-		> $YAML_FOLDER/isolation-network-policy.yaml
-fi
+# add namespace isolation network policy to deployment, this is only generated if the
+# ISOLATION_NETWORK_POLICY feature flag is enabled, disabled by default
+build-deploy-tool template network-policy
 set -x
 
 if [ "$(ls -A $YAML_FOLDER/)" ]; then